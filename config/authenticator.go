@@ -0,0 +1,108 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package config
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+// AuthenticationInput is everything an Authenticator needs to verify the credentials of a request against a
+// single security scheme.
+type AuthenticationInput struct {
+	// Request is the request being validated.
+	Request *http.Request
+
+	// SchemeName is the name of the security scheme, as defined in the components of the document.
+	SchemeName string
+
+	// Scheme is the security scheme being checked.
+	Scheme *v3.SecurityScheme
+
+	// Scopes are the scopes (or roles) listed by the security requirement for this scheme, they may be empty. Scopes
+	// are only checked by an Authenticator, they are not checked for a scheme without one.
+	Scopes []string
+
+	// Context is the context of the validation, an Authenticator that calls out to another service should use it,
+	// so it stops when the validation is cancelled. It is never nil.
+	Context context.Context
+}
+
+// Authenticator verifies the credentials supplied with a request for a security scheme, for example by looking
+// up an API key in a store, checking the signature and expiry of a JWT, or checking basic-auth credentials.
+//
+// Authenticate returns the principal the credentials resolve to when they are valid, which may be nil. A non-nil
+// error means the credentials were rejected, an *AuthenticationError can be returned to control how the failure
+// is reported.
+type Authenticator interface {
+	Authenticate(input *AuthenticationInput) (principal any, err error)
+}
+
+// AuthenticatorFunc allows a plain function to be used as an Authenticator.
+type AuthenticatorFunc func(input *AuthenticationInput) (any, error)
+
+// Authenticate calls f(input).
+func (f AuthenticatorFunc) Authenticate(input *AuthenticationInput) (any, error) {
+	return f(input)
+}
+
+// AuthenticationError is returned by an Authenticator to describe why credentials were rejected. The SubType and
+// HowToFix values are copied into the validation error reported for the security scheme.
+type AuthenticationError struct {
+	// SubType is used as the ValidationSubType of the validation error, for example 'expired'.
+	SubType string
+
+	// Reason describes why the credentials were rejected.
+	Reason string
+
+	// HowToFix is a hint for how to correct the request.
+	HowToFix string
+}
+
+func (e *AuthenticationError) Error() string {
+	return e.Reason
+}
+
+// WithAuthenticator registers an Authenticator for a security scheme. The key is either the name of a security
+// scheme in the components of the document, such as 'ApiKeyAuth', or a security scheme type, such as 'apiKey',
+// 'http', 'oauth2' or 'openIdConnect'. Types are matched ignoring case, and an Authenticator registered for
+// a scheme name is used over one registered for its type. A nil Authenticator removes the registration.
+func WithAuthenticator(nameOrType string, authenticator Authenticator) Option {
+	return func(o *ValidationOptions) {
+		// copy the registry, so options created with WithExistingOpts do not share it.
+		authenticators := make(map[string]Authenticator, len(o.Authenticators)+1)
+		for k, v := range o.Authenticators {
+			authenticators[k] = v
+		}
+		if authenticator == nil {
+			delete(authenticators, nameOrType)
+		} else {
+			authenticators[nameOrType] = authenticator
+		}
+		o.Authenticators = authenticators
+	}
+}
+
+// FindAuthenticator returns the Authenticator registered for a security scheme, looking up the name of the
+// scheme first, then its type. Nil is returned when no Authenticator has been registered.
+func (o *ValidationOptions) FindAuthenticator(schemeName string, scheme *v3.SecurityScheme) Authenticator {
+	if len(o.Authenticators) == 0 {
+		return nil
+	}
+	if a, ok := o.Authenticators[schemeName]; ok {
+		return a
+	}
+	if scheme == nil {
+		return nil
+	}
+	for k, a := range o.Authenticators {
+		if strings.EqualFold(k, scheme.Type) {
+			return a
+		}
+	}
+	return nil
+}
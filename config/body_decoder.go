@@ -0,0 +1,108 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package config
+
+import (
+	"context"
+	"path"
+	"strings"
+
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+)
+
+// BodyDecoder decodes a request or response body into the model used by JSON schema (maps, slices, strings,
+// float64, bool and nil), so it can be validated against the schema of the media type. The schema is supplied for
+// formats that need it to decode values, it may be nil.
+type BodyDecoder interface {
+	Decode(body []byte, schema *base.Schema) (any, error)
+}
+
+// BodyDecoderFunc allows a plain function to be used as a BodyDecoder.
+type BodyDecoderFunc func(body []byte, schema *base.Schema) (any, error)
+
+// Decode calls f(body, schema).
+func (f BodyDecoderFunc) Decode(body []byte, schema *base.Schema) (any, error) {
+	return f(body, schema)
+}
+
+// ContextBodyDecoder is a BodyDecoder that is supplied with the context of the validation, so a slow decoder can
+// stop when the validation is cancelled. DecodeContext is used instead of Decode.
+type ContextBodyDecoder interface {
+	BodyDecoder
+	DecodeContext(ctx context.Context, body []byte, schema *base.Schema) (any, error)
+}
+
+// DecodeBody decodes a body using a BodyDecoder, the context is supplied when the decoder is a ContextBodyDecoder.
+func DecodeBody(ctx context.Context, decoder BodyDecoder, body []byte, schema *base.Schema) (any, error) {
+	if contextDecoder, ok := decoder.(ContextBodyDecoder); ok {
+		return contextDecoder.DecodeContext(ctx, body, schema)
+	}
+	return decoder.Decode(body, schema)
+}
+
+// UnknownMediaTypePolicy controls what happens to a body with a media type that the validator cannot decode.
+type UnknownMediaTypePolicy int
+
+const (
+	// SkipUnknownMediaTypes treats bodies that cannot be decoded as valid, this is the default.
+	SkipUnknownMediaTypes UnknownMediaTypePolicy = iota
+
+	// FailUnknownMediaTypes reports bodies that cannot be decoded as a validation error.
+	FailUnknownMediaTypes
+)
+
+// WithBodyDecoder registers a BodyDecoder for a media type, such as 'application/yaml', or a media type pattern,
+// such as 'application/*+cbor'. Patterns use the syntax of path.Match, so '*' matches within the type or the
+// subtype. A registered decoder is used instead of the built-in JSON, XML, form and multipart decoding. A nil
+// BodyDecoder removes the registration.
+func WithBodyDecoder(mediaTypeOrPattern string, decoder BodyDecoder) Option {
+	return func(o *ValidationOptions) {
+		// copy the registry, so options created with WithExistingOpts do not share it.
+		decoders := make(map[string]BodyDecoder, len(o.BodyDecoders)+1)
+		for k, v := range o.BodyDecoders {
+			decoders[k] = v
+		}
+		key := strings.ToLower(strings.TrimSpace(mediaTypeOrPattern))
+		if decoder == nil {
+			delete(decoders, key)
+		} else {
+			decoders[key] = decoder
+		}
+		o.BodyDecoders = decoders
+	}
+}
+
+// WithUnknownMediaTypePolicy sets what happens to a body with a media type that has no registered BodyDecoder,
+// and is not one of the media types the validator decodes itself.
+func WithUnknownMediaTypePolicy(policy UnknownMediaTypePolicy) Option {
+	return func(o *ValidationOptions) {
+		o.UnknownMediaTypes = policy
+	}
+}
+
+// FindBodyDecoder returns the BodyDecoder registered for a media type. Parameters of the media type are ignored.
+// An exact registration is used over a pattern, and the longest matching pattern is used over shorter ones. Nil
+// is returned when no BodyDecoder has been registered.
+func (o *ValidationOptions) FindBodyDecoder(mediaType string) BodyDecoder {
+	if len(o.BodyDecoders) == 0 {
+		return nil
+	}
+	mediaType, _, _ = strings.Cut(mediaType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	if d, ok := o.BodyDecoders[mediaType]; ok {
+		return d
+	}
+	var found BodyDecoder
+	var longest string
+	for pattern, d := range o.BodyDecoders {
+		if matched, _ := path.Match(pattern, mediaType); !matched {
+			continue
+		}
+		// the longest pattern is the most specific, ties are broken by name, so the result is stable.
+		if found == nil || len(pattern) > len(longest) || (len(pattern) == len(longest) && pattern < longest) {
+			found, longest = d, pattern
+		}
+	}
+	return found
+}
@@ -0,0 +1,252 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package config
+
+import (
+	"context"
+	"log/slog"
+	"os"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+
+	"github.com/pb33f/libopenapi-validator/radix"
+)
+
+// defaultLogger only reports errors, and is shared by all options that are not supplied with a logger.
+var defaultLogger = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+	Level: slog.LevelError,
+}))
+
+// DefaultMultipartMemoryLimit is the number of bytes of a multipart request body that will be read into memory
+// for validation, unless another limit is set using WithMultipartMemoryLimit. It matches the limit used by
+// http.Request.ParseMultipartForm.
+const DefaultMultipartMemoryLimit int64 = 32 << 20
+
+// DefaultMaxRecordSize is the number of bytes a single record of a JSON sequence body, or a single event of an event
+// stream, may have, unless another limit is set using WithMaxRecordSize.
+const DefaultMaxRecordSize int64 = 1 << 20
+
+// ValidationOptions is a container for validation configuration, it is shared by the validator and every
+// sub-validator it creates.
+//
+// Generally, fluent With... style functions are used to establish the desired behavior.
+type ValidationOptions struct {
+	// RegexEngine is the regular expression engine used by the JSON schema compiler for 'pattern' checks.
+	// If nil, the default engine of the schema compiler is used.
+	RegexEngine jsonschema.RegexpEngine
+
+	// FormatAssertions will enforce the 'format' keyword of schemas (uuid, date-time, email etc.), rather than
+	// treating formats as annotations only.
+	FormatAssertions bool
+
+	// ContentAssertions will enforce the 'contentEncoding', 'contentMediaType' and 'contentSchema' keywords.
+	ContentAssertions bool
+
+	// SchemaLoader is used by the JSON schema compiler to resolve remote references. If nil, a loader that
+	// supports 'file', 'http' and 'https' schemes is used. A loader that implements ContextURLLoader is supplied
+	// with the context of the validation.
+	SchemaLoader jsonschema.URLLoader
+
+	// Logger is used to report problems that do not result in a validation error, such as schemas that
+	// fail to compile.
+	Logger *slog.Logger
+
+	// PathTree is the pre-compiled routing table used to locate the path item for a request. Validators will
+	// build a tree from their document when one is not supplied.
+	PathTree *radix.PathTree
+
+	// Authenticators verify the credentials supplied for security schemes, keyed by scheme name or type.
+	// When no Authenticator is registered for a scheme, only the presence of credentials is checked.
+	Authenticators map[string]Authenticator
+
+	// MultipartMemoryLimit is the maximum number of bytes of a 'multipart/form-data' request body that will be
+	// read into memory for validation. Bodies that are larger fail validation. A limit of zero or less
+	// removes the limit.
+	MultipartMemoryLimit int64
+
+	// MaxBodySize is the maximum number of bytes of a request or response body that will be read for validation.
+	// Bodies that are larger fail validation, before they have been fully read. A size of zero or less, the
+	// default, removes the limit.
+	MaxBodySize int64
+
+	// StreamBodies validates JSON sequence and event stream bodies as they are read, without keeping the bytes that
+	// have been read, so a stream of any length is validated in constant memory. The body is consumed by validation
+	// and cannot be read again. By default, the bytes that are read are kept, no more than MaxBodySize, and the body
+	// is restored once it has been validated. An event stream validated with a callback is always consumed.
+	StreamBodies bool
+
+	// MaxRecordSize is the maximum number of bytes of a single record of a JSON sequence body, or a single event of
+	// an event stream. Larger records fail validation, and are skipped without being held in memory. A size of zero
+	// or less removes the limit.
+	MaxRecordSize int64
+
+	// BodyDecoders decode request and response bodies, keyed by media type or media type pattern. They are
+	// used instead of the built-in decoding of JSON, XML, form and multipart bodies.
+	BodyDecoders map[string]BodyDecoder
+
+	// UnknownMediaTypes controls what happens to a body that cannot be decoded, because its media type has no
+	// registered BodyDecoder and is not built-in. By default, such bodies are skipped.
+	UnknownMediaTypes UnknownMediaTypePolicy
+
+	// ContentDecoders decompress request and response bodies before they are validated, keyed by the content
+	// coding of the Content-Encoding header. 'gzip', 'x-gzip' and 'deflate' are registered by default.
+	ContentDecoders map[string]ContentDecoder
+
+	// MaxDecompressedSize is the maximum number of bytes a compressed body may decompress to. Bodies that
+	// decompress to more fail validation, which guards against decompression bombs. A size of zero or less
+	// removes the limit.
+	MaxDecompressedSize int64
+
+	// EagerSchemaCompilation compiles every parameter, request body, response body and response header schema of
+	// the document when the validator is created, rather than when each schema is first used. Schemas that cannot
+	// be compiled are reported by validator.NewValidator.
+	EagerSchemaCompilation bool
+
+	// SchemaCache holds the rendered and compiled schemas of the validator and its sub-validators. Validators
+	// create an LRUSchemaCache of DefaultSchemaCacheSize entries when one is not supplied.
+	SchemaCache SchemaCache
+
+	// Concurrency is the number of workers a validator uses to validate the parameters and body of requests in
+	// parallel, they are shared by every request the validator validates at the same time. When every worker is
+	// busy, validations run on the goroutine of the request. Zero or less, the default, uses the number of CPUs
+	// available.
+	Concurrency int
+}
+
+// ContextURLLoader is a jsonschema.URLLoader that is supplied with the context of the validation that needs the
+// schema. Compiled schemas are shared by every validation, so the context carries the values of the validation
+// that compiled the schema first, but it is never cancelled.
+type ContextURLLoader interface {
+	jsonschema.URLLoader
+	LoadContext(ctx context.Context, url string) (any, error)
+}
+
+// Option enables an 'Options pattern' approach to configuring validators.
+type Option func(*ValidationOptions)
+
+// NewValidationOptions creates a new ValidationOptions instance with default values, then applies any supplied options.
+func NewValidationOptions(opts ...Option) *ValidationOptions {
+	// create the set of default values
+	o := &ValidationOptions{
+		Logger:               defaultLogger,
+		MultipartMemoryLimit: DefaultMultipartMemoryLimit,
+		MaxRecordSize:        DefaultMaxRecordSize,
+		ContentDecoders:      defaultContentDecoders(),
+		MaxDecompressedSize:  DefaultMaxDecompressedSize,
+	}
+
+	// apply any supplied overrides
+	for _, opt := range opts {
+		if opt != nil {
+			opt(o)
+		}
+	}
+	return o
+}
+
+// WithExistingOpts returns an Option that will copy the values from the supplied ValidationOptions instance.
+// This is how the validator hands its own configuration down to the sub-validators it creates.
+func WithExistingOpts(options *ValidationOptions) Option {
+	return func(o *ValidationOptions) {
+		if options != nil {
+			*o = *options
+		}
+	}
+}
+
+// WithRegexEngine assigns a custom regular expression engine to be used during validation.
+func WithRegexEngine(engine jsonschema.RegexpEngine) Option {
+	return func(o *ValidationOptions) {
+		o.RegexEngine = engine
+	}
+}
+
+// WithFormatAssertions enables checks for the 'format' keyword of schemas.
+func WithFormatAssertions() Option {
+	return func(o *ValidationOptions) {
+		o.FormatAssertions = true
+	}
+}
+
+// WithContentAssertions enables checks for the 'contentEncoding', 'contentMediaType' and 'contentSchema' keywords.
+func WithContentAssertions() Option {
+	return func(o *ValidationOptions) {
+		o.ContentAssertions = true
+	}
+}
+
+// WithSchemaLoader sets the loader used to resolve remote schema references, for example, to prevent the
+// validator from making network calls, or to serve schemas from an in-memory store.
+func WithSchemaLoader(loader jsonschema.URLLoader) Option {
+	return func(o *ValidationOptions) {
+		o.SchemaLoader = loader
+	}
+}
+
+// WithLogger sets the logger used by the validator. A nil logger is ignored.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *ValidationOptions) {
+		if logger != nil {
+			o.Logger = logger
+		}
+	}
+}
+
+// WithPathTree sets a pre-compiled routing table to be used when looking up paths. This allows a single
+// tree to be shared by many validators built from the same document.
+func WithPathTree(tree *radix.PathTree) Option {
+	return func(o *ValidationOptions) {
+		o.PathTree = tree
+	}
+}
+
+// WithMaxBodySize sets the maximum number of bytes of a request or response body that will be read for
+// validation. Bodies that are larger fail validation with a 'bodyTooLarge' error. A size of zero or less removes
+// the limit.
+func WithMaxBodySize(size int64) Option {
+	return func(o *ValidationOptions) {
+		o.MaxBodySize = size
+	}
+}
+
+// WithMultipartMemoryLimit sets the maximum number of bytes of a 'multipart/form-data' request body that will be
+// read into memory for validation. A limit of zero or less removes the limit.
+func WithMultipartMemoryLimit(limit int64) Option {
+	return func(o *ValidationOptions) {
+		o.MultipartMemoryLimit = limit
+	}
+}
+
+// WithStreamingBodies validates JSON sequence and event stream bodies as they are read, without keeping the bytes
+// that have been read. The body is consumed by validation, so it cannot be read again by the handler.
+func WithStreamingBodies() Option {
+	return func(o *ValidationOptions) {
+		o.StreamBodies = true
+	}
+}
+
+// WithMaxRecordSize sets the maximum number of bytes of a single record of a JSON sequence body, or a single event
+// of an event stream. A size of zero or less removes the limit.
+func WithMaxRecordSize(size int64) Option {
+	return func(o *ValidationOptions) {
+		o.MaxRecordSize = size
+	}
+}
+
+// WithConcurrency sets the number of workers a validator uses to validate the parameters and body of requests in
+// parallel. A number of zero or less uses the number of CPUs available.
+func WithConcurrency(workers int) Option {
+	return func(o *ValidationOptions) {
+		o.Concurrency = workers
+	}
+}
+
+// WithEagerSchemaCompilation compiles every schema of the document, in parallel, when the validator is created.
+// The first requests are validated as quickly as the rest, and a schema that cannot be compiled fails the creation
+// of the validator, rather than the validation of a request.
+func WithEagerSchemaCompilation() Option {
+	return func(o *ValidationOptions) {
+		o.EagerSchemaCompilation = true
+	}
+}
@@ -0,0 +1,234 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package config
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/stretchr/testify/assert"
+
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+func TestNewValidationOptions_Defaults(t *testing.T) {
+	o := NewValidationOptions()
+	assert.NotNil(t, o)
+	assert.NotNil(t, o.Logger)
+	assert.Nil(t, o.RegexEngine)
+	assert.Nil(t, o.SchemaLoader)
+	assert.False(t, o.FormatAssertions)
+	assert.False(t, o.ContentAssertions)
+	assert.Equal(t, DefaultMultipartMemoryLimit, o.MultipartMemoryLimit)
+	assert.Zero(t, o.MaxBodySize)
+	assert.False(t, o.StreamBodies)
+	assert.Equal(t, DefaultMaxRecordSize, o.MaxRecordSize)
+	assert.Equal(t, SkipUnknownMediaTypes, o.UnknownMediaTypes)
+	assert.Equal(t, DefaultMaxDecompressedSize, o.MaxDecompressedSize)
+	assert.False(t, o.EagerSchemaCompilation)
+	assert.Zero(t, o.Concurrency)
+	assert.NotNil(t, o.FindContentDecoder("gzip"))
+	assert.NotNil(t, o.FindContentDecoder("x-gzip"))
+	assert.NotNil(t, o.FindContentDecoder("deflate"))
+}
+
+func TestNewValidationOptions_WithOptions(t *testing.T) {
+	var engine jsonschema.RegexpEngine = func(s string) (jsonschema.Regexp, error) {
+		return regexp.Compile(s)
+	}
+	loader := jsonschema.SchemeURLLoader{"file": jsonschema.FileLoader{}}
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+
+	o := NewValidationOptions(
+		WithRegexEngine(engine),
+		WithFormatAssertions(),
+		WithContentAssertions(),
+		WithSchemaLoader(loader),
+		WithLogger(logger),
+		WithMultipartMemoryLimit(1024),
+		WithMaxBodySize(2048),
+		WithMaxDecompressedSize(4096),
+		WithEagerSchemaCompilation(),
+		WithConcurrency(3),
+	)
+	assert.NotNil(t, o.RegexEngine)
+	assert.True(t, o.FormatAssertions)
+	assert.True(t, o.ContentAssertions)
+	assert.Equal(t, loader, o.SchemaLoader)
+	assert.Same(t, logger, o.Logger)
+	assert.Equal(t, int64(1024), o.MultipartMemoryLimit)
+	assert.Equal(t, int64(2048), o.MaxBodySize)
+	assert.Equal(t, int64(4096), o.MaxDecompressedSize)
+	assert.True(t, o.EagerSchemaCompilation)
+	assert.Equal(t, 3, o.Concurrency)
+}
+
+func TestNewValidationOptions_NilLoggerIgnored(t *testing.T) {
+	o := NewValidationOptions(WithLogger(nil), nil)
+	assert.NotNil(t, o.Logger)
+}
+
+func TestWithExistingOpts(t *testing.T) {
+	existing := NewValidationOptions(WithFormatAssertions())
+	o := NewValidationOptions(WithExistingOpts(existing), WithContentAssertions())
+	assert.True(t, o.FormatAssertions)
+	assert.True(t, o.ContentAssertions)
+
+	// the original options are left untouched.
+	assert.False(t, existing.ContentAssertions)
+
+	o = NewValidationOptions(WithExistingOpts(nil))
+	assert.NotNil(t, o.Logger)
+}
+
+func TestWithAuthenticator(t *testing.T) {
+	byName := AuthenticatorFunc(func(*AuthenticationInput) (any, error) { return "name", nil })
+	byType := AuthenticatorFunc(func(*AuthenticationInput) (any, error) { return "type", nil })
+
+	existing := NewValidationOptions(WithAuthenticator("ApiKeyAuth", byName))
+	o := NewValidationOptions(WithExistingOpts(existing), WithAuthenticator("apiKey", byType))
+
+	// the registry of the existing options is not modified.
+	assert.Len(t, existing.Authenticators, 1)
+	assert.Len(t, o.Authenticators, 2)
+
+	scheme := &v3.SecurityScheme{Type: "apiKey"}
+	found := o.FindAuthenticator("ApiKeyAuth", scheme)
+	principal, _ := found.Authenticate(&AuthenticationInput{})
+	assert.Equal(t, "name", principal)
+
+	found = o.FindAuthenticator("OtherKey", &v3.SecurityScheme{Type: "APIKEY"})
+	principal, _ = found.Authenticate(&AuthenticationInput{})
+	assert.Equal(t, "type", principal)
+
+	assert.Nil(t, o.FindAuthenticator("BasicAuth", &v3.SecurityScheme{Type: "http"}))
+	assert.Nil(t, NewValidationOptions().FindAuthenticator("ApiKeyAuth", scheme))
+
+	o = NewValidationOptions(WithExistingOpts(o), WithAuthenticator("apiKey", nil))
+	assert.Len(t, o.Authenticators, 1)
+}
+
+func TestAuthenticationError(t *testing.T) {
+	var err error = &AuthenticationError{SubType: "expired", Reason: "the token has expired"}
+	assert.EqualError(t, err, "the token has expired")
+}
+
+func TestWithBodyDecoder(t *testing.T) {
+	decoder := func(name string) BodyDecoder {
+		return BodyDecoderFunc(func([]byte, *base.Schema) (any, error) { return name, nil })
+	}
+	decodedBy := func(d BodyDecoder) any {
+		if d == nil {
+			return nil
+		}
+		v, _ := d.Decode(nil, nil)
+		return v
+	}
+
+	existing := NewValidationOptions(WithBodyDecoder("Application/YAML", decoder("yaml")))
+	o := NewValidationOptions(WithExistingOpts(existing),
+		WithBodyDecoder("application/*", decoder("application")),
+		WithBodyDecoder("application/*+cbor", decoder("cbor")),
+		WithBodyDecoder("*/*", decoder("anything")),
+		WithUnknownMediaTypePolicy(FailUnknownMediaTypes),
+	)
+
+	// the registry of the existing options is not modified.
+	assert.Len(t, existing.BodyDecoders, 1)
+	assert.Len(t, o.BodyDecoders, 4)
+	assert.Equal(t, FailUnknownMediaTypes, o.UnknownMediaTypes)
+
+	assert.Equal(t, "yaml", decodedBy(o.FindBodyDecoder("application/yaml; charset=utf-8")))
+	assert.Equal(t, "cbor", decodedBy(o.FindBodyDecoder("application/vnd.burger+cbor")))
+	assert.Equal(t, "application", decodedBy(o.FindBodyDecoder("application/msgpack")))
+	assert.Equal(t, "anything", decodedBy(o.FindBodyDecoder("text/csv")))
+	assert.Nil(t, NewValidationOptions().FindBodyDecoder("application/yaml"))
+
+	o = NewValidationOptions(WithExistingOpts(o), WithBodyDecoder("*/*", nil))
+	assert.Len(t, o.BodyDecoders, 3)
+	assert.Nil(t, o.FindBodyDecoder("text/csv"))
+}
+
+func TestWithContentDecoder(t *testing.T) {
+	brotli := ContentDecoderFunc(func(io.Reader) (io.Reader, error) { return strings.NewReader("br"), nil })
+
+	existing := NewValidationOptions()
+	o := NewValidationOptions(WithExistingOpts(existing), WithContentDecoder(" BR ", brotli))
+
+	// the registry of the existing options is not modified.
+	assert.Nil(t, existing.FindContentDecoder("br"))
+	assert.Len(t, o.ContentDecoders, len(existing.ContentDecoders)+1)
+
+	reader, err := o.FindContentDecoder("br").NewReader(nil)
+	assert.NoError(t, err)
+	data, _ := io.ReadAll(reader)
+	assert.Equal(t, "br", string(data))
+
+	o = NewValidationOptions(WithExistingOpts(o), WithContentDecoder("gzip", nil))
+	assert.Nil(t, o.FindContentDecoder("gzip"))
+	assert.NotNil(t, o.FindContentDecoder("Deflate"))
+}
+
+type contextDecoder struct {
+	ctx context.Context
+}
+
+func (d *contextDecoder) Decode([]byte, *base.Schema) (any, error) {
+	return "decode", nil
+}
+
+func (d *contextDecoder) DecodeContext(ctx context.Context, _ []byte, _ *base.Schema) (any, error) {
+	d.ctx = ctx
+	return "decodeContext", nil
+}
+
+func (d *contextDecoder) NewReader(io.Reader) (io.Reader, error) {
+	return strings.NewReader("newReader"), nil
+}
+
+func (d *contextDecoder) NewReaderContext(ctx context.Context, _ io.Reader) (io.Reader, error) {
+	d.ctx = ctx
+	return strings.NewReader("newReaderContext"), nil
+}
+
+func TestDecodeBody(t *testing.T) {
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "burger")
+
+	plain := BodyDecoderFunc(func([]byte, *base.Schema) (any, error) { return "plain", nil })
+	decoded, err := DecodeBody(ctx, plain, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "plain", decoded)
+
+	decoder := &contextDecoder{}
+	decoded, err = DecodeBody(ctx, decoder, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "decodeContext", decoded)
+	assert.Equal(t, "burger", decoder.ctx.Value(key{}))
+}
+
+func TestNewDecodingReader(t *testing.T) {
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "burger")
+
+	plain := ContentDecoderFunc(func(io.Reader) (io.Reader, error) { return strings.NewReader("plain"), nil })
+	reader, err := NewDecodingReader(ctx, plain, nil)
+	assert.NoError(t, err)
+	data, _ := io.ReadAll(reader)
+	assert.Equal(t, "plain", string(data))
+
+	decoder := &contextDecoder{}
+	reader, err = NewDecodingReader(ctx, decoder, nil)
+	assert.NoError(t, err)
+	data, _ = io.ReadAll(reader)
+	assert.Equal(t, "newReaderContext", string(data))
+	assert.Equal(t, "burger", decoder.ctx.Value(key{}))
+}
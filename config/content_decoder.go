@@ -0,0 +1,93 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package config
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+	"strings"
+)
+
+// DefaultMaxDecompressedSize is the number of bytes a compressed body may decompress to, unless another limit is
+// set using WithMaxDecompressedSize.
+const DefaultMaxDecompressedSize int64 = 32 << 20
+
+// ContentDecoder decompresses a body that has been encoded with a content coding, such as 'gzip' or 'br'.
+type ContentDecoder interface {
+	NewReader(body io.Reader) (io.Reader, error)
+}
+
+// ContentDecoderFunc allows a plain function to be used as a ContentDecoder.
+type ContentDecoderFunc func(body io.Reader) (io.Reader, error)
+
+// NewReader calls f(body).
+func (f ContentDecoderFunc) NewReader(body io.Reader) (io.Reader, error) {
+	return f(body)
+}
+
+// ContextContentDecoder is a ContentDecoder that is supplied with the context of the validation.
+// NewReaderContext is used instead of NewReader.
+type ContextContentDecoder interface {
+	ContentDecoder
+	NewReaderContext(ctx context.Context, body io.Reader) (io.Reader, error)
+}
+
+// NewDecodingReader creates the reader of a ContentDecoder, the context is supplied when the decoder is a
+// ContextContentDecoder.
+func NewDecodingReader(ctx context.Context, decoder ContentDecoder, body io.Reader) (io.Reader, error) {
+	if contextDecoder, ok := decoder.(ContextContentDecoder); ok {
+		return contextDecoder.NewReaderContext(ctx, body)
+	}
+	return decoder.NewReader(body)
+}
+
+// defaultContentDecoders returns the content codings that can be decoded using the standard library. The 'deflate'
+// coding is the zlib format, as defined by RFC 9110.
+func defaultContentDecoders() map[string]ContentDecoder {
+	gzipDecoder := ContentDecoderFunc(func(body io.Reader) (io.Reader, error) {
+		return gzip.NewReader(body)
+	})
+	return map[string]ContentDecoder{
+		"gzip":   gzipDecoder,
+		"x-gzip": gzipDecoder,
+		"deflate": ContentDecoderFunc(func(body io.Reader) (io.Reader, error) {
+			return zlib.NewReader(body)
+		}),
+	}
+}
+
+// WithContentDecoder registers a ContentDecoder for a content coding of the Content-Encoding header, such as 'br'
+// or 'zstd'. A registered decoder replaces the built-in decoder of the same coding. A nil ContentDecoder removes
+// the registration, so bodies with that coding fail validation.
+func WithContentDecoder(coding string, decoder ContentDecoder) Option {
+	return func(o *ValidationOptions) {
+		// copy the registry, so options created with WithExistingOpts do not share it.
+		decoders := make(map[string]ContentDecoder, len(o.ContentDecoders)+1)
+		for k, v := range o.ContentDecoders {
+			decoders[k] = v
+		}
+		key := strings.ToLower(strings.TrimSpace(coding))
+		if decoder == nil {
+			delete(decoders, key)
+		} else {
+			decoders[key] = decoder
+		}
+		o.ContentDecoders = decoders
+	}
+}
+
+// WithMaxDecompressedSize sets the maximum number of bytes a compressed body may decompress to. A size of zero or
+// less removes the limit.
+func WithMaxDecompressedSize(size int64) Option {
+	return func(o *ValidationOptions) {
+		o.MaxDecompressedSize = size
+	}
+}
+
+// FindContentDecoder returns the ContentDecoder registered for a content coding, or nil if there is none.
+func (o *ValidationOptions) FindContentDecoder(coding string) ContentDecoder {
+	return o.ContentDecoders[strings.ToLower(strings.TrimSpace(coding))]
+}
@@ -0,0 +1,8 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+// Package config contains the options used to tune the behavior of the validator and all of its sub-validators.
+// Options are supplied using the functional options pattern, for example:
+//
+//	v, errs := validator.NewValidator(document, config.WithFormatAssertions(), config.WithLogger(logger))
+package config
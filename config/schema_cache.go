@@ -0,0 +1,159 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package config
+
+import (
+	"container/list"
+	"sync"
+)
+
+// DefaultSchemaCacheSize is the number of entries held by the schema cache a validator creates, when no cache is
+// set using WithSchemaCache.
+const DefaultSchemaCacheSize = 10000
+
+// SchemaCache holds the rendered and compiled schemas of validators, so the work is only performed once per schema.
+// Keys are comparable values of a type that belongs to the sub-validator that stores them, so a single cache can be
+// shared by every sub-validator. Implementations must be safe for concurrent use, a *sync.Map can be used as a cache
+// that never evicts entries.
+//
+// A cache can also be shared by validators built from different documents, so a schema that appears in more than
+// one document is only compiled once. The compiled form of a schema depends on the options it was compiled with,
+// so validators that share a cache must use the same regex engine, schema loader, format and content assertions.
+type SchemaCache interface {
+	// Load returns the value stored for a key, and true if a value was found.
+	Load(key any) (value any, ok bool)
+
+	// LoadOrStore returns the value stored for a key, and true, if there is one. Otherwise, it stores and returns
+	// the supplied value, and false.
+	LoadOrStore(key, value any) (actual any, loaded bool)
+
+	// Store sets the value for a key.
+	Store(key, value any)
+
+	// Clear removes every entry from the cache.
+	Clear()
+}
+
+// SchemaCacheStats is a snapshot of the counters of an LRUSchemaCache.
+type SchemaCacheStats struct {
+	// Hits is the number of lookups that found an entry.
+	Hits uint64
+	// Misses is the number of lookups that did not find an entry.
+	Misses uint64
+	// Evictions is the number of entries removed to make room for new entries.
+	Evictions uint64
+	// Entries is the number of entries in the cache.
+	Entries int
+}
+
+// LRUSchemaCache is the default SchemaCache. It holds a limited number of entries, when it is full the least
+// recently used entry is evicted to make room for a new one.
+type LRUSchemaCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[any]*list.Element
+	order   *list.List // most recently used first.
+	stats   SchemaCacheStats
+}
+
+type lruEntry struct {
+	key   any
+	value any
+}
+
+// NewLRUSchemaCache creates an LRUSchemaCache that holds up to size entries. A size of zero or less removes the
+// limit, so entries are never evicted.
+func NewLRUSchemaCache(size int) *LRUSchemaCache {
+	return &LRUSchemaCache{
+		size:    size,
+		entries: make(map[any]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// Load returns the value stored for a key, and true if a value was found. The entry becomes the most recently used.
+func (c *LRUSchemaCache) Load(key any) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.load(key)
+}
+
+// LoadOrStore returns the value stored for a key, and true, if there is one. Otherwise, it stores and returns the
+// supplied value, and false.
+func (c *LRUSchemaCache) LoadOrStore(key, value any) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if actual, ok := c.load(key); ok {
+		return actual, true
+	}
+	c.store(key, value)
+	return value, false
+}
+
+// Store sets the value for a key, the entry becomes the most recently used.
+func (c *LRUSchemaCache) Store(key, value any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.store(key, value)
+}
+
+// Clear removes every entry from the cache. The hit, miss and eviction counters are not reset.
+func (c *LRUSchemaCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[any]*list.Element)
+	c.order.Init()
+}
+
+// Len returns the number of entries in the cache.
+func (c *LRUSchemaCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// Stats returns the counters of the cache.
+func (c *LRUSchemaCache) Stats() SchemaCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Entries = c.order.Len()
+	return stats
+}
+
+func (c *LRUSchemaCache) load(key any) (any, bool) {
+	element, ok := c.entries[key]
+	if !ok {
+		c.stats.Misses++
+		return nil, false
+	}
+	c.stats.Hits++
+	c.order.MoveToFront(element)
+	return element.Value.(*lruEntry).value, true
+}
+
+func (c *LRUSchemaCache) store(key, value any) {
+	if element, ok := c.entries[key]; ok {
+		element.Value.(*lruEntry).value = value
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&lruEntry{key: key, value: value})
+	for c.size > 0 && c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry).key)
+		c.stats.Evictions++
+	}
+}
+
+// WithSchemaCache sets the cache used to hold rendered and compiled schemas. The cache is shared by every
+// sub-validator, and can be shared by many validators, see SchemaCache. A nil cache is ignored.
+func WithSchemaCache(cache SchemaCache) Option {
+	return func(o *ValidationOptions) {
+		if cache != nil {
+			o.SchemaCache = cache
+		}
+	}
+}
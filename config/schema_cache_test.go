@@ -0,0 +1,114 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package config
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUSchemaCache(t *testing.T) {
+	cache := NewLRUSchemaCache(2)
+
+	_, ok := cache.Load("a")
+	assert.False(t, ok)
+	cache.Store("a", 1)
+	cache.Store("b", 2)
+
+	value, ok := cache.Load("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+
+	// 'b' is the least recently used, so it is evicted to make room.
+	cache.Store("c", 3)
+	assert.Equal(t, 2, cache.Len())
+	_, ok = cache.Load("b")
+	assert.False(t, ok)
+	value, ok = cache.Load("c")
+	assert.True(t, ok)
+	assert.Equal(t, 3, value)
+
+	assert.Equal(t, SchemaCacheStats{Hits: 2, Misses: 2, Evictions: 1, Entries: 2}, cache.Stats())
+}
+
+func TestLRUSchemaCache_LoadOrStore(t *testing.T) {
+	cache := NewLRUSchemaCache(10)
+
+	actual, loaded := cache.LoadOrStore("a", 1)
+	assert.False(t, loaded)
+	assert.Equal(t, 1, actual)
+
+	actual, loaded = cache.LoadOrStore("a", 2)
+	assert.True(t, loaded)
+	assert.Equal(t, 1, actual)
+
+	// store replaces the value.
+	cache.Store("a", 3)
+	actual, loaded = cache.LoadOrStore("a", 4)
+	assert.True(t, loaded)
+	assert.Equal(t, 3, actual)
+	assert.Equal(t, 1, cache.Len())
+}
+
+func TestLRUSchemaCache_Clear(t *testing.T) {
+	cache := NewLRUSchemaCache(10)
+	cache.Store("a", 1)
+	cache.Store("b", 2)
+	cache.Load("a")
+
+	cache.Clear()
+	assert.Zero(t, cache.Len())
+	_, ok := cache.Load("a")
+	assert.False(t, ok)
+
+	// the counters are kept.
+	assert.Equal(t, SchemaCacheStats{Hits: 1, Misses: 1}, cache.Stats())
+}
+
+func TestLRUSchemaCache_Unbounded(t *testing.T) {
+	cache := NewLRUSchemaCache(0)
+	for i := range 100 {
+		cache.Store(i, i)
+	}
+	assert.Equal(t, 100, cache.Len())
+	assert.Zero(t, cache.Stats().Evictions)
+}
+
+func TestLRUSchemaCache_Concurrent(t *testing.T) {
+	cache := NewLRUSchemaCache(50)
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 100 {
+				key := fmt.Sprintf("%d-%d", i, j%60)
+				cache.LoadOrStore(key, j)
+				cache.Load(key)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 50, cache.Len())
+	stats := cache.Stats()
+	assert.Equal(t, uint64(2000), stats.Hits+stats.Misses)
+}
+
+func TestWithSchemaCache(t *testing.T) {
+	cache := NewLRUSchemaCache(10)
+	o := NewValidationOptions(WithSchemaCache(cache))
+	assert.Same(t, cache, o.SchemaCache)
+
+	// a nil cache is ignored.
+	o = NewValidationOptions(WithSchemaCache(cache), WithSchemaCache(nil))
+	assert.Same(t, cache, o.SchemaCache)
+	assert.Nil(t, NewValidationOptions().SchemaCache)
+
+	var unbounded SchemaCache = &sync.Map{}
+	o = NewValidationOptions(WithSchemaCache(unbounded))
+	assert.Same(t, unbounded, o.SchemaCache)
+}
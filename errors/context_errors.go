@@ -0,0 +1,53 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package errors
+
+import (
+	"context"
+	stdErrors "errors"
+	"net/http"
+
+	"github.com/pb33f/libopenapi-validator/helpers"
+)
+
+// ContextError reports a validation that was stopped because its context was cancelled, or its deadline passed.
+// The context error is held as the Context of the validation error, and is returned by Unwrap.
+func ContextError(request *http.Request, err error) *ValidationError {
+	subType, message := helpers.ContextCanceled, "Validation was cancelled before it finished"
+	if stdErrors.Is(err, context.DeadlineExceeded) {
+		subType, message = helpers.ContextDeadlineExceeded, "Validation did not finish before its deadline"
+	}
+	validationError := &ValidationError{
+		ValidationType:    helpers.ContextValidation,
+		ValidationSubType: subType,
+		Message:           message,
+		Reason:            "The validation was stopped by its context: " + err.Error(),
+		SpecLine:          1,
+		SpecCol:           0,
+		HowToFix:          HowToFixContextDone,
+		Context:           err,
+	}
+	if request != nil {
+		validationError.RequestPath = request.URL.Path
+		validationError.RequestMethod = request.Method
+	}
+	return validationError
+}
+
+// ValidateWithContext runs a validation, unless its context is already done. The result of a validation that
+// finishes after its context is done may be incomplete, so it is replaced by a single ContextError.
+func ValidateWithContext(
+	ctx context.Context,
+	request *http.Request,
+	validate func() (bool, []*ValidationError),
+) (bool, []*ValidationError) {
+	if err := ctx.Err(); err != nil {
+		return false, []*ValidationError{ContextError(request, err)}
+	}
+	valid, validationErrors := validate()
+	if err := ctx.Err(); err != nil {
+		return false, []*ValidationError{ContextError(request, err)}
+	}
+	return valid, validationErrors
+}
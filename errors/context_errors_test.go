@@ -0,0 +1,74 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package errors
+
+import (
+	"context"
+	stdErrors "errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pb33f/libopenapi-validator/helpers"
+)
+
+func TestContextError(t *testing.T) {
+	request, _ := http.NewRequest(http.MethodGet, "https://things.com/burgers", nil)
+
+	err := ContextError(request, context.Canceled)
+	assert.True(t, err.IsContextError())
+	assert.Equal(t, helpers.ContextCanceled, err.ValidationSubType)
+	assert.Equal(t, "Validation was cancelled before it finished", err.Message)
+	assert.Equal(t, "/burgers", err.RequestPath)
+	assert.Equal(t, http.MethodGet, err.RequestMethod)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	err = ContextError(nil, context.DeadlineExceeded)
+	assert.Equal(t, helpers.ContextDeadlineExceeded, err.ValidationSubType)
+	assert.Equal(t, "Validation did not finish before its deadline", err.Message)
+	assert.Empty(t, err.RequestPath)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// other validation errors do not wrap anything.
+	other := &ValidationError{ValidationType: helpers.RequestBodyValidation, Context: context.Canceled}
+	assert.False(t, other.IsContextError())
+	assert.Nil(t, other.Unwrap())
+	assert.False(t, stdErrors.Is(other, context.Canceled))
+}
+
+func TestValidateWithContext(t *testing.T) {
+	request, _ := http.NewRequest(http.MethodGet, "https://things.com/burgers", nil)
+	failed := []*ValidationError{{Message: "failed"}}
+
+	valid, errs := ValidateWithContext(context.Background(), request, func() (bool, []*ValidationError) {
+		return false, failed
+	})
+	assert.False(t, valid)
+	assert.Equal(t, failed, errs)
+
+	// a context that is already done, the validation is not run.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	called := false
+	valid, errs = ValidateWithContext(ctx, request, func() (bool, []*ValidationError) {
+		called = true
+		return true, nil
+	})
+	assert.False(t, called)
+	assert.False(t, valid)
+	require.Len(t, errs, 1)
+	assert.ErrorIs(t, errs[0], context.Canceled)
+
+	// a context that is done during the validation, the result is replaced.
+	ctx, cancel = context.WithCancel(context.Background())
+	valid, errs = ValidateWithContext(ctx, request, func() (bool, []*ValidationError) {
+		cancel()
+		return false, failed
+	})
+	assert.False(t, valid)
+	require.Len(t, errs, 1)
+	assert.True(t, errs[0].IsContextError())
+}
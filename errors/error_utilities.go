@@ -1,7 +1,10 @@
 package errors
 
 import (
+	"fmt"
 	"net/http"
+
+	"github.com/pb33f/libopenapi-validator/helpers"
 )
 
 // PopulateValidationErrors mutates the provided validation errors with additional useful error information, that is
@@ -14,3 +17,14 @@ func PopulateValidationErrors(validationErrors []*ValidationError, request *http
 		validationError.RequestPath = request.URL.Path
 	}
 }
+
+// howToFixBodyTooLarge explains which limit a body that is too large has exceeded, and how to raise it.
+func howToFixBodyTooLarge(tooLarge *helpers.BodyTooLargeError) string {
+	if tooLarge.Decompressed {
+		return fmt.Sprintf(HowToFixDecompressedSize, tooLarge.Limit)
+	}
+	if tooLarge.Multipart {
+		return fmt.Sprintf(HowToFixMultipartLimit, tooLarge.Limit)
+	}
+	return fmt.Sprintf(HowToFixBodyTooLarge, tooLarge.Limit)
+}
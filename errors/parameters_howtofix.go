@@ -21,6 +21,15 @@ const (
 	HowToFixInvalidJSON         string = "The JSON submitted is invalid, please check the syntax"
 	HowToFixDecodingError              = "The object can't be decoded, so make sure it's being encoded correctly according to the spec."
 	HowToFixInvalidContentType         = "The content type is invalid, Use one of the %d supported types for this operation: %s"
+	HowToFixPartContentType            = "Send the '%s' part using one of the content types defined by the encoding: '%s'"
+	HowToFixPartSize                   = "Send a '%s' part of %s %d bytes"
+	HowToFixMissingBodyDecoder         = "Register a body decoder for the '%s' media type using config.WithBodyDecoder"
+	HowToFixBodyTooLarge               = "Send a body of no more than %d bytes, or raise the limit using config.WithMaxBodySize"
+	HowToFixDecompressedSize           = "Send a body that decompresses to no more than %d bytes, or raise the limit using config.WithMaxDecompressedSize"
+	HowToFixMultipartLimit             = "Send a multipart body of no more than %d bytes, or raise the limit using config.WithMultipartMemoryLimit"
+	HowToFixPathAmbiguity              = "Rename or remove one of the paths, so that a request can only match one of them"
+	HowToFixContentLength              = "Ensure the Content-Length header is the number of bytes in the body"
+	HowToFixContextDone                = "Validate again using a context that has not been cancelled, and that leaves enough time before its deadline"
 	HowToFixInvalidResponseCode        = "The service is responding with a code that is not defined in the spec, fix the service or add the code to the specification"
 	HowToFixInvalidEncoding            = "Ensure the correct encoding has been used on the object"
 	HowToFixMissingValue               = "Ensure the value has been set"
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/pb33f/libopenapi/datamodel/high/base"
 	"github.com/pb33f/libopenapi/orderedmap"
 
 	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
@@ -54,3 +55,148 @@ func OperationNotFound(pathItem *v3.PathItem, request *http.Request, method stri
 		SpecPath:      specPath,
 	}
 }
+
+func RequestBodyCannotBeDecoded(request *http.Request, mediaType string, reason string, specPath string) *ValidationError {
+	return &ValidationError{
+		ValidationType:    helpers.RequestBodyValidation,
+		ValidationSubType: helpers.Schema,
+		Message: fmt.Sprintf("%s request body for '%s' cannot be decoded as '%s'",
+			request.Method, request.URL.Path, mediaType),
+		Reason:        fmt.Sprintf("The request body cannot be decoded: %s", reason),
+		SpecLine:      1,
+		SpecCol:       0,
+		HowToFix:      HowToFixDecodingError,
+		RequestPath:   request.URL.Path,
+		RequestMethod: request.Method,
+		SpecPath:      specPath,
+	}
+}
+
+func RequestBodyTooLarge(request *http.Request, tooLarge *helpers.BodyTooLargeError, specPath string) *ValidationError {
+	return &ValidationError{
+		ValidationType:    helpers.RequestBodyValidation,
+		ValidationSubType: helpers.BodyTooLarge,
+		Message: fmt.Sprintf("%s request body for '%s' is too large",
+			request.Method, request.URL.Path),
+		Reason:        fmt.Sprintf("The request body cannot be validated: %s", tooLarge.Error()),
+		SpecLine:      1,
+		SpecCol:       0,
+		HowToFix:      howToFixBodyTooLarge(tooLarge),
+		RequestPath:   request.URL.Path,
+		RequestMethod: request.Method,
+		SpecPath:      specPath,
+	}
+}
+
+func RequestContentLengthMismatch(request *http.Request, mismatch *helpers.ContentLengthError,
+	specPath string,
+) *ValidationError {
+	return &ValidationError{
+		ValidationType:    helpers.RequestBodyValidation,
+		ValidationSubType: helpers.BodyContentLength,
+		Message: fmt.Sprintf("%s request body for '%s' does not match its content length",
+			request.Method, request.URL.Path),
+		Reason:        fmt.Sprintf("The request body is incomplete or has been altered: %s", mismatch.Error()),
+		SpecLine:      1,
+		SpecCol:       0,
+		HowToFix:      HowToFixContentLength,
+		RequestPath:   request.URL.Path,
+		RequestMethod: request.Method,
+		SpecPath:      specPath,
+	}
+}
+
+func RequestPartContentTypeInvalid(request *http.Request, name string, contentType string,
+	encoding *v3.Encoding, specPath string,
+) *ValidationError {
+	line, col := 1, 0
+	if low := encoding.GoLow(); low != nil && low.ContentType.ValueNode != nil {
+		line, col = low.ContentType.ValueNode.Line, low.ContentType.ValueNode.Column
+	}
+	return &ValidationError{
+		ValidationType:    helpers.RequestBodyValidation,
+		ValidationSubType: helpers.RequestBodyContentType,
+		Message: fmt.Sprintf("%s request body part '%s' has an invalid content type '%s'",
+			request.Method, name, contentType),
+		Reason: fmt.Sprintf("The content type '%s' of the '%s' part is not one of the content types "+
+			"defined by the encoding: '%s'", contentType, name, encoding.ContentType),
+		SpecLine:      line,
+		SpecCol:       col,
+		Context:       encoding,
+		HowToFix:      fmt.Sprintf(HowToFixPartContentType, name, encoding.ContentType),
+		RequestPath:   request.URL.Path,
+		RequestMethod: request.Method,
+		SpecPath:      specPath,
+	}
+}
+
+// RequestPartSizeInvalid reports a binary part whose size in bytes is outside the 'minLength' and 'maxLength' of
+// its schema.
+func RequestPartSizeInvalid(request *http.Request, name string, size int64, schema *base.Schema,
+	specPath string,
+) *ValidationError {
+	line, col := 1, 0
+	bound, limit := "no more than", int64(0)
+	low := schema.GoLow()
+	if schema.MaxLength != nil && size > *schema.MaxLength {
+		limit = *schema.MaxLength
+		if low != nil && low.MaxLength.ValueNode != nil {
+			line, col = low.MaxLength.ValueNode.Line, low.MaxLength.ValueNode.Column
+		}
+	} else if schema.MinLength != nil {
+		bound, limit = "at least", *schema.MinLength
+		if low != nil && low.MinLength.ValueNode != nil {
+			line, col = low.MinLength.ValueNode.Line, low.MinLength.ValueNode.Column
+		}
+	}
+	return &ValidationError{
+		ValidationType:    helpers.RequestBodyValidation,
+		ValidationSubType: helpers.PartSize,
+		Message: fmt.Sprintf("%s request body part '%s' has an invalid size of %d bytes",
+			request.Method, name, size),
+		Reason: fmt.Sprintf("The '%s' part is %d bytes, however the schema requires %s %d bytes",
+			name, size, bound, limit),
+		SpecLine:      line,
+		SpecCol:       col,
+		Context:       schema,
+		HowToFix:      fmt.Sprintf(HowToFixPartSize, name, bound, limit),
+		RequestPath:   request.URL.Path,
+		RequestMethod: request.Method,
+		SpecPath:      specPath,
+	}
+}
+
+func RequestBodyMediaTypeUnsupported(request *http.Request, mediaType *v3.MediaType, contentType string,
+	specPath string,
+) *ValidationError {
+	line, col := mediaTypeLocation(mediaType)
+	return &ValidationError{
+		ValidationType:    helpers.RequestBodyValidation,
+		ValidationSubType: helpers.RequestBodyContentType,
+		Message: fmt.Sprintf("%s request body for '%s' cannot be validated as '%s'",
+			request.Method, request.URL.Path, contentType),
+		Reason: fmt.Sprintf("The media type '%s' of the request body is defined, however there is no "+
+			"decoder for it, so the body cannot be validated", contentType),
+		SpecLine:      line,
+		SpecCol:       col,
+		Context:       mediaType,
+		HowToFix:      fmt.Sprintf(HowToFixMissingBodyDecoder, contentType),
+		RequestPath:   request.URL.Path,
+		RequestMethod: request.Method,
+		SpecPath:      specPath,
+	}
+}
+
+// mediaTypeLocation returns the line and column of a media type in the specification.
+func mediaTypeLocation(mediaType *v3.MediaType) (int, int) {
+	if mediaType == nil || mediaType.GoLow() == nil {
+		return 1, 0
+	}
+	if node := mediaType.GoLow().KeyNode; node != nil {
+		return node.Line, node.Column
+	}
+	if node := mediaType.GoLow().RootNode; node != nil {
+		return node.Line, node.Column
+	}
+	return 1, 0
+}
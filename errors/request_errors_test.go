@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"testing"
 
+	"github.com/pb33f/libopenapi/datamodel/high/base"
 	"github.com/pb33f/libopenapi/datamodel/low"
 	"github.com/pb33f/libopenapi/orderedmap"
 	"github.com/stretchr/testify/require"
@@ -98,3 +99,108 @@ func TestOperationNotFound(t *testing.T) {
 	require.Equal(t, 25, err.SpecCol)
 	require.Equal(t, HowToFixPathMethod, err.HowToFix)
 }
+
+func TestRequestPartContentTypeInvalid(t *testing.T) {
+	request, _ := http.NewRequest(http.MethodPost, "/test", nil)
+	encoding := &v3.Encoding{ContentType: "image/png"}
+
+	err := RequestPartContentTypeInvalid(request, "photo", "image/gif", encoding, "/test")
+
+	require.NotNil(t, err)
+	require.Equal(t, helpers.RequestBodyValidation, err.ValidationType)
+	require.Equal(t, helpers.RequestBodyContentType, err.ValidationSubType)
+	require.Equal(t, "POST request body part 'photo' has an invalid content type 'image/gif'", err.Message)
+	require.Contains(t, err.Reason, "defined by the encoding: 'image/png'")
+	require.Equal(t, 1, err.SpecLine)
+	require.Equal(t, "Send the 'photo' part using one of the content types defined by the encoding: 'image/png'",
+		err.HowToFix)
+	require.Equal(t, "/test", err.SpecPath)
+}
+
+func TestRequestPartSizeInvalid(t *testing.T) {
+	request, _ := http.NewRequest(http.MethodPost, "/test", nil)
+	minLength, maxLength := int64(4), int64(8)
+	schema := &base.Schema{MinLength: &minLength, MaxLength: &maxLength}
+
+	err := RequestPartSizeInvalid(request, "photo", 9, schema, "/test")
+
+	require.NotNil(t, err)
+	require.Equal(t, helpers.RequestBodyValidation, err.ValidationType)
+	require.Equal(t, helpers.PartSize, err.ValidationSubType)
+	require.Equal(t, "POST request body part 'photo' has an invalid size of 9 bytes", err.Message)
+	require.Equal(t, "The 'photo' part is 9 bytes, however the schema requires no more than 8 bytes", err.Reason)
+	require.Equal(t, "Send a 'photo' part of no more than 8 bytes", err.HowToFix)
+	require.Equal(t, 1, err.SpecLine)
+	require.Equal(t, "/test", err.SpecPath)
+
+	err = RequestPartSizeInvalid(request, "photo", 2, schema, "/test")
+	require.Equal(t, "The 'photo' part is 2 bytes, however the schema requires at least 4 bytes", err.Reason)
+}
+
+func TestRequestBodyMediaTypeUnsupported(t *testing.T) {
+	request, _ := http.NewRequest(http.MethodPost, "/test", nil)
+
+	err := RequestBodyMediaTypeUnsupported(request, nil, "text/csv", "/test")
+
+	require.NotNil(t, err)
+	require.Equal(t, helpers.RequestBodyValidation, err.ValidationType)
+	require.Equal(t, helpers.RequestBodyContentType, err.ValidationSubType)
+	require.Equal(t, "POST request body for '/test' cannot be validated as 'text/csv'", err.Message)
+	require.Contains(t, err.Reason, "there is no decoder for it")
+	require.Equal(t, 1, err.SpecLine)
+	require.Equal(t, "/test", err.SpecPath)
+}
+
+func TestRequestBodyTooLarge(t *testing.T) {
+	request, _ := http.NewRequest(http.MethodPost, "/test", nil)
+
+	err := RequestBodyTooLarge(request, &helpers.BodyTooLargeError{Limit: 1024, ContentLength: -1}, "/test")
+
+	require.NotNil(t, err)
+	require.True(t, err.IsBodyTooLargeError())
+	require.Equal(t, helpers.RequestBodyValidation, err.ValidationType)
+	require.Equal(t, "POST request body for '/test' is too large", err.Message)
+	require.Equal(t, "The request body cannot be validated: the body is larger than the limit of 1024 bytes", err.Reason)
+	require.Equal(t, "Send a body of no more than 1024 bytes, or raise the limit using config.WithMaxBodySize",
+		err.HowToFix)
+	require.Equal(t, "/test", err.SpecPath)
+}
+
+func TestRequestBodyTooLarge_Decompressed(t *testing.T) {
+	request, _ := http.NewRequest(http.MethodPost, "/test", nil)
+
+	err := RequestBodyTooLarge(request,
+		&helpers.BodyTooLargeError{Limit: 2048, ContentLength: -1, Decompressed: true}, "/test")
+
+	require.True(t, err.IsBodyTooLargeError())
+	require.Equal(t, "The request body cannot be validated: the decompressed body is larger than the limit of 2048 bytes",
+		err.Reason)
+	require.Equal(t, "Send a body that decompresses to no more than 2048 bytes, "+
+		"or raise the limit using config.WithMaxDecompressedSize", err.HowToFix)
+}
+
+func TestRequestBodyTooLarge_Multipart(t *testing.T) {
+	request, _ := http.NewRequest(http.MethodPost, "/test", nil)
+
+	err := RequestBodyTooLarge(request,
+		&helpers.BodyTooLargeError{Limit: 2048, ContentLength: -1, Multipart: true}, "/test")
+
+	require.True(t, err.IsBodyTooLargeError())
+	require.Equal(t, "The request body cannot be validated: the multipart body is larger than the memory limit of 2048 bytes",
+		err.Reason)
+	require.Equal(t, "Send a multipart body of no more than 2048 bytes, "+
+		"or raise the limit using config.WithMultipartMemoryLimit", err.HowToFix)
+}
+
+func TestRequestContentLengthMismatch(t *testing.T) {
+	request, _ := http.NewRequest(http.MethodPost, "/test", nil)
+
+	err := RequestContentLengthMismatch(request, &helpers.ContentLengthError{ContentLength: 10, Read: 4}, "/test")
+
+	require.NotNil(t, err)
+	require.False(t, err.IsBodyTooLargeError())
+	require.Equal(t, helpers.BodyContentLength, err.ValidationSubType)
+	require.Equal(t, "POST request body for '/test' does not match its content length", err.Message)
+	require.Contains(t, err.Reason, "the content length is 10 bytes, however 4 bytes were read")
+	require.Equal(t, HowToFixContentLength, err.HowToFix)
+}
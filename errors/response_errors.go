@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/pb33f/libopenapi/orderedmap"
+	"gopkg.in/yaml.v3"
 
 	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
 
@@ -72,3 +73,117 @@ func ResponseCodeNotFound(op *v3.Operation, request *http.Request, code int) *Va
 		HowToFix: HowToFixInvalidResponseCode,
 	}
 }
+
+func ResponseHeaderMissing(name string, header *v3.Header, statusCode int) *ValidationError {
+	line, col := headerLocation(header, true)
+	return &ValidationError{
+		ValidationType:    helpers.ResponseHeaderValidation,
+		ValidationSubType: "missing",
+		Message:           fmt.Sprintf("Response header '%s' is missing", name),
+		Reason: fmt.Sprintf("The response header '%s' is defined as being required for status code '%d', "+
+			"however it's missing from the response", name, statusCode),
+		SpecLine: line,
+		SpecCol:  col,
+		Context:  header,
+		HowToFix: HowToFixMissingValue,
+	}
+}
+
+func ResponseHeaderCannotBeDecoded(name string, header *v3.Header, value string, reason string) *ValidationError {
+	line, col := headerLocation(header, false)
+	return &ValidationError{
+		ValidationType:    helpers.ResponseHeaderValidation,
+		ValidationSubType: helpers.Schema,
+		Message:           fmt.Sprintf("Response header '%s' cannot be decoded", name),
+		Reason:            fmt.Sprintf("The response header '%s' value '%s' cannot be decoded: %s", name, value, reason),
+		SpecLine:          line,
+		SpecCol:           col,
+		Context:           header,
+		HowToFix:          HowToFixInvalidEncoding,
+	}
+}
+
+// headerLocation returns the line and column of a header, or of its 'required' keyword.
+func headerLocation(header *v3.Header, required bool) (int, int) {
+	low := header.GoLow()
+	if low == nil {
+		return 1, 0
+	}
+	var node *yaml.Node
+	if required {
+		node = low.Required.KeyNode
+	}
+	if node == nil {
+		node = low.KeyNode
+	}
+	if node == nil {
+		node = low.RootNode
+	}
+	if node == nil {
+		return 1, 0
+	}
+	return node.Line, node.Column
+}
+
+func ResponseBodyMediaTypeUnsupported(request *http.Request, response *http.Response, mediaType *v3.MediaType,
+	contentType string,
+) *ValidationError {
+	line, col := mediaTypeLocation(mediaType)
+	return &ValidationError{
+		ValidationType:    helpers.ResponseBodyValidation,
+		ValidationSubType: helpers.RequestBodyContentType,
+		Message: fmt.Sprintf("%d response body for '%s' cannot be validated as '%s'",
+			response.StatusCode, request.URL.Path, contentType),
+		Reason: fmt.Sprintf("The media type '%s' of the response body is defined, however there is no "+
+			"decoder for it, so the body cannot be validated", contentType),
+		SpecLine: line,
+		SpecCol:  col,
+		Context:  mediaType,
+		HowToFix: fmt.Sprintf(HowToFixMissingBodyDecoder, contentType),
+	}
+}
+
+func ResponseBodyCannotBeDecoded(request *http.Request, response *http.Response, mediaType string,
+	reason string,
+) *ValidationError {
+	return &ValidationError{
+		ValidationType:    helpers.ResponseBodyValidation,
+		ValidationSubType: helpers.Schema,
+		Message: fmt.Sprintf("%d response body for '%s' cannot be decoded as '%s'",
+			response.StatusCode, request.URL.Path, mediaType),
+		Reason:   fmt.Sprintf("The response body cannot be decoded: %s", reason),
+		SpecLine: 1,
+		SpecCol:  0,
+		HowToFix: HowToFixDecodingError,
+	}
+}
+
+func ResponseBodyTooLarge(request *http.Request, response *http.Response,
+	tooLarge *helpers.BodyTooLargeError,
+) *ValidationError {
+	return &ValidationError{
+		ValidationType:    helpers.ResponseBodyValidation,
+		ValidationSubType: helpers.BodyTooLarge,
+		Message: fmt.Sprintf("%d response body for '%s' is too large",
+			response.StatusCode, request.URL.Path),
+		Reason:   fmt.Sprintf("The response body cannot be validated: %s", tooLarge.Error()),
+		SpecLine: 1,
+		SpecCol:  0,
+		HowToFix: howToFixBodyTooLarge(tooLarge),
+	}
+}
+
+func ResponseContentLengthMismatch(request *http.Request, response *http.Response,
+	mismatch *helpers.ContentLengthError,
+) *ValidationError {
+	return &ValidationError{
+		ValidationType:    helpers.ResponseBodyValidation,
+		ValidationSubType: helpers.BodyContentLength,
+		Message: fmt.Sprintf("%d response body for '%s' does not match its content length",
+			response.StatusCode, request.URL.Path),
+		Reason:   fmt.Sprintf("The response body is incomplete or has been altered: %s", mismatch.Error()),
+		SpecLine: 1,
+		SpecCol:  0,
+		HowToFix: HowToFixContentLength,
+	}
+}
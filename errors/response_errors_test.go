@@ -4,6 +4,7 @@
 package errors
 
 import (
+	"fmt"
 	"net/http"
 	"testing"
 
@@ -159,3 +160,64 @@ func TestResponseCodeNotFound(t *testing.T) {
 	require.Equal(t, 56, err.SpecCol)
 	require.Equal(t, HowToFixInvalidResponseCode, err.HowToFix)
 }
+
+func TestResponseBodyMediaTypeUnsupported(t *testing.T) {
+	request, _ := http.NewRequest(http.MethodGet, "/test", nil)
+	response := &http.Response{StatusCode: http.StatusOK}
+	mediaType := v3.NewMediaType(&lowv3.MediaType{KeyNode: &yaml.Node{Line: 12, Column: 14}})
+
+	err := ResponseBodyMediaTypeUnsupported(request, response, mediaType, "text/csv")
+
+	require.NotNil(t, err)
+	require.Equal(t, helpers.ResponseBodyValidation, err.ValidationType)
+	require.Equal(t, helpers.RequestBodyContentType, err.ValidationSubType)
+	require.Equal(t, "200 response body for '/test' cannot be validated as 'text/csv'", err.Message)
+	require.Contains(t, err.Reason, "there is no decoder for it")
+	require.Equal(t, 12, err.SpecLine)
+	require.Equal(t, 14, err.SpecCol)
+	require.Equal(t, "Register a body decoder for the 'text/csv' media type using config.WithBodyDecoder", err.HowToFix)
+}
+
+func TestResponseBodyCannotBeDecoded(t *testing.T) {
+	request, _ := http.NewRequest(http.MethodGet, "/test", nil)
+	response := &http.Response{StatusCode: http.StatusOK}
+
+	err := ResponseBodyCannotBeDecoded(request, response, "application/x-ndjson", "record 2 is not valid JSON")
+
+	require.NotNil(t, err)
+	require.Equal(t, helpers.ResponseBodyValidation, err.ValidationType)
+	require.Equal(t, helpers.Schema, err.ValidationSubType)
+	require.Equal(t, "200 response body for '/test' cannot be decoded as 'application/x-ndjson'", err.Message)
+	require.Equal(t, "The response body cannot be decoded: record 2 is not valid JSON", err.Reason)
+	require.Equal(t, HowToFixDecodingError, err.HowToFix)
+	require.Nil(t, err.RecordIndex)
+}
+
+func TestResponseBodyTooLarge(t *testing.T) {
+	request, _ := http.NewRequest(http.MethodGet, "/test", nil)
+	response := &http.Response{StatusCode: http.StatusOK}
+
+	err := ResponseBodyTooLarge(request, response, &helpers.BodyTooLargeError{Limit: 10, ContentLength: 20})
+
+	require.NotNil(t, err)
+	require.True(t, err.IsBodyTooLargeError())
+	require.Equal(t, helpers.ResponseBodyValidation, err.ValidationType)
+	require.Equal(t, "200 response body for '/test' is too large", err.Message)
+	require.Contains(t, err.Reason, "content length of 20 bytes, which is larger than the limit of 10 bytes")
+
+	err = ResponseBodyTooLarge(request, response, &helpers.BodyTooLargeError{Limit: 10, Decompressed: true})
+	require.Contains(t, err.Reason, "the decompressed body is larger than the limit of 10 bytes")
+	require.Equal(t, fmt.Sprintf(HowToFixDecompressedSize, 10), err.HowToFix)
+}
+
+func TestResponseContentLengthMismatch(t *testing.T) {
+	request, _ := http.NewRequest(http.MethodGet, "/test", nil)
+	response := &http.Response{StatusCode: http.StatusOK}
+
+	err := ResponseContentLengthMismatch(request, response, &helpers.ContentLengthError{ContentLength: 10, Read: 4})
+
+	require.NotNil(t, err)
+	require.Equal(t, helpers.BodyContentLength, err.ValidationSubType)
+	require.Equal(t, "200 response body for '/test' does not match its content length", err.Message)
+	require.Equal(t, HowToFixContentLength, err.HowToFix)
+}
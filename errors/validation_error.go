@@ -7,6 +7,8 @@ import (
 	"fmt"
 
 	"github.com/santhosh-tekuri/jsonschema/v6"
+
+	"github.com/pb33f/libopenapi-validator/helpers"
 )
 
 // SchemaValidationFailure is a wrapper around the jsonschema.ValidationError object, to provide a more
@@ -24,6 +26,10 @@ type SchemaValidationFailure struct {
 	// AbsoluteLocation is the absolute path to the validation failure as exposed by the jsonschema library.
 	AbsoluteLocation string `json:"absoluteLocation,omitempty" yaml:"absoluteLocation,omitempty"`
 
+	// ElementPath is the path of the element, or attribute, that failed validation when the body is XML.
+	// For example '/pet/tags/tag[2]' or '/pet/@id'.
+	ElementPath string `json:"elementPath,omitempty" yaml:"elementPath,omitempty"`
+
 	// Line is the line number where the violation occurred. This may a local line number
 	// if the validation is a schema (only schemas are validated locally, so the line number will be relative to
 	// the Context object held by the ValidationError object).
@@ -84,6 +90,10 @@ type ValidationError struct {
 	// RequestMethod is the HTTP method of the request
 	RequestMethod string `json:"requestMethod" yaml:"requestMethod"`
 
+	// RecordIndex is the zero based index of the record that failed validation, when the body is a stream of
+	// records, such as newline delimited JSON. It is nil for any other body.
+	RecordIndex *int `json:"recordIndex,omitempty" yaml:"recordIndex,omitempty"`
+
 	// SchemaValidationErrors is a slice of SchemaValidationFailure objects that describe the validation errors
 	// This is only populated whe the validation type is against a schema.
 	SchemaValidationErrors []*SchemaValidationFailure `json:"validationErrors,omitempty" yaml:"validationErrors,omitempty"`
@@ -119,6 +129,28 @@ func (v *ValidationError) IsPathMissingError() bool {
 	return v.ValidationType == "path" && v.ValidationSubType == "missing"
 }
 
+// IsBodyTooLargeError returns true if the error has a ValidationSubType of "bodyTooLarge", the body was larger than
+// the maximum body size, so it was not validated.
+func (v *ValidationError) IsBodyTooLargeError() bool {
+	return v.ValidationSubType == helpers.BodyTooLarge
+}
+
+// IsContextError returns true if the error has a ValidationType of "context", the validation was stopped because
+// its context was cancelled, or its deadline passed, so the result is incomplete.
+func (v *ValidationError) IsContextError() bool {
+	return v.ValidationType == helpers.ContextValidation
+}
+
+// Unwrap returns the error of the context that stopped the validation, so errors.Is can be used with
+// context.Canceled and context.DeadlineExceeded. Nil is returned for every other validation error.
+func (v *ValidationError) Unwrap() error {
+	if !v.IsContextError() {
+		return nil
+	}
+	err, _ := v.Context.(error)
+	return err
+}
+
 // IsOperationMissingError returns true if the error has a ValidationType of "request" and a ValidationSubType of "missingOperation"
 func (v *ValidationError) IsOperationMissingError() bool {
 	return v.ValidationType == "path" && v.ValidationSubType == "missingOperation"
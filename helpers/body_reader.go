@@ -0,0 +1,128 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package helpers
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// BodyTooLargeError is returned when a body is larger than the configured maximum body size.
+type BodyTooLargeError struct {
+	// Limit is the maximum size of a body, in bytes.
+	Limit int64
+
+	// ContentLength is the declared length of the body, or -1 if the body was read until the limit was exceeded.
+	ContentLength int64
+
+	// Decompressed is true when the limit is the maximum decompressed size, and it was exceeded while the body
+	// was being decompressed.
+	Decompressed bool
+
+	// Multipart is true when the limit is the memory limit of a multipart body, which is smaller than the maximum
+	// body size.
+	Multipart bool
+}
+
+func (e *BodyTooLargeError) Error() string {
+	if e.Decompressed {
+		return fmt.Sprintf("the decompressed body is larger than the limit of %d bytes", e.Limit)
+	}
+	if e.Multipart {
+		return fmt.Sprintf("the multipart body is larger than the memory limit of %d bytes", e.Limit)
+	}
+	if e.ContentLength >= 0 {
+		return fmt.Sprintf("the body has a content length of %d bytes, which is larger than the limit of %d bytes",
+			e.ContentLength, e.Limit)
+	}
+	return fmt.Sprintf("the body is larger than the limit of %d bytes", e.Limit)
+}
+
+// ContentLengthError is returned when the number of bytes read from a body is not the declared content length.
+type ContentLengthError struct {
+	// ContentLength is the declared length of the body.
+	ContentLength int64
+
+	// Read is the number of bytes that were read from the body.
+	Read int64
+}
+
+func (e *ContentLengthError) Error() string {
+	return fmt.Sprintf("the content length is %d bytes, however %d bytes were read", e.ContentLength, e.Read)
+}
+
+// LimitedReader reads from a body until more than the limit has been read, at which point a *BodyTooLargeError
+// is returned. The number of bytes read is counted, so it can be checked against the content length of the body.
+// A limit of zero or less means there is no limit.
+type LimitedReader struct {
+	reader       io.Reader
+	limit        int64
+	read         int64
+	decompressed bool
+}
+
+// NewLimitedReader creates a LimitedReader for a body.
+func NewLimitedReader(body io.Reader, limit int64) *LimitedReader {
+	return &LimitedReader{reader: body, limit: limit}
+}
+
+// Read reads from the body. At most a single byte over the limit is read, before the error is returned.
+func (r *LimitedReader) Read(p []byte) (int, error) {
+	if r.limit > 0 {
+		if r.read > r.limit {
+			return 0, &BodyTooLargeError{Limit: r.limit, ContentLength: -1, Decompressed: r.decompressed}
+		}
+		if remaining := r.limit + 1 - r.read; int64(len(p)) > remaining {
+			p = p[:remaining]
+		}
+	}
+	n, err := r.reader.Read(p)
+	r.read += int64(n)
+	if r.limit > 0 && r.read > r.limit {
+		return n, &BodyTooLargeError{Limit: r.limit, ContentLength: -1, Decompressed: r.decompressed}
+	}
+	return n, err
+}
+
+// BytesRead returns the number of bytes read from the body.
+func (r *LimitedReader) BytesRead() int64 {
+	return r.read
+}
+
+// CheckContentLength returns a *ContentLengthError if a content length has been declared, and it is not the number
+// of bytes that were read from the body. Like net/http, a content length of zero or less is treated as unknown,
+// because requests and responses that are built by hand often leave it unset.
+func (r *LimitedReader) CheckContentLength(contentLength int64) error {
+	if contentLength > 0 && contentLength != r.read {
+		return &ContentLengthError{ContentLength: contentLength, Read: r.read}
+	}
+	return nil
+}
+
+// ReadBody reads a whole body, as long as it is no larger than the limit. A body with a content length over the
+// limit is rejected before anything is read. The bytes that were read are always returned, along with a
+// *BodyTooLargeError if the limit was exceeded, or a *ContentLengthError if the bytes read do not match the
+// content length. A content length of zero or less is unknown, a limit of zero or less means no limit.
+func ReadBody(body io.Reader, limit int64, contentLength int64) ([]byte, error) {
+	if limit > 0 && contentLength > limit {
+		return nil, &BodyTooLargeError{Limit: limit, ContentLength: contentLength}
+	}
+	reader := NewLimitedReader(body, limit)
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return data, err
+	}
+	return data, reader.CheckContentLength(contentLength)
+}
+
+// ReplayBody returns a body that replays the bytes that have already been read from a body, followed by the unread
+// remainder of the body, so a body that has been validated can be read again. Closing the returned body closes
+// the original body.
+func ReplayBody(read []byte, body io.ReadCloser) io.ReadCloser {
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(read), body), body}
+}
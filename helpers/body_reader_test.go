@@ -0,0 +1,88 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package helpers
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadBody(t *testing.T) {
+	data, err := ReadBody(strings.NewReader("burger"), 6, 6)
+	require.NoError(t, err)
+	assert.Equal(t, "burger", string(data))
+
+	// no limit, and an unknown content length.
+	data, err = ReadBody(strings.NewReader("burger"), 0, -1)
+	require.NoError(t, err)
+	assert.Equal(t, "burger", string(data))
+}
+
+func TestReadBody_TooLarge(t *testing.T) {
+	body := strings.NewReader("burgers")
+	data, err := ReadBody(body, 6, -1)
+	assert.EqualError(t, err, "the body is larger than the limit of 6 bytes")
+	assert.IsType(t, &BodyTooLargeError{}, err)
+
+	// no more than a single byte over the limit is read.
+	assert.Equal(t, "burgers", string(data))
+	assert.Equal(t, 0, body.Len())
+
+	// a declared content length over the limit is rejected before anything is read.
+	body = strings.NewReader("burgers and fries")
+	data, err = ReadBody(body, 6, 17)
+	assert.EqualError(t, err, "the body has a content length of 17 bytes, which is larger than the limit of 6 bytes")
+	assert.Empty(t, data)
+	assert.Equal(t, 17, body.Len())
+}
+
+func TestReadBody_ContentLength(t *testing.T) {
+	data, err := ReadBody(strings.NewReader("burger"), 0, 10)
+	assert.EqualError(t, err, "the content length is 10 bytes, however 6 bytes were read")
+	assert.Equal(t, &ContentLengthError{ContentLength: 10, Read: 6}, err)
+	assert.Equal(t, "burger", string(data))
+
+	// a content length of zero is unknown.
+	_, err = ReadBody(strings.NewReader("burger"), 0, 0)
+	assert.NoError(t, err)
+}
+
+func TestLimitedReader(t *testing.T) {
+	r := NewLimitedReader(strings.NewReader("burger"), 4)
+	buf := make([]byte, 3)
+	n, err := r.Read(buf)
+	assert.Equal(t, 3, n)
+	assert.NoError(t, err)
+
+	n, err = r.Read(buf)
+	assert.Equal(t, 2, n)
+	assert.IsType(t, &BodyTooLargeError{}, err)
+	assert.Equal(t, int64(5), r.BytesRead())
+
+	n, err = r.Read(buf)
+	assert.Zero(t, n)
+	assert.IsType(t, &BodyTooLargeError{}, err)
+
+	r = NewLimitedReader(strings.NewReader("burger"), 0)
+	_, _ = io.ReadAll(r)
+	assert.NoError(t, r.CheckContentLength(6))
+	assert.NoError(t, r.CheckContentLength(-1))
+	assert.Equal(t, &ContentLengthError{ContentLength: 7, Read: 6}, r.CheckContentLength(7))
+}
+
+func TestReplayBody(t *testing.T) {
+	original := io.NopCloser(strings.NewReader("burger"))
+	read := make([]byte, 3)
+	_, _ = io.ReadFull(original, read)
+
+	body := ReplayBody(read, original)
+	replayed, err := io.ReadAll(body)
+	require.NoError(t, err)
+	assert.Equal(t, "burger", string(replayed))
+	assert.NoError(t, body.Close())
+}
@@ -0,0 +1,178 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package helpers
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/ianaindex"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// UnsupportedCharsetError is returned when the charset of a body is not known, so it cannot be transcoded to UTF-8.
+type UnsupportedCharsetError struct {
+	// Charset is the charset that is not supported.
+	Charset string
+}
+
+func (e *UnsupportedCharsetError) Error() string {
+	return fmt.Sprintf("the charset '%s' is not supported", e.Charset)
+}
+
+// InvalidUTF8Error is returned when a body that should be UTF-8 contains a byte sequence that is not valid UTF-8.
+type InvalidUTF8Error struct {
+	// Offset is the zero based offset of the first byte of the invalid sequence.
+	Offset int64
+}
+
+func (e *InvalidUTF8Error) Error() string {
+	return fmt.Sprintf("the body is not valid UTF-8, the byte at offset %d is invalid", e.Offset)
+}
+
+// lookupCharset returns the encoding of a charset, using the IANA names first, then the aliases used by browsers,
+// such as 'utf8'. A nil encoding is returned for UTF-8, and for a body without a charset, which is UTF-8.
+func lookupCharset(charset string) (encoding.Encoding, error) {
+	charset = strings.TrimSpace(charset)
+	if charset == "" {
+		return nil, nil
+	}
+	enc, err := ianaindex.IANA.Encoding(charset)
+	if err != nil || enc == nil {
+		if enc, err = htmlindex.Get(charset); err != nil {
+			enc = nil
+		}
+	}
+	if enc == nil {
+		return nil, &UnsupportedCharsetError{Charset: charset}
+	}
+	if enc == unicode.UTF8 {
+		return nil, nil
+	}
+	return enc, nil
+}
+
+// DecodeCharset transcodes a body from its charset to UTF-8. A body without a charset, or with the UTF-8 charset,
+// is checked and returned as is. An unknown charset returns an *UnsupportedCharsetError, and a UTF-8 body with an
+// invalid byte sequence returns an *InvalidUTF8Error.
+func DecodeCharset(body []byte, charset string) ([]byte, error) {
+	enc, err := lookupCharset(charset)
+	if err != nil {
+		return nil, err
+	}
+	if enc == nil {
+		if offset := invalidUTF8Offset(body); offset >= 0 {
+			return nil, &InvalidUTF8Error{Offset: int64(offset)}
+		}
+		return body, nil
+	}
+	decoded, err := enc.NewDecoder().Bytes(body)
+	if err != nil {
+		return nil, fmt.Errorf("the body cannot be decoded as '%s': %w", charset, err)
+	}
+	return decoded, nil
+}
+
+// NewCharsetReader returns a reader that transcodes a body from its charset to UTF-8, while it is being read. A body
+// without a charset, or with the UTF-8 charset, is checked as it is read. See DecodeCharset for the errors that
+// are returned.
+func NewCharsetReader(body io.Reader, charset string) (io.Reader, error) {
+	enc, err := lookupCharset(charset)
+	if err != nil {
+		return nil, err
+	}
+	if enc == nil {
+		return &utf8Reader{reader: body}, nil
+	}
+	return transform.NewReader(body, enc.NewDecoder()), nil
+}
+
+// invalidUTF8Offset returns the offset of the first invalid byte sequence of a body, or -1 if it is valid UTF-8.
+func invalidUTF8Offset(body []byte) int {
+	if utf8.Valid(body) {
+		return -1
+	}
+	for i := 0; i < len(body); {
+		if body[i] < utf8.RuneSelf {
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRune(body[i:])
+		if r == utf8.RuneError && size == 1 {
+			return i
+		}
+		i += size
+	}
+	return -1
+}
+
+// utf8Reader checks that a body is valid UTF-8 as it is read. A sequence that is split across reads is carried over
+// to the next read, so only a sequence that is invalid returns an *InvalidUTF8Error.
+type utf8Reader struct {
+	reader  io.Reader
+	offset  int64
+	partial []byte
+	err     error
+}
+
+func (r *utf8Reader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	n, err := r.reader.Read(p)
+	data := p[:n]
+	i := 0
+
+	// complete the sequence that was carried over from the previous read.
+	if len(r.partial) > 0 {
+		for i < n && !utf8.FullRune(r.partial) {
+			r.partial = append(r.partial, data[i])
+			i++
+		}
+		if !utf8.FullRune(r.partial) {
+			if err == io.EOF {
+				r.err = &InvalidUTF8Error{Offset: r.offset}
+				return n, r.err
+			}
+			return n, err
+		}
+		rn, size := utf8.DecodeRune(r.partial)
+		if rn == utf8.RuneError && size == 1 {
+			r.err = &InvalidUTF8Error{Offset: r.offset}
+			return n, r.err
+		}
+		r.offset += int64(size)
+		r.partial = r.partial[:0]
+	}
+
+	for i < n {
+		if data[i] < utf8.RuneSelf {
+			i++
+			r.offset++
+			continue
+		}
+		if !utf8.FullRune(data[i:]) {
+			r.partial = append(r.partial, data[i:]...)
+			break
+		}
+		rn, size := utf8.DecodeRune(data[i:])
+		if rn == utf8.RuneError && size == 1 {
+			r.err = &InvalidUTF8Error{Offset: r.offset}
+			return n, r.err
+		}
+		i += size
+		r.offset += int64(size)
+	}
+
+	if err == io.EOF && len(r.partial) > 0 {
+		r.err = &InvalidUTF8Error{Offset: r.offset}
+		return n, r.err
+	}
+	return n, err
+}
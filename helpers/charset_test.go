@@ -0,0 +1,98 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package helpers
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/encoding/unicode"
+)
+
+func TestDecodeCharset(t *testing.T) {
+	// no charset, and UTF-8 under any of its names, is returned as is.
+	for _, charset := range []string{"", "utf-8", "UTF-8", "utf8"} {
+		decoded, err := DecodeCharset([]byte(`{"name": "Café"}`), charset)
+		require.NoError(t, err, charset)
+		assert.Equal(t, `{"name": "Café"}`, string(decoded))
+	}
+
+	decoded, err := DecodeCharset([]byte("{\"name\": \"Caf\xe9\"}"), "iso-8859-1")
+	require.NoError(t, err)
+	assert.Equal(t, `{"name": "Café"}`, string(decoded))
+
+	utf16, _ := unicode.UTF16(unicode.BigEndian, unicode.UseBOM).NewEncoder().Bytes([]byte(`{"name": "Café"}`))
+	decoded, err = DecodeCharset(utf16, "utf-16")
+	require.NoError(t, err)
+	assert.Equal(t, `{"name": "Café"}`, string(decoded))
+}
+
+func TestDecodeCharset_Unsupported(t *testing.T) {
+	_, err := DecodeCharset([]byte("burger"), "x-burger")
+	assert.EqualError(t, err, "the charset 'x-burger' is not supported")
+	assert.IsType(t, &UnsupportedCharsetError{}, err)
+
+	// known to the IANA registry, but without an encoding.
+	_, err = DecodeCharset([]byte("burger"), "utf-32")
+	assert.IsType(t, &UnsupportedCharsetError{}, err)
+}
+
+func TestDecodeCharset_InvalidUTF8(t *testing.T) {
+	_, err := DecodeCharset([]byte("{\"name\": \"Caf\xe9\"}"), "")
+	assert.EqualError(t, err, "the body is not valid UTF-8, the byte at offset 13 is invalid")
+	invalid, ok := err.(*InvalidUTF8Error)
+	require.True(t, ok)
+	assert.Equal(t, int64(13), invalid.Offset)
+
+	// a truncated sequence is invalid.
+	_, err = DecodeCharset([]byte("Caf\xc3"), "utf-8")
+	assert.EqualError(t, err, "the body is not valid UTF-8, the byte at offset 3 is invalid")
+}
+
+func TestNewCharsetReader(t *testing.T) {
+	// sequences split across reads are valid.
+	reader, err := NewCharsetReader(iotest.OneByteReader(strings.NewReader("Café 🍔")), "")
+	require.NoError(t, err)
+	data, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, "Café 🍔", string(data))
+
+	reader, err = NewCharsetReader(bytes.NewReader([]byte("Caf\xe9")), "latin1")
+	require.NoError(t, err)
+	data, err = io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, "Café", string(data))
+
+	_, err = NewCharsetReader(strings.NewReader("burger"), "x-burger")
+	assert.IsType(t, &UnsupportedCharsetError{}, err)
+}
+
+func TestNewCharsetReader_InvalidUTF8(t *testing.T) {
+	tests := map[string]struct {
+		body   string
+		offset int64
+	}{
+		"invalid byte":      {body: "burger \xff", offset: 7},
+		"invalid sequence":  {body: "Caf\xc3\x28 burger", offset: 3},
+		"truncated at EOF":  {body: "burger \xf0\x9f\x8d", offset: 7},
+		"after a multibyte": {body: "é\x80", offset: 2},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			for _, r := range []io.Reader{strings.NewReader(tc.body), iotest.OneByteReader(strings.NewReader(tc.body))} {
+				reader, err := NewCharsetReader(r, "utf-8")
+				require.NoError(t, err)
+				_, err = io.ReadAll(reader)
+				invalid, ok := err.(*InvalidUTF8Error)
+				require.True(t, ok, "%v", err)
+				assert.Equal(t, tc.offset, invalid.Offset)
+			}
+		})
+	}
+}
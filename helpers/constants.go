@@ -13,7 +13,12 @@ const (
 	RequestBodyValidation     = "requestBody"
 	Schema                    = "schema"
 	ResponseBodyValidation    = "response"
+	ResponseHeaderValidation  = "responseHeader"
 	RequestBodyContentType    = "contentType"
+	PartSize                  = "partSize"
+	BodyTooLarge              = "bodyTooLarge"
+	BodyContentLength         = "contentLength"
+	PathAmbiguity             = "pathAmbiguity"
 	RequestMissingOperation   = "missingOperation"
 	ResponseBodyResponseCode  = "statusCode"
 	SpaceDelimited            = "spaceDelimited"
@@ -42,11 +47,25 @@ const (
 	Form                      = "form"
 	Query                     = "query"
 	JSONContentType           = "application/json"
+	FormURLEncodedContentType = "application/x-www-form-urlencoded"
+	MultipartFormDataType     = "multipart/form-data"
+	OctetStreamContentType    = "application/octet-stream"
+	TextPlainContentType      = "text/plain"
+	XMLContentType            = "application/xml"
 	JSONType                  = "json"
+	XMLType                   = "xml"
 	ContentTypeHeader         = "Content-Type"
+	ContentEncodingHeader     = "Content-Encoding"
 	AuthorizationHeader       = "Authorization"
 	Charset                   = "charset"
 	Boundary                  = "boundary"
 	Preferred                 = "preferred"
 	FailSegment               = "**&&FAIL&&**"
+	SecurityMissingToken      = "missingToken"
+	SecurityExpiredToken      = "expiredToken"
+	SecurityInvalidToken      = "invalidToken"
+	SecurityInsufficientScope = "insufficientScope"
+	ContextValidation         = "context"
+	ContextCanceled           = "canceled"
+	ContextDeadlineExceeded   = "deadlineExceeded"
 )
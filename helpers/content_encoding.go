@@ -0,0 +1,98 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package helpers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pb33f/libopenapi-validator/config"
+)
+
+// UnsupportedContentEncodingError is returned when a body has been encoded with a content coding that has no
+// registered config.ContentDecoder.
+type UnsupportedContentEncodingError struct {
+	// Encoding is the content coding that is not supported.
+	Encoding string
+}
+
+func (e *UnsupportedContentEncodingError) Error() string {
+	return fmt.Sprintf("the content encoding '%s' is not supported", e.Encoding)
+}
+
+// contentCodings splits the value of a Content-Encoding header into the codings that have been applied to a body,
+// in the order they were applied. The 'identity' coding is dropped, as it does not change the body.
+func contentCodings(contentEncoding string) []string {
+	var codings []string
+	for _, coding := range strings.Split(contentEncoding, Comma) {
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding != "" && coding != "identity" {
+			codings = append(codings, coding)
+		}
+	}
+	return codings
+}
+
+// NewContentReader returns a reader that decompresses a body, according to the value of its Content-Encoding
+// header. Codings are removed in the reverse of the order they were applied, using the content decoders of the
+// options. The decompressed body is limited to the maximum decompressed size, so reading past it returns a
+// *BodyTooLargeError. A coding without a decoder returns an *UnsupportedContentEncodingError. When the body has not
+// been encoded, it is returned as is. The context is supplied to decoders that implement
+// config.ContextContentDecoder.
+func NewContentReader(
+	ctx context.Context,
+	body io.Reader,
+	contentEncoding string,
+	options *config.ValidationOptions,
+) (io.Reader, error) {
+	codings := contentCodings(contentEncoding)
+	if len(codings) == 0 {
+		return body, nil
+	}
+	reader := body
+	for i := len(codings) - 1; i >= 0; i-- {
+		decoder := options.FindContentDecoder(codings[i])
+		if decoder == nil {
+			return nil, &UnsupportedContentEncodingError{Encoding: codings[i]}
+		}
+		var err error
+		if reader, err = config.NewDecodingReader(ctx, decoder, reader); err != nil {
+			if err == io.EOF {
+				// decoders that read a header up front fail on an empty body, which has nothing to decompress.
+				return bytes.NewReader(nil), nil
+			}
+			return nil, fmt.Errorf("the body cannot be decoded as '%s': %w", codings[i], err)
+		}
+	}
+	return &LimitedReader{reader: reader, limit: options.MaxDecompressedSize, decompressed: true}, nil
+}
+
+// DecodeContent decompresses a whole body, according to the value of its Content-Encoding header. See
+// NewContentReader for the errors that are returned. When the body is empty, or has not been encoded, it is
+// returned as is. Decompression stops once the context is done.
+func DecodeContent(
+	ctx context.Context,
+	body []byte,
+	contentEncoding string,
+	options *config.ValidationOptions,
+) ([]byte, error) {
+	if len(body) == 0 || len(contentCodings(contentEncoding)) == 0 {
+		return body, nil
+	}
+	reader, err := NewContentReader(ctx, bytes.NewReader(body), contentEncoding, options)
+	if err != nil {
+		return nil, err
+	}
+	decoded, err := io.ReadAll(NewContextReader(ctx, reader))
+	if err != nil {
+		if _, ok := err.(*BodyTooLargeError); ok {
+			return nil, err
+		}
+		return nil, fmt.Errorf("the body cannot be decompressed: %w", err)
+	}
+	return decoded, nil
+}
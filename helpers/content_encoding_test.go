@@ -0,0 +1,132 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package helpers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"encoding/base64"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pb33f/libopenapi-validator/config"
+)
+
+func gzipped(t *testing.T, data string) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write([]byte(data))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func deflated(t *testing.T, data string) []byte {
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	_, err := w.Write([]byte(data))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestDecodeContent(t *testing.T) {
+	options := config.NewValidationOptions()
+
+	decoded, err := DecodeContent(context.Background(), gzipped(t, `{"name": "Big Mac"}`), "gzip", options)
+	require.NoError(t, err)
+	assert.Equal(t, `{"name": "Big Mac"}`, string(decoded))
+
+	decoded, err = DecodeContent(context.Background(), deflated(t, `{"name": "Whopper"}`), "Deflate", options)
+	require.NoError(t, err)
+	assert.Equal(t, `{"name": "Whopper"}`, string(decoded))
+
+	// bodies without a coding, or with the identity coding, are returned as is.
+	decoded, err = DecodeContent(context.Background(), []byte("burger"), "", options)
+	require.NoError(t, err)
+	assert.Equal(t, "burger", string(decoded))
+
+	decoded, err = DecodeContent(context.Background(), []byte("burger"), "identity", options)
+	require.NoError(t, err)
+	assert.Equal(t, "burger", string(decoded))
+
+	// an empty body has nothing to decompress.
+	decoded, err = DecodeContent(context.Background(), nil, "gzip", options)
+	require.NoError(t, err)
+	assert.Empty(t, decoded)
+}
+
+func TestDecodeContent_MultipleCodings(t *testing.T) {
+	options := config.NewValidationOptions(config.WithContentDecoder("x-base64",
+		config.ContentDecoderFunc(func(body io.Reader) (io.Reader, error) {
+			return base64.NewDecoder(base64.StdEncoding, body), nil
+		})))
+
+	// the body was gzipped, then base64 encoded, so it is decoded in reverse.
+	body := base64.StdEncoding.EncodeToString(gzipped(t, "burger"))
+	decoded, err := DecodeContent(context.Background(), []byte(body), "gzip, x-base64", options)
+	require.NoError(t, err)
+	assert.Equal(t, "burger", string(decoded))
+}
+
+func TestDecodeContent_Unsupported(t *testing.T) {
+	_, err := DecodeContent(context.Background(), []byte("burger"), "br", config.NewValidationOptions())
+	assert.EqualError(t, err, "the content encoding 'br' is not supported")
+	assert.IsType(t, &UnsupportedContentEncodingError{}, err)
+
+	// a removed decoder is no longer supported.
+	options := config.NewValidationOptions(config.WithContentDecoder("gzip", nil))
+	_, err = DecodeContent(context.Background(), gzipped(t, "burger"), "gzip", options)
+	assert.EqualError(t, err, "the content encoding 'gzip' is not supported")
+}
+
+func TestDecodeContent_Corrupt(t *testing.T) {
+	_, err := DecodeContent(context.Background(), []byte("this is not a gzipped burger"), "gzip", config.NewValidationOptions())
+	assert.EqualError(t, err, "the body cannot be decoded as 'gzip': gzip: invalid header")
+
+	body := gzipped(t, "burger")
+	_, err = DecodeContent(context.Background(), body[:len(body)-4], "gzip", config.NewValidationOptions())
+	assert.EqualError(t, err, "the body cannot be decompressed: unexpected EOF")
+}
+
+func TestDecodeContent_TooLarge(t *testing.T) {
+	// a small body that decompresses to a lot of data is stopped at the limit.
+	body := gzipped(t, strings.Repeat("0", 1<<20))
+	options := config.NewValidationOptions(config.WithMaxDecompressedSize(1024))
+
+	_, err := DecodeContent(context.Background(), body, "gzip", options)
+	assert.EqualError(t, err, "the decompressed body is larger than the limit of 1024 bytes")
+	tooLarge, ok := err.(*BodyTooLargeError)
+	require.True(t, ok)
+	assert.True(t, tooLarge.Decompressed)
+
+	// the limit can be removed.
+	decoded, err := DecodeContent(context.Background(), body, "gzip", config.NewValidationOptions(config.WithMaxDecompressedSize(0)))
+	require.NoError(t, err)
+	assert.Len(t, decoded, 1<<20)
+}
+
+func TestNewContentReader(t *testing.T) {
+	options := config.NewValidationOptions()
+
+	body := strings.NewReader("burger")
+	reader, err := NewContentReader(context.Background(), body, "", options)
+	require.NoError(t, err)
+	assert.Same(t, body, reader)
+
+	reader, err = NewContentReader(context.Background(), bytes.NewReader(gzipped(t, "burger")), "x-gzip", options)
+	require.NoError(t, err)
+	data, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, "burger", string(data))
+
+	_, err = NewContentReader(context.Background(), strings.NewReader("burger"), "zstd", options)
+	assert.IsType(t, &UnsupportedContentEncodingError{}, err)
+}
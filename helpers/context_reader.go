@@ -0,0 +1,30 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package helpers
+
+import (
+	"context"
+	"io"
+)
+
+// NewContextReader returns a reader that stops reading a body once its context is done, the error of the context
+// is returned by every read after that. A body is returned as it is when its context can never be done.
+func NewContextReader(ctx context.Context, body io.Reader) io.Reader {
+	if ctx.Done() == nil {
+		return body
+	}
+	return &contextReader{ctx: ctx, reader: body}
+}
+
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.reader.Read(p)
+}
@@ -0,0 +1,29 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package helpers
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewContextReader(t *testing.T) {
+	body := strings.NewReader("burger")
+	assert.Same(t, body, NewContextReader(context.Background(), body))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	reader := NewContextReader(ctx, strings.NewReader("burger"))
+	buf := make([]byte, 3)
+	n, err := reader.Read(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "bur", string(buf[:n]))
+
+	cancel()
+	_, err = io.ReadAll(reader)
+	assert.ErrorIs(t, err, context.Canceled)
+}
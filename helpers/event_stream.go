@@ -0,0 +1,160 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package helpers
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// EventStreamContentType is the media type of a server-sent events stream.
+const EventStreamContentType = "text/event-stream"
+
+// IsEventStreamContentType returns true if the content type is a server-sent events stream.
+func IsEventStreamContentType(contentType string) bool {
+	return ParseMediaType(contentType).String() == EventStreamContentType
+}
+
+// ServerSentEvent is a single event dispatched from a 'text/event-stream' body.
+type ServerSentEvent struct {
+	// Event is the event type, it is empty when the event has no 'event' field.
+	Event string
+
+	// Data is the data of the event, multiple 'data' fields are joined with new lines.
+	Data string
+
+	// ID is the last event ID seen in the stream, events without an 'id' field carry the previous ID.
+	ID string
+
+	// Retry is the reconnection time in milliseconds, or -1 when the event has no valid 'retry' field.
+	Retry int
+}
+
+// EventStreamReader reads the events of a 'text/event-stream' body one at a time, following the parsing rules of
+// the HTML specification. Comments are ignored, and blocks of fields that have no data are not dispatched.
+type EventStreamReader struct {
+	reader *bufio.Reader
+	limit  int64
+	lastID string
+	index  int
+	done   bool
+}
+
+// NewEventStreamReader creates an EventStreamReader for a body. Events larger than the limit, counting the bytes of
+// all their lines, are not held in memory, a limit of zero or less means no limit.
+func NewEventStreamReader(body io.Reader, limit int64) *EventStreamReader {
+	return &EventStreamReader{reader: bufio.NewReader(body), limit: limit, index: -1}
+}
+
+// Next reads the next event from the stream. When there are no more events io.EOF is returned. An event that is
+// not terminated by a blank line at the end of the stream is discarded, as the specification requires. An event
+// that is larger than the limit returns a *RecordTooLargeError, but the reader can carry on with the following event.
+func (r *EventStreamReader) Next() (*ServerSentEvent, error) {
+	event := &ServerSentEvent{Retry: -1}
+	var data strings.Builder
+	var hasData, tooLarge bool
+	var size int64
+	for !r.done {
+		remaining := int64(-1)
+		if r.limit > 0 {
+			remaining = max(r.limit-size, 0)
+		}
+		line, truncated, err := r.readLine(remaining)
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				return nil, err
+			}
+			r.done = true
+			break
+		}
+		if line == "" && !truncated {
+			if tooLarge {
+				// the fields of the event have been discarded, the event is reported, not dispatched.
+				r.index++
+				return nil, &RecordTooLargeError{Index: r.index, Limit: r.limit}
+			}
+			// a blank line dispatches the event, as long as there is some data.
+			if !hasData {
+				event = &ServerSentEvent{Retry: -1}
+				size = 0
+				continue
+			}
+			event.Data = data.String()
+			event.ID = r.lastID
+			r.index++
+			return event, nil
+		}
+		if tooLarge || strings.HasPrefix(line, ":") {
+			continue // a comment, used to keep the connection alive, is not part of the event.
+		}
+		size += int64(len(line)) + 1
+		if truncated || (r.limit > 0 && size > r.limit) {
+			tooLarge = true
+			data.Reset()
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event.Event = value
+		case "data":
+			if hasData {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+			hasData = true
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				r.lastID = value
+			}
+		case "retry":
+			// the retry field is ignored unless it is made of ASCII digits only.
+			if strings.Trim(value, "0123456789") == "" {
+				if retry, convErr := strconv.Atoi(value); convErr == nil {
+					event.Retry = retry
+				}
+			}
+		}
+	}
+	return nil, io.EOF
+}
+
+// Index returns the zero based index of the last event returned by Next, or -1 if no event has been read.
+func (r *EventStreamReader) Index() int {
+	return r.index
+}
+
+// readLine reads a single line, lines end with a carriage return, a line feed, or both. A line at the end of the
+// stream that has no line ending returns io.EOF, so it is never dispatched. No more than keep bytes of the line are
+// kept (but always the first byte, so comments can be told apart), the rest of a longer line is discarded and true
+// is returned. A keep of less than zero keeps the whole line.
+func (r *EventStreamReader) readLine(keep int64) (string, bool, error) {
+	var line bytes.Buffer
+	truncated := false
+	for {
+		b, err := r.reader.ReadByte()
+		if err != nil {
+			return "", false, err
+		}
+		switch b {
+		case '\n':
+			return line.String(), truncated, nil
+		case '\r':
+			if next, peekErr := r.reader.Peek(1); peekErr == nil && next[0] == '\n' {
+				_, _ = r.reader.ReadByte()
+			}
+			return line.String(), truncated, nil
+		}
+		if keep >= 0 && line.Len() > 0 && int64(line.Len()) >= keep {
+			truncated = true
+			continue
+		}
+		line.WriteByte(b)
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package helpers
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsEventStreamContentType(t *testing.T) {
+	assert.True(t, IsEventStreamContentType("text/event-stream"))
+	assert.True(t, IsEventStreamContentType("Text/Event-Stream; charset=utf-8"))
+	assert.False(t, IsEventStreamContentType("application/json"))
+}
+
+func TestEventStreamReader(t *testing.T) {
+	body := ": keep alive\n\n" +
+		"event: cooked\nid: 1\nretry: 3000\ndata: {\"name\":\ndata:\"Big Mac\"}\n\n" +
+		"id: 2\r\ndata:second\r\n\r\n" +
+		"retry: soon\rdata: third\r\r" +
+		"id\nevent: empty\n\n" +
+		"data: unterminated"
+	r := NewEventStreamReader(strings.NewReader(body), 0)
+	assert.Equal(t, -1, r.Index())
+
+	event, err := r.Next()
+	require.NoError(t, err)
+	assert.Equal(t, &ServerSentEvent{Event: "cooked", Data: "{\"name\":\n\"Big Mac\"}", ID: "1", Retry: 3000}, event)
+	assert.Equal(t, 0, r.Index())
+
+	event, err = r.Next()
+	require.NoError(t, err)
+	assert.Equal(t, &ServerSentEvent{Data: "second", ID: "2", Retry: -1}, event)
+
+	// the last event id carries over, and an invalid retry is ignored.
+	event, err = r.Next()
+	require.NoError(t, err)
+	assert.Equal(t, &ServerSentEvent{Data: "third", ID: "2", Retry: -1}, event)
+	assert.Equal(t, 2, r.Index())
+
+	// a block without data is not dispatched, and neither is an unterminated event.
+	_, err = r.Next()
+	assert.Equal(t, io.EOF, err)
+	_, err = r.Next()
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, 2, r.Index())
+}
+
+func TestEventStreamReader_EventTooLarge(t *testing.T) {
+	// the second event is larger than the limit, it has a long line and many short lines, comments are not counted.
+	body := "data: one\n\n" +
+		"data: " + strings.Repeat("x", 64) + "\ndata: more\n\n" +
+		"data: a\ndata: b\ndata: c\ndata: d\n\n" +
+		": " + strings.Repeat("keep alive ", 8) + "\ndata: two\n\n"
+	r := NewEventStreamReader(strings.NewReader(body), 24)
+
+	event, err := r.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "one", event.Data)
+
+	_, err = r.Next()
+	assert.Equal(t, &RecordTooLargeError{Index: 1, Limit: 24}, err)
+
+	_, err = r.Next()
+	assert.Equal(t, &RecordTooLargeError{Index: 2, Limit: 24}, err)
+
+	// the reader carries on after the events that are too large.
+	event, err = r.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "two", event.Data)
+	assert.Equal(t, 3, r.Index())
+
+	_, err = r.Next()
+	assert.Equal(t, io.EOF, err)
+}
@@ -0,0 +1,78 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package helpers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// IsJSONArray returns true if a JSON document starts with an array.
+func IsJSONArray(body []byte) bool {
+	trimmed := bytes.TrimLeft(body, " \t\r\n")
+	return len(trimmed) > 0 && trimmed[0] == '['
+}
+
+// DecodeJSONArray decodes the items of a JSON array one at a time, and passes each item to a function along with
+// its index, so the whole array is never materialized. An error is returned if the document is not a single array.
+func DecodeJSONArray(body io.Reader, each func(index int, item any)) error {
+	decoder := json.NewDecoder(body)
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("the document is not an array")
+	}
+	for index := 0; decoder.More(); index++ {
+		var item any
+		if err = decoder.Decode(&item); err != nil {
+			if errors.Is(err, io.ErrUnexpectedEOF) {
+				// report a truncated item the same way as json.Unmarshal.
+				return fmt.Errorf("unexpected end of JSON input")
+			}
+			return err
+		}
+		each(index, item)
+	}
+	// the closing bracket, which must be the end of the document.
+	if _, err = decoder.Token(); err != nil {
+		return err
+	}
+	if _, err = decoder.Token(); !errors.Is(err, io.EOF) {
+		if err == nil {
+			return fmt.Errorf("invalid character after top-level value")
+		}
+		return err
+	}
+	return nil
+}
+
+// StreamableItems returns the items schema of a compiled array schema, when the items of an array can be validated
+// one at a time, and nil otherwise. That is only possible when nothing but the items schema applies to the array,
+// keywords such as 'maxItems', 'uniqueItems', 'contains' or 'oneOf' need the whole array.
+func StreamableItems(schema *jsonschema.Schema) *jsonschema.Schema {
+	items := schema.Items2020
+	if items == nil {
+		items, _ = schema.Items.(*jsonschema.Schema)
+	}
+	if items == nil || schema.Bool != nil || schema.Ref != nil || schema.RecursiveRef != nil ||
+		schema.DynamicRef != nil || schema.Enum != nil || schema.Const != nil || schema.Not != nil ||
+		len(schema.AllOf) > 0 || len(schema.AnyOf) > 0 || len(schema.OneOf) > 0 || schema.If != nil ||
+		schema.MinItems != nil || schema.MaxItems != nil || schema.UniqueItems || schema.Contains != nil ||
+		schema.AdditionalItems != nil || len(schema.PrefixItems) > 0 || schema.UnevaluatedItems != nil ||
+		len(schema.Extensions) > 0 {
+		return nil
+	}
+	if schema.Types != nil && !slices.Contains(schema.Types.ToStrings(), Array) {
+		return nil
+	}
+	return items
+}
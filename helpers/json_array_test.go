@@ -0,0 +1,58 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package helpers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pb33f/libopenapi-validator/config"
+)
+
+func TestIsJSONArray(t *testing.T) {
+	assert.True(t, IsJSONArray([]byte(" \n [1, 2]")))
+	assert.False(t, IsJSONArray([]byte(`{"a": [1]}`)))
+	assert.False(t, IsJSONArray([]byte("  ")))
+}
+
+func TestDecodeJSONArray(t *testing.T) {
+	var items []any
+	var indexes []int
+	err := DecodeJSONArray(strings.NewReader(`[{"name": "Big Mac"}, 2, [3]] `), func(index int, item any) {
+		indexes = append(indexes, index)
+		items = append(items, item)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int{0, 1, 2}, indexes)
+	assert.Equal(t, []any{map[string]any{"name": "Big Mac"}, float64(2), []any{float64(3)}}, items)
+
+	each := func(int, any) {}
+	assert.EqualError(t, DecodeJSONArray(strings.NewReader(`{"name": "Big Mac"}`), each),
+		"the document is not an array")
+	assert.EqualError(t, DecodeJSONArray(strings.NewReader(`[1, 2`), each), "unexpected end of JSON input")
+	assert.EqualError(t, DecodeJSONArray(strings.NewReader(`[1, {"a": `), each), "unexpected end of JSON input")
+	assert.EqualError(t, DecodeJSONArray(strings.NewReader(`[1 2]`), each),
+		"invalid character '2' after array element")
+	assert.EqualError(t, DecodeJSONArray(strings.NewReader(`[1, 2] [3]`), each),
+		"invalid character after top-level value")
+}
+
+func TestStreamableItems(t *testing.T) {
+	compile := func(s string) bool {
+		jsch, err := NewCompiledSchema("streamable", []byte(s), config.NewValidationOptions())
+		require.NoError(t, err)
+		return StreamableItems(jsch) != nil
+	}
+	assert.True(t, compile(`{"type": "array", "items": {"type": "object"}, "description": "burgers"}`))
+	assert.True(t, compile(`{"items": {"type": "object"}}`))
+	assert.False(t, compile(`{"type": "array"}`))
+	assert.False(t, compile(`{"type": "object", "items": {"type": "object"}}`))
+	assert.False(t, compile(`{"type": "array", "items": {"type": "object"}, "maxItems": 2}`))
+	assert.False(t, compile(`{"type": "array", "items": {"type": "object"}, "uniqueItems": true}`))
+	assert.False(t, compile(`{"type": "array", "items": {"type": "object"}, "contains": {"type": "object"}}`))
+	assert.False(t, compile(`{"type": "array", "items": {"type": "object"}, "oneOf": [{"minItems": 1}]}`))
+}
@@ -0,0 +1,128 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package helpers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// RecordSeparator is the ASCII record separator that starts each record of an 'application/json-seq' body.
+const RecordSeparator byte = 0x1E
+
+// IsJSONSequence returns true for media types that carry a stream of JSON records rather than a single document:
+// newline delimited JSON ('application/x-ndjson'), JSON Lines ('application/jsonl') and JSON text sequences
+// ('application/json-seq', or any media type with the '+json-seq' suffix).
+func (m MediaType) IsJSONSequence() bool {
+	if m.Suffix() == "json-seq" {
+		return true
+	}
+	if m.Type != "application" {
+		return false
+	}
+	switch m.SubType {
+	case "x-ndjson", "ndjson", "jsonl", "x-jsonl", "jsonlines", "x-jsonlines", "json-seq":
+		return true
+	}
+	return false
+}
+
+// IsJSONSequenceContentType returns true if the content type is a stream of JSON records, see
+// MediaType.IsJSONSequence.
+func IsJSONSequenceContentType(contentType string) bool {
+	return ParseMediaType(contentType).IsJSONSequence()
+}
+
+// RecordTooLargeError is returned when a single record of a streamed body is larger than the configured maximum
+// record size. The record is skipped, the records that follow it can still be read.
+type RecordTooLargeError struct {
+	// Index is the zero based index of the record.
+	Index int
+
+	// Limit is the maximum size of a record, in bytes.
+	Limit int64
+}
+
+func (e *RecordTooLargeError) Error() string {
+	return fmt.Sprintf("record %d is larger than the limit of %d bytes", e.Index, e.Limit)
+}
+
+// JSONSequenceReader reads the records of a newline delimited JSON, JSON Lines or JSON text sequence body one at a
+// time, so only a single record is held in memory. Blank lines, and empty records, are skipped.
+type JSONSequenceReader struct {
+	reader    *bufio.Reader
+	delimiter byte
+	limit     int64
+	index     int
+	done      bool
+}
+
+// NewJSONSequenceReader creates a JSONSequenceReader for a body. Records of a 'json-seq' content type are
+// separated by the record separator, records of every other content type are separated by new lines. Records
+// larger than the limit are not held in memory, a limit of zero or less means no limit.
+func NewJSONSequenceReader(body io.Reader, contentType string, limit int64) *JSONSequenceReader {
+	delimiter := byte('\n')
+	if m := ParseMediaType(contentType); m.SubType == "json-seq" || m.Suffix() == "json-seq" {
+		delimiter = RecordSeparator
+	}
+	return &JSONSequenceReader{reader: bufio.NewReader(body), delimiter: delimiter, limit: limit, index: -1}
+}
+
+// Next reads and decodes the next record. The decoded value is returned with the raw bytes of the record. When
+// there are no more records io.EOF is returned. A record that is not valid JSON returns an error, and a record that
+// is larger than the limit returns a *RecordTooLargeError, but the reader can carry on with the following record.
+func (r *JSONSequenceReader) Next() (any, []byte, error) {
+	for !r.done {
+		record, tooLarge, err := r.readRecord()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				return nil, nil, err
+			}
+			r.done = true
+		}
+		if tooLarge {
+			r.index++
+			return nil, nil, &RecordTooLargeError{Index: r.index, Limit: r.limit}
+		}
+		record = bytes.TrimSpace(bytes.TrimSuffix(record, []byte{r.delimiter}))
+		if len(record) == 0 {
+			continue
+		}
+		r.index++
+		var decoded any
+		if err = json.Unmarshal(record, &decoded); err != nil {
+			return nil, record, fmt.Errorf("record %d is not valid JSON: %w", r.index, err)
+		}
+		return decoded, record, nil
+	}
+	return nil, nil, io.EOF
+}
+
+// Index returns the zero based index of the last record returned by Next, or -1 if no record has been read.
+func (r *JSONSequenceReader) Index() int {
+	return r.index
+}
+
+// readRecord reads the bytes up to and including the next delimiter. Once a record is larger than the limit, the
+// rest of it is read and discarded, and true is returned.
+func (r *JSONSequenceReader) readRecord() ([]byte, bool, error) {
+	var record []byte
+	tooLarge := false
+	for {
+		chunk, err := r.reader.ReadSlice(r.delimiter)
+		if !tooLarge {
+			record = append(record, chunk...)
+			if r.limit > 0 && int64(len(bytes.TrimSuffix(record, []byte{r.delimiter}))) > r.limit {
+				tooLarge, record = true, nil
+			}
+		}
+		if !errors.Is(err, bufio.ErrBufferFull) {
+			return record, tooLarge, err
+		}
+	}
+}
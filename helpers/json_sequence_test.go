@@ -0,0 +1,96 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package helpers
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsJSONSequenceContentType(t *testing.T) {
+	assert.True(t, IsJSONSequenceContentType("application/x-ndjson"))
+	assert.True(t, IsJSONSequenceContentType("application/jsonl; charset=utf-8"))
+	assert.True(t, IsJSONSequenceContentType("application/x-jsonlines"))
+	assert.True(t, IsJSONSequenceContentType("application/json-seq"))
+	assert.True(t, IsJSONSequenceContentType("application/geo+json-seq"))
+	assert.False(t, IsJSONSequenceContentType("application/json"))
+	assert.False(t, IsJSONSequenceContentType("text/jsonl"))
+}
+
+func TestJSONSequenceReader_Lines(t *testing.T) {
+	body := "{\"name\":\"one\"}\n\n  [1, 2]  \r\n{broken}\n\"last\""
+	r := NewJSONSequenceReader(strings.NewReader(body), "application/x-ndjson", 0)
+	assert.Equal(t, -1, r.Index())
+
+	decoded, record, err := r.Next()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"name": "one"}, decoded)
+	assert.Equal(t, `{"name":"one"}`, string(record))
+	assert.Equal(t, 0, r.Index())
+
+	decoded, record, err = r.Next()
+	require.NoError(t, err)
+	assert.Equal(t, []any{float64(1), float64(2)}, decoded)
+	assert.Equal(t, "[1, 2]", string(record))
+
+	_, record, err = r.Next()
+	assert.EqualError(t, err, "record 2 is not valid JSON: invalid character 'b' looking for beginning of object key string")
+	assert.Equal(t, "{broken}", string(record))
+
+	// the reader carries on after a broken record, the last record has no trailing new line.
+	decoded, _, err = r.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "last", decoded)
+	assert.Equal(t, 3, r.Index())
+
+	_, _, err = r.Next()
+	assert.Equal(t, io.EOF, err)
+	_, _, err = r.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestJSONSequenceReader_Sequence(t *testing.T) {
+	body := "\x1e{\"name\":\"one\"}\n\x1e{\n  \"name\": \"two\"\n}\n\x1e"
+	r := NewJSONSequenceReader(strings.NewReader(body), "application/json-seq", 0)
+
+	var decoded []any
+	for {
+		record, _, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		decoded = append(decoded, record)
+	}
+	assert.Equal(t, []any{map[string]any{"name": "one"}, map[string]any{"name": "two"}}, decoded)
+	assert.Equal(t, 1, r.Index())
+}
+
+func TestJSONSequenceReader_RecordTooLarge(t *testing.T) {
+	// the second record is larger than the limit, and larger than the buffer of the reader.
+	body := "{\"a\":1}\n\"" + strings.Repeat("x", 8192) + "\"\n[1,2]\n"
+	r := NewJSONSequenceReader(strings.NewReader(body), "application/x-ndjson", 8)
+
+	decoded, _, err := r.Next()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"a": float64(1)}, decoded)
+
+	_, record, err := r.Next()
+	assert.Nil(t, record)
+	assert.Equal(t, &RecordTooLargeError{Index: 1, Limit: 8}, err)
+	assert.EqualError(t, err, "record 1 is larger than the limit of 8 bytes")
+
+	// the reader carries on after the record that is too large.
+	decoded, _, err = r.Next()
+	require.NoError(t, err)
+	assert.Equal(t, []any{float64(1), float64(2)}, decoded)
+	assert.Equal(t, 2, r.Index())
+
+	_, _, err = r.Next()
+	assert.Equal(t, io.EOF, err)
+}
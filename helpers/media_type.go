@@ -0,0 +1,143 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package helpers
+
+import (
+	"strings"
+
+	"github.com/pb33f/libopenapi/orderedmap"
+)
+
+// MediaType is a media type, or a media range, parsed from a Content-Type header or from the content map of a
+// specification, for example 'application/vnd.burger+json; version=2' or 'text/*'.
+type MediaType struct {
+	// Type is the top level type in lower case, such as 'application', or '*'.
+	Type string
+
+	// SubType is the subtype in lower case, such as 'vnd.burger+json', or '*'.
+	SubType string
+
+	// Parameters are the parameters of the media type, keyed by lower case name.
+	Parameters map[string]string
+}
+
+// ParseMediaType parses a media type or media range. Parsing is lenient, a value without a subtype has an empty
+// SubType, and parameters that are not 'name=value' pairs are ignored. Quotes around parameter values are removed.
+func ParseMediaType(mediaType string) MediaType {
+	segs := strings.Split(mediaType, SemiColon)
+	t, sub, _ := strings.Cut(strings.ToLower(strings.TrimSpace(segs[0])), Slash)
+	parsed := MediaType{Type: strings.TrimSpace(t), SubType: strings.TrimSpace(sub)}
+	for _, seg := range segs[1:] {
+		k, v, found := strings.Cut(seg, Equals)
+		if !found {
+			continue
+		}
+		if parsed.Parameters == nil {
+			parsed.Parameters = make(map[string]string)
+		}
+		parsed.Parameters[strings.ToLower(strings.TrimSpace(k))] = strings.Trim(strings.TrimSpace(v), `"`)
+	}
+	return parsed
+}
+
+// String returns the type and subtype, without parameters.
+func (m MediaType) String() string {
+	return m.Type + Slash + m.SubType
+}
+
+// Suffix returns the structured syntax suffix of the subtype, such as 'json' for 'application/vnd.burger+json'.
+func (m MediaType) Suffix() string {
+	if i := strings.LastIndex(m.SubType, "+"); i >= 0 {
+		return m.SubType[i+1:]
+	}
+	return ""
+}
+
+// IsJSON returns true for 'application/json', 'text/json' and any media type with the '+json' suffix. Media types
+// that only start with 'json', such as 'application/jsonl' or 'application/json-seq', are not JSON documents.
+func (m MediaType) IsJSON() bool {
+	return m.isStructured(JSONType)
+}
+
+// IsXML returns true for 'application/xml', 'text/xml' and any media type with the '+xml' suffix.
+func (m MediaType) IsXML() bool {
+	return m.isStructured(XMLType)
+}
+
+func (m MediaType) isStructured(syntax string) bool {
+	if m.SubType == syntax {
+		return m.Type == "application" || m.Type == "text"
+	}
+	return m.Suffix() == syntax
+}
+
+// Match returns how specifically the media range m matches the media type, and false when it does not match at
+// all. A higher score is more specific: an exact subtype beats a suffix range such as 'application/*+json', which
+// beats 'application/*', which beats '*/*'. Every parameter of the range, other than 'q', must be present in the
+// media type with the same value, and each matching parameter adds to the score.
+func (m MediaType) Match(mediaType MediaType) (int, bool) {
+	var score int
+	switch {
+	case m.Type == Asterisk && m.SubType == Asterisk:
+		score = 0
+	case m.Type != mediaType.Type:
+		return 0, false
+	case m.SubType == mediaType.SubType:
+		score = 3
+	case m.SubType == Asterisk:
+		score = 1
+	case strings.HasPrefix(m.SubType, "*+") && mediaType.Suffix() == m.SubType[2:]:
+		score = 2
+	default:
+		return 0, false
+	}
+	score *= 100
+	for k, v := range m.Parameters {
+		if k == "q" {
+			continue
+		}
+		if value, ok := mediaType.Parameters[k]; !ok || !strings.EqualFold(value, v) {
+			return 0, false
+		}
+		score++
+	}
+	return score, true
+}
+
+// FindMediaType returns the entry of a content map that best matches a content type. The keys of the map are
+// media ranges, the most specific match wins, and ties go to the first entry in the specification.
+func FindMediaType[T any](content *orderedmap.Map[string, T], contentType string) (string, T, bool) {
+	ct := ParseMediaType(contentType)
+	var bestKey string
+	var best T
+	bestScore := -1
+	for pair := orderedmap.First(content); pair != nil; pair = pair.Next() {
+		if score, ok := ParseMediaType(pair.Key()).Match(ct); ok && score > bestScore {
+			bestKey, best, bestScore = pair.Key(), pair.Value(), score
+		}
+	}
+	return bestKey, best, bestScore >= 0
+}
+
+// MatchesMediaRanges returns true if the content type matches one of the comma separated media ranges, such as
+// 'image/png, image/*'.
+func MatchesMediaRanges(contentType, ranges string) bool {
+	ct := ParseMediaType(contentType)
+	for _, r := range strings.Split(ranges, Comma) {
+		if _, ok := ParseMediaType(r).Match(ct); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// IsJSONContentType returns true if the content type is a JSON document, see MediaType.IsJSON.
+func IsJSONContentType(contentType string) bool {
+	return ParseMediaType(contentType).IsJSON()
+}
+
+// IsXMLContentType returns true if the content type is an XML document, see MediaType.IsXML.
+func IsXMLContentType(contentType string) bool {
+	return ParseMediaType(contentType).IsXML()
+}
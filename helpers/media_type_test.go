@@ -0,0 +1,104 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package helpers
+
+import (
+	"testing"
+
+	"github.com/pb33f/libopenapi/orderedmap"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMediaType(t *testing.T) {
+	m := ParseMediaType(` Application/VND.Burger+JSON ; Version="2"; charset=utf-8; broken`)
+	assert.Equal(t, "application", m.Type)
+	assert.Equal(t, "vnd.burger+json", m.SubType)
+	assert.Equal(t, map[string]string{"version": "2", "charset": "utf-8"}, m.Parameters)
+	assert.Equal(t, "application/vnd.burger+json", m.String())
+	assert.Equal(t, "json", m.Suffix())
+
+	m = ParseMediaType("text")
+	assert.Equal(t, "text", m.Type)
+	assert.Empty(t, m.SubType)
+	assert.Nil(t, m.Parameters)
+}
+
+func TestMediaType_IsJSON(t *testing.T) {
+	assert.True(t, IsJSONContentType("application/json"))
+	assert.True(t, IsJSONContentType("text/json; charset=utf-8"))
+	assert.True(t, IsJSONContentType("application/problem+json"))
+	assert.False(t, IsJSONContentType("application/jsonl"))
+	assert.False(t, IsJSONContentType("application/json-seq"))
+	assert.False(t, IsJSONContentType("application/x-ndjson"))
+	assert.False(t, IsJSONContentType("image/json"))
+}
+
+func TestMediaType_IsXML(t *testing.T) {
+	assert.True(t, IsXMLContentType("application/xml"))
+	assert.True(t, IsXMLContentType("text/xml; charset=utf-8"))
+	assert.True(t, IsXMLContentType("application/atom+xml"))
+	assert.False(t, IsXMLContentType("application/json"))
+	assert.False(t, IsXMLContentType("application/xml-dtd"))
+}
+
+func TestMediaType_Match(t *testing.T) {
+	ct := ParseMediaType("application/vnd.burger+json; version=2; charset=utf-8")
+
+	score := func(r string) int {
+		s, ok := ParseMediaType(r).Match(ct)
+		if !ok {
+			return -1
+		}
+		return s
+	}
+	assert.Equal(t, 0, score("*/*"))
+	assert.Equal(t, 100, score("application/*"))
+	assert.Equal(t, 200, score("application/*+json"))
+	assert.Equal(t, 300, score("application/vnd.burger+json"))
+	assert.Equal(t, 301, score("application/vnd.burger+json; version=2"))
+	assert.Equal(t, 301, score("application/vnd.burger+json; version=2; q=0.5"))
+	assert.Equal(t, -1, score("application/vnd.burger+json; version=1"))
+	assert.Equal(t, -1, score("application/vnd.burger+json; profile=full"))
+	assert.Equal(t, -1, score("application/*+xml"))
+	assert.Equal(t, -1, score("text/*"))
+	assert.Equal(t, -1, score("application/json"))
+}
+
+func TestFindMediaType(t *testing.T) {
+	content := orderedmap.New[string, string]()
+	content.Set("*/*", "anything")
+	content.Set("text/*", "text")
+	content.Set("application/json", "json")
+	content.Set("application/json; version=2", "json v2")
+	content.Set("application/*+json", "structured json")
+
+	find := func(ct string) string {
+		_, v, _ := FindMediaType(content, ct)
+		return v
+	}
+	assert.Equal(t, "json", find("application/json"))
+	assert.Equal(t, "json", find("application/json; version=1"))
+	assert.Equal(t, "json v2", find("application/json; version=2"))
+	assert.Equal(t, "structured json", find("application/problem+json"))
+	assert.Equal(t, "text", find("text/csv; charset=utf-8"))
+	assert.Equal(t, "anything", find("application/jsonl"))
+
+	key, _, ok := FindMediaType(content, "image/png")
+	assert.True(t, ok)
+	assert.Equal(t, "*/*", key)
+
+	_, _, ok = FindMediaType(orderedmap.New[string, string](), "image/png")
+	assert.False(t, ok)
+	_, _, ok = FindMediaType[string](nil, "image/png")
+	assert.False(t, ok)
+}
+
+func TestMatchesMediaRanges(t *testing.T) {
+	assert.True(t, MatchesMediaRanges("image/png", "image/png"))
+	assert.True(t, MatchesMediaRanges("IMAGE/PNG; q=1", "image/jpeg, image/png"))
+	assert.True(t, MatchesMediaRanges("image/png", "image/*"))
+	assert.True(t, MatchesMediaRanges("text/plain", "*/*"))
+	assert.False(t, MatchesMediaRanges("image/png", "image/jpeg"))
+	assert.False(t, MatchesMediaRanges("imagery/png", "image/*"))
+}
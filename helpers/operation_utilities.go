@@ -43,13 +43,14 @@ func ExtractContentType(contentType string) (string, string, string) {
 		segs := strings.Split(contentType, SemiColon)
 		contentType = strings.TrimSpace(segs[0])
 		for _, v := range segs[1:] {
-			kv := strings.Split(v, Equals)
+			// parameter values may be quoted, and a boundary may itself contain '='.
+			kv := strings.SplitN(v, Equals, 2)
 			if len(kv) == 2 {
 				if strings.TrimSpace(strings.ToLower(kv[0])) == Charset {
-					charset = strings.TrimSpace(kv[1])
+					charset = strings.Trim(strings.TrimSpace(kv[1]), `"`)
 				}
 				if strings.TrimSpace(strings.ToLower(kv[0])) == Boundary {
-					boundary = strings.TrimSpace(kv[1])
+					boundary = strings.Trim(strings.TrimSpace(kv[1]), `"`)
 				}
 			}
 		}
@@ -58,3 +59,37 @@ func ExtractContentType(contentType string) (string, string, string) {
 	}
 	return contentType, charset, boundary
 }
+
+// DocumentOperation is an operation of a document, along with the path item and method it is defined for.
+type DocumentOperation struct {
+	// Path is the path of the operation, as it appears in the document.
+	Path string
+	// Method is the HTTP method of the operation, in upper case.
+	Method    string
+	PathItem  *v3.PathItem
+	Operation *v3.Operation
+}
+
+// ExtractDocumentOperations returns every operation of a document, in the order of the paths and methods of the
+// document.
+func ExtractDocumentOperations(document *v3.Document) []DocumentOperation {
+	if document == nil || document.Paths == nil || document.Paths.PathItems == nil {
+		return nil
+	}
+	var operations []DocumentOperation
+	for pair := document.Paths.PathItems.First(); pair != nil; pair = pair.Next() {
+		item := pair.Value()
+		if item == nil {
+			continue
+		}
+		for op := item.GetOperations().First(); op != nil; op = op.Next() {
+			operations = append(operations, DocumentOperation{
+				Path:      pair.Key(),
+				Method:    strings.ToUpper(op.Key()),
+				PathItem:  item,
+				Operation: op.Value(),
+			})
+		}
+	}
+	return operations
+}
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"testing"
 
+	"github.com/pb33f/libopenapi"
 	"github.com/pb33f/libopenapi/datamodel/high/v3"
 	"github.com/stretchr/testify/require"
 )
@@ -52,6 +53,37 @@ func TestExtractOperation(t *testing.T) {
 	require.Nil(t, operation)
 }
 
+func TestExtractDocumentOperations(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /burgers:
+    post:
+      summary: create
+    get:
+      summary: list
+  /burgers/{id}:
+    delete:
+      summary: delete`
+	doc, err := libopenapi.NewDocument([]byte(spec))
+	require.NoError(t, err)
+	m, errs := doc.BuildV3Model()
+	require.Empty(t, errs)
+
+	operations := ExtractDocumentOperations(&m.Model)
+	require.Len(t, operations, 3)
+	require.Equal(t, "/burgers", operations[0].Path)
+	// operations are in the order they appear in the document.
+	require.Equal(t, http.MethodPost, operations[0].Method)
+	require.Equal(t, "create", operations[0].Operation.Summary)
+	require.Equal(t, http.MethodGet, operations[1].Method)
+	require.Equal(t, "/burgers/{id}", operations[2].Path)
+	require.Equal(t, http.MethodDelete, operations[2].Method)
+	require.Same(t, m.Model.Paths.PathItems.GetOrZero("/burgers/{id}"), operations[2].PathItem)
+
+	require.Nil(t, ExtractDocumentOperations(nil))
+	require.Nil(t, ExtractDocumentOperations(&v3.Document{}))
+}
+
 // Test ExtractContentType for various input cases
 func TestExtractContentType(t *testing.T) {
 	// Simple content type with no charset or boundary
@@ -84,6 +116,12 @@ func TestExtractContentType(t *testing.T) {
 	require.Equal(t, "ISO-8859-1", charset)
 	require.Equal(t, "myBoundary", boundary)
 
+	// Quoted boundary containing '='
+	contentType, charset, boundary = ExtractContentType(`multipart/form-data; boundary="a=b==c"`)
+	require.Equal(t, "multipart/form-data", contentType)
+	require.Empty(t, charset)
+	require.Equal(t, "a=b==c", boundary)
+
 	// Invalid content type (no key-value pair for charset/boundary)
 	contentType, charset, boundary = ExtractContentType("application/xml; charset; boundary")
 	require.Equal(t, "application/xml", contentType)
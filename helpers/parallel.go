@@ -0,0 +1,85 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package helpers
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// RunParallel calls every job, using no more goroutines than there are CPUs available, and returns the errors of
+// the jobs that failed. The errors are in the order of the jobs, not the order in which the jobs finished.
+func RunParallel(jobs []func() error) []error {
+	results := make([]error, len(jobs))
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for range min(runtime.GOMAXPROCS(0), len(jobs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(next.Add(1) - 1)
+				if i >= len(jobs) {
+					return
+				}
+				results[i] = jobs[i]()
+			}
+		}()
+	}
+	wg.Wait()
+
+	var errs []error
+	for _, err := range results {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// WorkerPool bounds the number of goroutines that run jobs in parallel, across every caller that shares the pool.
+// A job never waits for a worker, when every worker is busy the job is run by the goroutine of the caller, which
+// would otherwise be waiting for the jobs to finish.
+type WorkerPool struct {
+	workers chan struct{}
+}
+
+// NewWorkerPool creates a WorkerPool of size workers. A size of zero or less uses the number of CPUs available.
+func NewWorkerPool(size int) *WorkerPool {
+	if size <= 0 {
+		size = runtime.GOMAXPROCS(0)
+	}
+	return &WorkerPool{workers: make(chan struct{}, size)}
+}
+
+// Size returns the maximum number of workers of the pool.
+func (p *WorkerPool) Size() int {
+	return cap(p.workers)
+}
+
+// Run calls every job, and returns once they have all finished. Jobs are handed to the free workers of the pool in
+// order, the last job, and any job that finds no free worker, is run by the caller.
+func (p *WorkerPool) Run(jobs []func()) {
+	var wg sync.WaitGroup
+	for i, job := range jobs {
+		if i < len(jobs)-1 {
+			select {
+			case p.workers <- struct{}{}:
+				wg.Add(1)
+				go func() {
+					defer func() {
+						<-p.workers
+						wg.Done()
+					}()
+					job()
+				}()
+				continue
+			default:
+			}
+		}
+		job()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,95 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunParallel(t *testing.T) {
+	var calls atomic.Int64
+	var jobs []func() error
+	for i := range 100 {
+		jobs = append(jobs, func() error {
+			calls.Add(1)
+			if i%10 == 0 {
+				return fmt.Errorf("job %d", i)
+			}
+			return nil
+		})
+	}
+
+	errs := RunParallel(jobs)
+	assert.Equal(t, int64(100), calls.Load())
+	assert.Len(t, errs, 10)
+	for i, err := range errs {
+		assert.EqualError(t, err, fmt.Sprintf("job %d", i*10))
+	}
+}
+
+func TestRunParallel_NoJobs(t *testing.T) {
+	assert.Empty(t, RunParallel(nil))
+	assert.Empty(t, RunParallel([]func() error{func() error { return nil }}))
+	assert.Len(t, RunParallel([]func() error{func() error { return errors.New("nope") }}), 1)
+}
+
+func TestWorkerPool(t *testing.T) {
+	pool := NewWorkerPool(2)
+	assert.Equal(t, 2, pool.Size())
+	assert.Equal(t, runtime.GOMAXPROCS(0), NewWorkerPool(0).Size())
+
+	// several callers share the pool, no more than two workers run alongside the callers.
+	const callers = 4
+	var running, maxRunning, calls atomic.Int64
+	var wg sync.WaitGroup
+	for range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results := make([]int, 50)
+			jobs := make([]func(), len(results))
+			for i := range jobs {
+				jobs[i] = func() {
+					n := running.Add(1)
+					for {
+						m := maxRunning.Load()
+						if n <= m || maxRunning.CompareAndSwap(m, n) {
+							break
+						}
+					}
+					time.Sleep(time.Microsecond)
+					results[i] = i
+					calls.Add(1)
+					running.Add(-1)
+				}
+			}
+			pool.Run(jobs)
+			for i, result := range results {
+				assert.Equal(t, i, result)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int64(callers*50), calls.Load())
+	assert.LessOrEqual(t, maxRunning.Load(), int64(callers+pool.Size()))
+	assert.Empty(t, pool.workers)
+}
+
+func TestWorkerPool_NoJobs(t *testing.T) {
+	pool := NewWorkerPool(1)
+	pool.Run(nil)
+
+	called := false
+	pool.Run([]func(){func() { called = true }})
+	assert.True(t, called)
+	assert.Empty(t, pool.workers)
+}
@@ -4,6 +4,8 @@
 package helpers
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"slices"
@@ -13,6 +15,8 @@ import (
 	"github.com/pb33f/libopenapi/datamodel/high/base"
 
 	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+
+	"github.com/pb33f/libopenapi-validator/config"
 )
 
 // QueryParam is a struct that holds the key, values and property name for a query parameter
@@ -66,43 +70,26 @@ func ExtractParamsForOperation(request *http.Request, item *v3.PathItem) []*v3.P
 }
 
 // ExtractSecurityForOperation will extract the security requirements for the operation based on the request method.
+// An operation that opts out of security with an empty list returns an empty, non-nil slice. An operation that does
+// not define any security returns nil, use ExtractEffectiveSecurity to fall back to the document security.
 func ExtractSecurityForOperation(request *http.Request, item *v3.PathItem) []*base.SecurityRequirement {
-	var schemes []*base.SecurityRequirement
-	switch request.Method {
-	case http.MethodGet:
-		if item.Get != nil {
-			schemes = append(schemes, item.Get.Security...)
-		}
-	case http.MethodPost:
-		if item.Post != nil {
-			schemes = append(schemes, item.Post.Security...)
-		}
-	case http.MethodPut:
-		if item.Put != nil {
-			schemes = append(schemes, item.Put.Security...)
-		}
-	case http.MethodDelete:
-		if item.Delete != nil {
-			schemes = append(schemes, item.Delete.Security...)
-		}
-	case http.MethodOptions:
-		if item.Options != nil {
-			schemes = append(schemes, item.Options.Security...)
-		}
-	case http.MethodHead:
-		if item.Head != nil {
-			schemes = append(schemes, item.Head.Security...)
-		}
-	case http.MethodPatch:
-		if item.Patch != nil {
-			schemes = append(schemes, item.Patch.Security...)
-		}
-	case http.MethodTrace:
-		if item.Trace != nil {
-			schemes = append(schemes, item.Trace.Security...)
-		}
+	if operation := ExtractOperation(request, item); operation != nil {
+		return operation.Security
 	}
-	return schemes
+	return nil
+}
+
+// ExtractEffectiveSecurity will extract the security requirements that apply to the operation based on the request
+// method. Security defined on the operation overrides the security defined at the top level of the document, an
+// empty list on the operation removes all security for that operation.
+func ExtractEffectiveSecurity(request *http.Request, item *v3.PathItem, document *v3.Document) []*base.SecurityRequirement {
+	if security := ExtractSecurityForOperation(request, item); security != nil {
+		return security
+	}
+	if document != nil {
+		return document.Security
+	}
+	return nil
 }
 
 func cast(v string) any {
@@ -121,6 +108,30 @@ func cast(v string) any {
 	return v
 }
 
+// CastToSchemaType converts a string value to the first type of the schema it can be read as. Values that cannot
+// be converted, or that are read against a schema without a type, are left as strings, so a schema check
+// will report any type mismatch.
+func CastToSchemaType(value string, schema *base.Schema) any {
+	if schema == nil {
+		return value
+	}
+	for _, ty := range schema.Type {
+		switch ty {
+		case Integer, Number:
+			if f, err := strconv.ParseFloat(value, 64); err == nil {
+				return f
+			}
+		case Boolean:
+			if value == "true" || value == "false" {
+				return value == "true"
+			}
+		case String:
+			return value
+		}
+	}
+	return value
+}
+
 // ConstructParamMapFromDeepObjectEncoding will construct a map from the query parameters that are encoded as
 // deep objects. It's kind of a crazy way to do things, but hey, each to their own.
 func ConstructParamMapFromDeepObjectEncoding(values []*QueryParam, sch *base.Schema) map[string]interface{} {
@@ -311,6 +322,80 @@ func DoesFormParamContainDelimiter(value, style string) bool {
 	return false
 }
 
+// DecodeHeaderArray splits a header array in the 'simple' style, the only style of headers, into its items. The
+// whitespace around each comma is removed, so repeated header lines that are joined by ', ' are read as one array.
+func DecodeHeaderArray(value string) []string {
+	items := ExplodeQueryValue(value, DefaultDelimited)
+	for i := range items {
+		items[i] = strings.TrimSpace(items[i])
+	}
+	return items
+}
+
+// DecodeHeaderObject decodes a header object in the 'simple' style, from comma separated keys and values, or from
+// comma separated 'key=value' pairs when exploded. An empty map is returned when the value holds no properties.
+func DecodeHeaderObject(value string, explode bool) map[string]interface{} {
+	if explode {
+		return ConstructKVFromCSV(value)
+	}
+	return ConstructMapFromCSV(value)
+}
+
+// DecodeSimpleHeader decodes a header value in the 'simple' style using DecodeHeaderArray and DecodeHeaderObject,
+// the items of an array and other values are converted to the type of the schema. An error is returned when an
+// object header holds no properties.
+func DecodeSimpleHeader(value string, schema *base.Schema, explode bool) (any, error) {
+	if schema == nil {
+		return value, nil
+	}
+	switch {
+	case slices.Contains(schema.Type, Array):
+		var items *base.Schema
+		if schema.Items != nil && schema.Items.IsA() {
+			items = schema.Items.A.Schema()
+		}
+		var decoded []any
+		for _, item := range DecodeHeaderArray(value) {
+			decoded = append(decoded, CastToSchemaType(item, items))
+		}
+		return decoded, nil
+	case slices.Contains(schema.Type, Object):
+		decoded := DecodeHeaderObject(value, explode)
+		if len(decoded) == 0 {
+			return nil, fmt.Errorf("the value cannot be extracted into an object")
+		}
+		return decoded, nil
+	}
+	return CastToSchemaType(value, schema), nil
+}
+
+// DecodeHeaderContent decodes a header value that is defined using content, with the BodyDecoder registered for
+// the media type, or as JSON. A 'text/plain' value is used as it is. False is returned for any other media type,
+// as the value cannot be decoded.
+func DecodeHeaderContent(
+	ctx context.Context,
+	value string,
+	mediaType string,
+	schema *base.Schema,
+	options *config.ValidationOptions,
+) (any, bool, error) {
+	if options != nil {
+		if decoder := options.FindBodyDecoder(mediaType); decoder != nil {
+			decoded, err := config.DecodeBody(ctx, decoder, []byte(value), schema)
+			return decoded, true, err
+		}
+	}
+	switch {
+	case IsJSONContentType(mediaType):
+		var decoded any
+		err := json.Unmarshal([]byte(value), &decoded)
+		return decoded, true, err
+	case strings.EqualFold(strings.TrimSpace(strings.Split(mediaType, SemiColon)[0]), TextPlainContentType):
+		return value, true, nil
+	}
+	return nil, false, nil
+}
+
 // ExplodeQueryValue will explode a query value based on the style (space, pipe, or form/default).
 func ExplodeQueryValue(value, style string) []string {
 	switch style {
@@ -4,6 +4,7 @@
 package helpers
 
 import (
+	"context"
 	"net/http"
 	"testing"
 
@@ -11,6 +12,8 @@ import (
 	"github.com/stretchr/testify/require"
 
 	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+
+	"github.com/pb33f/libopenapi-validator/config"
 )
 
 // Test ExtractParamsForOperation with various HTTP methods
@@ -479,3 +482,109 @@ func TestConstructParamMapFromFormEncodingArray(t *testing.T) {
 	require.Equal(t, true, decoded["param1"].(map[string]interface{})["key2"])       // cast to bool
 	require.Equal(t, "hello", decoded["param1"].(map[string]interface{})["key3"])    // string remains string
 }
+
+func TestExtractEffectiveSecurity(t *testing.T) {
+	docSecurity := []*base.SecurityRequirement{{}}
+	opSecurity := []*base.SecurityRequirement{{}, {}}
+	document := &v3.Document{Security: docSecurity}
+	pathItem := &v3.PathItem{
+		Get:  &v3.Operation{},
+		Post: &v3.Operation{Security: opSecurity},
+		Put:  &v3.Operation{Security: []*base.SecurityRequirement{}},
+	}
+
+	request, _ := http.NewRequest(http.MethodGet, "/", nil)
+	require.Nil(t, ExtractSecurityForOperation(request, pathItem))
+	require.Equal(t, docSecurity, ExtractEffectiveSecurity(request, pathItem, document))
+	require.Nil(t, ExtractEffectiveSecurity(request, pathItem, nil))
+
+	request, _ = http.NewRequest(http.MethodPost, "/", nil)
+	require.Equal(t, opSecurity, ExtractEffectiveSecurity(request, pathItem, document))
+
+	// an empty list opts out of the document security.
+	request, _ = http.NewRequest(http.MethodPut, "/", nil)
+	security := ExtractEffectiveSecurity(request, pathItem, document)
+	require.NotNil(t, security)
+	require.Empty(t, security)
+
+	// there is no operation, so the document security applies.
+	request, _ = http.NewRequest(http.MethodPatch, "/", nil)
+	require.Equal(t, docSecurity, ExtractEffectiveSecurity(request, pathItem, document))
+}
+
+func TestCastToSchemaType(t *testing.T) {
+	require.Equal(t, "01234", CastToSchemaType("01234", &base.Schema{Type: []string{String}}))
+	require.Equal(t, float64(1234), CastToSchemaType("01234", &base.Schema{Type: []string{Integer}}))
+	require.Equal(t, 1.5, CastToSchemaType("1.5", &base.Schema{Type: []string{Number}}))
+	require.Equal(t, true, CastToSchemaType("true", &base.Schema{Type: []string{Boolean}}))
+	require.Equal(t, "yes", CastToSchemaType("yes", &base.Schema{Type: []string{Boolean}}))
+	require.Equal(t, float64(2), CastToSchemaType("2", &base.Schema{Type: []string{Boolean, Integer}}))
+	require.Equal(t, "2", CastToSchemaType("2", nil))
+}
+
+func TestDecodeHeaderArray(t *testing.T) {
+	require.Equal(t, []string{"1", "2", "3"}, DecodeHeaderArray("1, 2,3"))
+	require.Equal(t, []string{"one"}, DecodeHeaderArray("one"))
+}
+
+func TestDecodeHeaderObject(t *testing.T) {
+	require.Equal(t, map[string]interface{}{"name": "Big Mac", "patties": int64(2)},
+		DecodeHeaderObject("name,Big Mac,patties,2", false))
+	require.Equal(t, map[string]interface{}{"patties": int64(2)}, DecodeHeaderObject("patties=2", true))
+	require.Empty(t, DecodeHeaderObject("patties", true))
+}
+
+func TestDecodeSimpleHeader(t *testing.T) {
+	decoded, err := DecodeSimpleHeader("1, 2", &base.Schema{
+		Type:  []string{Array},
+		Items: &base.DynamicValue[*base.SchemaProxy, bool]{A: base.CreateSchemaProxy(&base.Schema{Type: []string{Integer}})},
+	}, false)
+	require.NoError(t, err)
+	require.Equal(t, []any{float64(1), float64(2)}, decoded)
+
+	decoded, err = DecodeSimpleHeader("patties=2", &base.Schema{Type: []string{Object}}, true)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"patties": int64(2)}, decoded)
+
+	_, err = DecodeSimpleHeader("patties", &base.Schema{Type: []string{Object}}, true)
+	require.EqualError(t, err, "the value cannot be extracted into an object")
+
+	decoded, err = DecodeSimpleHeader("10", &base.Schema{Type: []string{Integer}}, false)
+	require.NoError(t, err)
+	require.Equal(t, float64(10), decoded)
+
+	decoded, err = DecodeSimpleHeader("10", nil, false)
+	require.NoError(t, err)
+	require.Equal(t, "10", decoded)
+}
+
+func TestDecodeHeaderContent(t *testing.T) {
+	ctx := context.Background()
+	decoded, ok, err := DecodeHeaderContent(ctx, `{"name": "Big Mac"}`, JSONContentType, nil, nil)
+	require.True(t, ok)
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"name": "Big Mac"}, decoded)
+
+	_, ok, err = DecodeHeaderContent(ctx, `{"name":`, JSONContentType, nil, nil)
+	require.True(t, ok)
+	require.Error(t, err)
+
+	decoded, ok, err = DecodeHeaderContent(ctx, "Big Mac", "text/plain; charset=utf-8", nil, nil)
+	require.True(t, ok)
+	require.NoError(t, err)
+	require.Equal(t, "Big Mac", decoded)
+
+	// a media type without a decoder cannot be decoded.
+	_, ok, err = DecodeHeaderContent(ctx, "<burger/>", "application/xml", nil, config.NewValidationOptions())
+	require.False(t, ok)
+	require.NoError(t, err)
+
+	options := config.NewValidationOptions(config.WithBodyDecoder("application/xml",
+		config.BodyDecoderFunc(func(body []byte, _ *base.Schema) (any, error) {
+			return map[string]any{"xml": string(body)}, nil
+		})))
+	decoded, ok, err = DecodeHeaderContent(ctx, "<burger/>", "application/xml", nil, options)
+	require.True(t, ok)
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"xml": "<burger/>"}, decoded)
+}
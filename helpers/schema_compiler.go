@@ -0,0 +1,152 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package helpers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+
+	"github.com/pb33f/libopenapi-validator/config"
+)
+
+// NewCompiler creates a new JSON schema compiler, configured using the supplied validation options.
+// If no options are supplied, the defaults are used.
+func NewCompiler(o *config.ValidationOptions) *jsonschema.Compiler {
+	if o == nil {
+		o = config.NewValidationOptions()
+	}
+	compiler := jsonschema.NewCompiler()
+	if o.SchemaLoader != nil {
+		compiler.UseLoader(o.SchemaLoader)
+	} else {
+		compiler.UseLoader(NewCompilerLoader())
+	}
+	if o.RegexEngine != nil {
+		compiler.UseRegexpEngine(o.RegexEngine)
+	}
+	if o.FormatAssertions {
+		compiler.AssertFormat()
+	}
+	if o.ContentAssertions {
+		compiler.AssertContent()
+	}
+	return compiler
+}
+
+// NewCompilerContext creates a new JSON schema compiler in the same way as NewCompiler. The context is supplied to
+// the schema loader when it implements config.ContextURLLoader, which the default loader does.
+func NewCompilerContext(ctx context.Context, o *config.ValidationOptions) *jsonschema.Compiler {
+	if o == nil {
+		o = config.NewValidationOptions()
+	}
+	compiler := NewCompiler(o)
+	if o.SchemaLoader == nil {
+		compiler.UseLoader(NewCompilerLoaderContext(ctx))
+	} else if loader, ok := o.SchemaLoader.(config.ContextURLLoader); ok {
+		compiler.UseLoader(NewContextLoader(ctx, loader))
+	}
+	return compiler
+}
+
+// NewCompiledSchema compiles a rendered JSON schema into a *jsonschema.Schema that is ready to validate
+// objects. The name is used as the resource name for the schema within the compiler.
+func NewCompiledSchema(name string, jsonSchema []byte, o *config.ValidationOptions) (*jsonschema.Schema, error) {
+	return NewCompiledSchemaContext(context.Background(), name, jsonSchema, o)
+}
+
+// NewCompiledSchemaContext compiles a rendered JSON schema in the same way as NewCompiledSchema, the context is
+// supplied to the schema loader, see NewCompilerContext.
+func NewCompiledSchemaContext(
+	ctx context.Context,
+	name string,
+	jsonSchema []byte,
+	o *config.ValidationOptions,
+) (*jsonschema.Schema, error) {
+	compiler := NewCompilerContext(ctx, o)
+	resourceName := fmt.Sprintf("%s.json", name)
+	decodedSchema, err := jsonschema.UnmarshalJSON(bytes.NewReader(jsonSchema))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode schema '%s': %w", name, err)
+	}
+	if err = compiler.AddResource(resourceName, decodedSchema); err != nil {
+		return nil, fmt.Errorf("failed to add schema '%s' to compiler: %w", name, err)
+	}
+	jsch, err := compiler.Compile(resourceName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile schema '%s': %w", name, err)
+	}
+	return jsch, nil
+}
+
+// CompiledSchemaKey identifies a compiled schema in a schema cache. A schema is identified by the hash of its
+// rendered form rather than the hash of the document model, which hashes a reference by its name alone, so the same
+// reference in two documents that share a cache would be mistaken for one schema. The format and content assertions
+// are part of the key, but the regex engine is only identified by its function, so two closures of the same function
+// are not told apart, and the schema loader is not part of the key at all. Validators that share a cache must use the
+// same options, see config.SchemaCache.
+type CompiledSchemaKey struct {
+	Hash              [32]byte
+	RegexEngine       uintptr
+	FormatAssertions  bool
+	ContentAssertions bool
+}
+
+// NewCompiledSchemaKey creates the key of a rendered schema, compiled using the supplied validation options.
+func NewCompiledSchemaKey(renderedSchema []byte, o *config.ValidationOptions) CompiledSchemaKey {
+	key := CompiledSchemaKey{Hash: sha256.Sum256(renderedSchema)}
+	if o != nil {
+		if o.RegexEngine != nil {
+			key.RegexEngine = reflect.ValueOf(o.RegexEngine).Pointer()
+		}
+		key.FormatAssertions = o.FormatAssertions
+		key.ContentAssertions = o.ContentAssertions
+	}
+	return key
+}
+
+// SchemaKeys remembers the CompiledSchemaKey of each schema of a document, so a schema is only rendered to create its
+// key the first time it is seen, rather than every time it is used to validate. The zero value is ready to use, and
+// it is safe for concurrent use.
+type SchemaKeys struct {
+	keys sync.Map
+}
+
+// Key returns the key of the schema identified by id, which must be comparable, for example the *base.Schema of the
+// document model. The schema is rendered by calling render when it has not been seen before.
+func (k *SchemaKeys) Key(id any, render func() []byte, o *config.ValidationOptions) CompiledSchemaKey {
+	if key, ok := k.keys.Load(id); ok {
+		return key.(CompiledSchemaKey)
+	}
+	key := NewCompiledSchemaKey(render(), o)
+	k.keys.Store(id, key)
+	return key
+}
+
+// SchemaCompileError is returned when a schema of a document is compiled ahead of validation, and it cannot be
+// compiled, so any request or response that uses the schema would fail validation.
+type SchemaCompileError struct {
+	// Path is the path of the operation the schema belongs to, as it appears in the document.
+	Path string
+	// Method is the HTTP method of the operation the schema belongs to.
+	Method string
+	// Location describes where the schema is found in the operation, for example "query parameter 'id'".
+	Location string
+	// Err is the error returned by the schema compiler.
+	Err error
+}
+
+func (e *SchemaCompileError) Error() string {
+	return fmt.Sprintf("the schema of the %s of %s %s cannot be compiled: %s", e.Location, e.Method, e.Path,
+		e.Err.Error())
+}
+
+func (e *SchemaCompileError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,125 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package helpers
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pb33f/libopenapi-validator/config"
+)
+
+func TestNewCompiledSchema(t *testing.T) {
+	jsch, err := NewCompiledSchema("test", []byte(`{"type": "string"}`), nil)
+	assert.NoError(t, err)
+	assert.NoError(t, jsch.Validate("hello"))
+	assert.Error(t, jsch.Validate(123))
+}
+
+func TestNewCompiledSchema_BadJSON(t *testing.T) {
+	jsch, err := NewCompiledSchema("test", []byte(`{"type": `), nil)
+	assert.Error(t, err)
+	assert.Nil(t, jsch)
+}
+
+func TestNewCompiledSchema_InvalidSchema(t *testing.T) {
+	jsch, err := NewCompiledSchema("test", []byte(`{"type": 1234}`), nil)
+	assert.Error(t, err)
+	assert.Nil(t, jsch)
+	assert.Contains(t, err.Error(), "failed to compile schema 'test'")
+}
+
+func TestNewCompiledSchema_FormatAssertions(t *testing.T) {
+	schema := []byte(`{"type": "string", "format": "email"}`)
+
+	jsch, err := NewCompiledSchema("test", schema, config.NewValidationOptions())
+	assert.NoError(t, err)
+	assert.NoError(t, jsch.Validate("not an email"))
+
+	jsch, err = NewCompiledSchema("test", schema, config.NewValidationOptions(config.WithFormatAssertions()))
+	assert.NoError(t, err)
+	assert.Error(t, jsch.Validate("not an email"))
+}
+
+func TestNewCompiledSchema_ContentAssertions(t *testing.T) {
+	schema := []byte(`{"type": "string", "contentEncoding": "base64"}`)
+
+	jsch, err := NewCompiledSchema("test", schema, config.NewValidationOptions(config.WithContentAssertions()))
+	assert.NoError(t, err)
+	assert.NoError(t, jsch.Validate("aGVsbG8="))
+	assert.Error(t, jsch.Validate("not base64!"))
+}
+
+func TestNewCompiledSchema_RegexEngine(t *testing.T) {
+	called := false
+	engine := func(s string) (jsonschema.Regexp, error) {
+		called = true
+		return regexp.Compile(s)
+	}
+	jsch, err := NewCompiledSchema("test", []byte(`{"type": "string", "pattern": "^[a-z]+$"}`),
+		config.NewValidationOptions(config.WithRegexEngine(engine)))
+	assert.NoError(t, err)
+	assert.True(t, called)
+	assert.Error(t, jsch.Validate("ABC"))
+}
+
+type failingLoader struct{}
+
+func (failingLoader) Load(url string) (any, error) {
+	return nil, errors.New("no remote schemas allowed")
+}
+
+func TestNewCompiledSchema_SchemaLoader(t *testing.T) {
+	_, err := NewCompiledSchema("test", []byte(`{"$ref": "https://pb33f.io/nope.json"}`),
+		config.NewValidationOptions(config.WithSchemaLoader(failingLoader{})))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "no remote schemas allowed")
+}
+
+func TestNewCompiledSchemaKey(t *testing.T) {
+	schema := []byte(`{"type": "string", "format": "email"}`)
+	key := NewCompiledSchemaKey(schema, config.NewValidationOptions())
+	assert.Equal(t, key, NewCompiledSchemaKey(schema, nil))
+	assert.Equal(t, key, NewCompiledSchemaKey([]byte(`{"type": "string", "format": "email"}`), nil))
+
+	// the content of the schema and the compile options are part of the key.
+	assert.NotEqual(t, key, NewCompiledSchemaKey([]byte(`{"type": "string"}`), nil))
+	assert.NotEqual(t, key, NewCompiledSchemaKey(schema, config.NewValidationOptions(config.WithFormatAssertions())))
+	assert.NotEqual(t, key, NewCompiledSchemaKey(schema, config.NewValidationOptions(config.WithContentAssertions())))
+
+	engine := func(expr string) (jsonschema.Regexp, error) {
+		return regexp.Compile(expr)
+	}
+	assert.NotEqual(t, key, NewCompiledSchemaKey(schema, config.NewValidationOptions(config.WithRegexEngine(engine))))
+}
+
+func TestSchemaKeys(t *testing.T) {
+	var keys SchemaKeys
+	renders := 0
+	render := func() []byte {
+		renders++
+		return []byte(`{"type": "string"}`)
+	}
+	key := keys.Key("burger", render, nil)
+	assert.Equal(t, NewCompiledSchemaKey([]byte(`{"type": "string"}`), nil), key)
+
+	// the key is remembered, so the schema is only rendered once.
+	assert.Equal(t, key, keys.Key("burger", render, nil))
+	assert.Equal(t, 1, renders)
+
+	keys.Key("fries", render, nil)
+	assert.Equal(t, 2, renders)
+}
+
+func TestSchemaCompileError(t *testing.T) {
+	cause := errors.New("failed to compile schema 'id'")
+	err := &SchemaCompileError{Path: "/burgers/{id}", Method: "GET", Location: "path parameter 'id'", Err: cause}
+	assert.EqualError(t, err, "the schema of the path parameter 'id' of GET /burgers/{id} cannot be compiled: "+
+		"failed to compile schema 'id'")
+	assert.ErrorIs(t, err, cause)
+}
@@ -4,12 +4,15 @@
 package helpers
 
 import (
+	"context"
 	"crypto/tls"
 	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/santhosh-tekuri/jsonschema/v6"
+
+	"github.com/pb33f/libopenapi-validator/config"
 )
 
 // HTTPURLLoader is a type that implements the Loader interface for loading schemas from HTTP URLs.
@@ -22,8 +25,17 @@ import (
 type HTTPURLLoader http.Client
 
 func (l *HTTPURLLoader) Load(url string) (any, error) {
+	return l.LoadContext(context.Background(), url)
+}
+
+// LoadContext loads a schema in the same way as Load, the request for the schema is made using the context.
+func (l *HTTPURLLoader) LoadContext(ctx context.Context, url string) (any, error) {
 	client := (*http.Client)(l)
-	resp, err := client.Get(url)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(request)
 	if err != nil {
 		return nil, err
 	}
@@ -55,3 +67,28 @@ func NewCompilerLoader() jsonschema.SchemeURLLoader {
 		"https": NewHTTPURLLoader(false),
 	}
 }
+
+// NewCompilerLoaderContext creates the same loader as NewCompilerLoader, remote schemas are requested using the
+// context.
+func NewCompilerLoaderContext(ctx context.Context) jsonschema.SchemeURLLoader {
+	return jsonschema.SchemeURLLoader{
+		"file":  jsonschema.FileLoader{},
+		"http":  NewContextLoader(ctx, NewHTTPURLLoader(false)),
+		"https": NewContextLoader(ctx, NewHTTPURLLoader(false)),
+	}
+}
+
+// NewContextLoader binds a context to a config.ContextURLLoader, so it can be used by the schema compiler, which
+// does not supply a context when it loads a schema.
+func NewContextLoader(ctx context.Context, loader config.ContextURLLoader) jsonschema.URLLoader {
+	return &contextLoader{ctx: ctx, loader: loader}
+}
+
+type contextLoader struct {
+	ctx    context.Context
+	loader config.ContextURLLoader
+}
+
+func (l *contextLoader) Load(url string) (any, error) {
+	return l.loader.LoadContext(l.ctx, url)
+}
@@ -4,6 +4,7 @@
 package helpers
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -11,6 +12,8 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/pb33f/libopenapi-validator/config"
 )
 
 // Test the Load function for a successful case
@@ -102,3 +105,53 @@ func TestNewCompilerLoader(t *testing.T) {
 	require.NotNil(t, loader["https"])
 	require.NotNil(t, loader["file"])
 }
+
+// Test the LoadContext function with a context that is already cancelled
+func TestHTTPURLLoader_LoadContext_Cancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"success": true}`)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := NewHTTPURLLoader(false).LoadContext(ctx, server.URL)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+type contextURLLoader struct {
+	ctx context.Context
+}
+
+func (l *contextURLLoader) Load(url string) (any, error) {
+	return l.LoadContext(context.Background(), url)
+}
+
+func (l *contextURLLoader) LoadContext(ctx context.Context, url string) (any, error) {
+	l.ctx = ctx
+	return map[string]any{"type": "string"}, nil
+}
+
+func TestNewContextLoader(t *testing.T) {
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "burger")
+
+	loader := &contextURLLoader{}
+	_, err := NewContextLoader(ctx, loader).Load("https://things.com/burger.json")
+	require.NoError(t, err)
+	require.Equal(t, "burger", loader.ctx.Value(key{}))
+}
+
+func TestNewCompiledSchemaContext_ContextURLLoader(t *testing.T) {
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "burger")
+
+	loader := &contextURLLoader{}
+	o := config.NewValidationOptions(config.WithSchemaLoader(loader))
+	jsch, err := NewCompiledSchemaContext(ctx, "burger",
+		[]byte(`{"$ref": "https://things.com/burger.json"}`), o)
+	require.NoError(t, err)
+	require.NotNil(t, jsch)
+	require.Equal(t, "burger", loader.ctx.Value(key{}))
+}
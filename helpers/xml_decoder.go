@@ -0,0 +1,303 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package helpers
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	"github.com/pb33f/libopenapi/orderedmap"
+	"golang.org/x/text/transform"
+)
+
+// XMLPaths maps the JSON pointer of each value decoded from an XML document, to the path of the element, or
+// attribute, that the value was read from. For example '/tags/1' may map to '/pet/tags/tag[2]'.
+type XMLPaths map[string]string
+
+// Find returns the path of the XML element for a JSON pointer (the instance location of a schema violation).
+// When the pointer has no element of its own, the path of the closest parent is returned.
+func (p XMLPaths) Find(pointer string) string {
+	for {
+		if path, ok := p[pointer]; ok {
+			return path
+		}
+		i := strings.LastIndex(pointer, Slash)
+		if i < 0 {
+			return ""
+		}
+		pointer = pointer[:i]
+	}
+}
+
+// DecodeXML decodes an XML document into the same model that a JSON document is decoded into, so it can be
+// validated against a JSON schema. The 'xml' object of each schema is used to locate values:
+//
+//	name      - the name of the element or attribute, which defaults to the property name
+//	namespace - the namespace the element must be in
+//	attribute - the property is an attribute of the parent element, rather than a child element
+//	wrapped   - the items of an array are wrapped by an element, rather than being repeated in the parent
+//
+// Values are converted to the types defined by the schema. Elements that are not described by the schema are
+// kept, so 'additionalProperties' can be checked. The path of every element that was decoded is returned, so
+// schema violations can point to the element that caused them. The body must be UTF-8, see DecodeCharset, and
+// the encoding declaration of the document is ignored.
+func DecodeXML(body []byte, schema *base.Schema) (any, XMLPaths, error) {
+	return decodeXML(body, schema, false)
+}
+
+// DecodeXMLDocument decodes an XML document in the same way as DecodeXML, for a body that has no charset in its
+// content type. The body is transcoded to UTF-8 from the encoding declaration of the document, and a document
+// without one must be UTF-8.
+func DecodeXMLDocument(body []byte, schema *base.Schema) (any, XMLPaths, error) {
+	return decodeXML(body, schema, true)
+}
+
+func decodeXML(body []byte, schema *base.Schema, transcode bool) (any, XMLPaths, error) {
+	root, err := parseXML(body, transcode)
+	if err != nil {
+		return nil, nil, err
+	}
+	if schema != nil && schema.XML != nil {
+		if schema.XML.Name != "" && root.name.Local != schema.XML.Name {
+			return nil, nil, fmt.Errorf("the root element is '%s', however '%s' is required",
+				root.name.Local, schema.XML.Name)
+		}
+		if schema.XML.Namespace != "" && root.name.Space != schema.XML.Namespace {
+			return nil, nil, fmt.Errorf("the root element '%s' is in the namespace '%s', however '%s' is required",
+				root.name.Local, root.name.Space, schema.XML.Namespace)
+		}
+	}
+	paths := make(XMLPaths)
+	return decodeXMLElement(root, schema, "", Slash+root.name.Local, paths), paths, nil
+}
+
+// xmlElement is an element of a parsed XML document.
+type xmlElement struct {
+	name     xml.Name
+	attrs    []xml.Attr
+	children []*xmlElement
+	text     strings.Builder
+}
+
+// parseXML parses an XML document. The document is transcoded from its encoding declaration when transcode is
+// true, otherwise it has already been transcoded to UTF-8, and the declaration only needs to be a known charset.
+func parseXML(body []byte, transcode bool) (*xmlElement, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		enc, err := lookupCharset(charset)
+		if err != nil {
+			return nil, err
+		}
+		if transcode && enc != nil {
+			return transform.NewReader(input, enc.NewDecoder()), nil
+		}
+		return input, nil
+	}
+	var root *xmlElement
+	var stack []*xmlElement
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			el := &xmlElement{name: t.Name, attrs: t.Attr}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, el)
+			} else if root != nil {
+				return nil, fmt.Errorf("the document has more than one root element")
+			} else {
+				root = el
+			}
+			stack = append(stack, el)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(t)
+			}
+		}
+	}
+	if root == nil {
+		return nil, fmt.Errorf("the document has no root element")
+	}
+	return root, nil
+}
+
+func decodeXMLElement(el *xmlElement, schema *base.Schema, pointer, path string, paths XMLPaths) any {
+	paths[pointer] = path
+	switch {
+	case schema != nil && slices.Contains(schema.Type, Array):
+		items := xmlItemsSchema(schema)
+		var children []*xmlElement
+		for _, child := range el.children {
+			if items == nil || items.XML == nil || items.XML.Name == "" || xmlNameMatches(child.name, items.XML.Name, items) {
+				children = append(children, child)
+			}
+		}
+		decoded := make([]any, len(children))
+		counts := make(map[string]int)
+		for i, child := range children {
+			counts[child.name.Local]++
+			decoded[i] = decodeXMLElement(child, items, pointer+Slash+strconv.Itoa(i),
+				fmt.Sprintf("%s/%s[%d]", path, child.name.Local, counts[child.name.Local]), paths)
+		}
+		return decoded
+
+	case schema != nil && (slices.Contains(schema.Type, Object) || schema.Properties != nil):
+		return decodeXMLObject(el, schema, pointer, path, paths)
+
+	case schema == nil && len(el.children) > 0:
+		// nothing describes the element, so its children become properties.
+		return decodeXMLObject(el, nil, pointer, path, paths)
+	}
+
+	if schema != nil && slices.Contains(schema.Type, String) {
+		return el.text.String()
+	}
+	return CastToSchemaType(strings.TrimSpace(el.text.String()), schema)
+}
+
+func decodeXMLObject(el *xmlElement, schema *base.Schema, pointer, path string, paths XMLPaths) map[string]any {
+	decoded := make(map[string]any)
+	consumed := make(map[*xmlElement]bool)
+
+	var properties *orderedmap.Map[string, *base.SchemaProxy]
+	if schema != nil {
+		properties = schema.Properties
+	}
+	for pair := orderedmap.First(properties); pair != nil; pair = pair.Next() {
+		name := pair.Key()
+		var prop *base.Schema
+		if pair.Value() != nil {
+			prop = pair.Value().Schema()
+		}
+		elementName := xmlName(prop, name)
+		propPointer := pointer + Slash + escapePointer(name)
+
+		switch {
+		case prop != nil && prop.XML != nil && prop.XML.Attribute:
+			for _, attr := range el.attrs {
+				if xmlNameMatches(attr.Name, elementName, prop) {
+					decoded[name] = CastToSchemaType(attr.Value, prop)
+					paths[propPointer] = path + "/@" + elementName
+					break
+				}
+			}
+
+		case prop != nil && slices.Contains(prop.Type, Array):
+			items := xmlItemsSchema(prop)
+			itemName := xmlName(items, elementName)
+			children, itemsPath := el.children, path
+			if prop.XML != nil && prop.XML.Wrapped {
+				wrapper := findXMLChild(el, elementName, prop, consumed)
+				if wrapper == nil {
+					continue
+				}
+				consumed[wrapper] = true
+				children, itemsPath = wrapper.children, path+Slash+elementName
+			}
+			var values []any
+			for _, child := range children {
+				if consumed[child] || !xmlNameMatches(child.name, itemName, items) {
+					continue
+				}
+				consumed[child] = true
+				values = append(values, decodeXMLElement(child, items, propPointer+Slash+strconv.Itoa(len(values)),
+					fmt.Sprintf("%s/%s[%d]", itemsPath, itemName, len(values)+1), paths))
+			}
+			if values == nil && itemsPath == path {
+				// there are no items and no wrapper, so the property is missing.
+				continue
+			}
+			if values == nil {
+				values = []any{}
+			}
+			decoded[name] = values
+			paths[propPointer] = itemsPath
+
+		default:
+			child := findXMLChild(el, elementName, prop, consumed)
+			if child == nil {
+				continue
+			}
+			consumed[child] = true
+			decoded[name] = decodeXMLElement(child, prop, propPointer, path+Slash+elementName, paths)
+		}
+	}
+
+	// elements that are not described by the schema are kept, so 'additionalProperties' can be checked.
+	counts := make(map[string]int)
+	for _, child := range el.children {
+		if consumed[child] {
+			continue
+		}
+		key := child.name.Local
+		counts[key]++
+		childPointer := pointer + Slash + escapePointer(key)
+		childPath := fmt.Sprintf("%s/%s[%d]", path, key, counts[key])
+		switch existing := decoded[key].(type) {
+		case nil:
+			decoded[key] = decodeXMLElement(child, nil, childPointer, childPath, paths)
+		case []any:
+			decoded[key] = append(existing, decodeXMLElement(child, nil,
+				childPointer+Slash+strconv.Itoa(len(existing)), childPath, paths))
+		default:
+			// a repeated element becomes a list.
+			paths[childPointer+"/0"] = paths[childPointer]
+			decoded[key] = []any{existing, decodeXMLElement(child, nil, childPointer+"/1", childPath, paths)}
+			paths[childPointer] = path + Slash + key
+		}
+	}
+	return decoded
+}
+
+// findXMLChild returns the first child element with the name, that has not already been decoded.
+func findXMLChild(el *xmlElement, name string, schema *base.Schema, consumed map[*xmlElement]bool) *xmlElement {
+	for _, child := range el.children {
+		if !consumed[child] && xmlNameMatches(child.name, name, schema) {
+			return child
+		}
+	}
+	return nil
+}
+
+// xmlNameMatches checks the local name of an element or attribute, and the namespace, if the schema defines one.
+func xmlNameMatches(n xml.Name, name string, schema *base.Schema) bool {
+	if n.Local != name {
+		return false
+	}
+	return schema == nil || schema.XML == nil || schema.XML.Namespace == "" || n.Space == schema.XML.Namespace
+}
+
+// xmlName returns the name defined by the 'xml' object of the schema, or the fallback.
+func xmlName(schema *base.Schema, fallback string) string {
+	if schema != nil && schema.XML != nil && schema.XML.Name != "" {
+		return schema.XML.Name
+	}
+	return fallback
+}
+
+func xmlItemsSchema(schema *base.Schema) *base.Schema {
+	if schema.Items != nil && schema.Items.IsA() {
+		return schema.Items.A.Schema()
+	}
+	return nil
+}
+
+// escapePointer escapes a property name, so it can be used as a segment of a JSON pointer.
+func escapePointer(name string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(name, "~", "~0"), Slash, "~1")
+}
@@ -0,0 +1,193 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package helpers
+
+import (
+	"testing"
+
+	"github.com/pb33f/libopenapi"
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func xmlTestSchema(t *testing.T, name string) *base.Schema {
+	spec := `openapi: 3.1.0
+components:
+  schemas:
+    Burger:
+      type: object
+      xml:
+        name: burger
+        namespace: https://pb33f.io/burgers
+      properties:
+        id:
+          type: integer
+          xml:
+            attribute: true
+        name:
+          type: string
+        patties:
+          type: integer
+          xml:
+            name: pattyCount
+        vegetarian:
+          type: boolean
+        toppings:
+          type: array
+          xml:
+            wrapped: true
+          items:
+            type: string
+            xml:
+              name: topping
+        sauces:
+          type: array
+          items:
+            type: string
+            xml:
+              name: sauce
+        drink:
+          type: object
+          properties:
+            size:
+              type: string
+              xml:
+                attribute: true
+    Burgers:
+      type: array
+      items:
+        type: object
+        xml:
+          name: burger
+        properties:
+          name:
+            type: string`
+	doc, err := libopenapi.NewDocument([]byte(spec))
+	require.NoError(t, err)
+	m, errs := doc.BuildV3Model()
+	require.Empty(t, errs)
+	return m.Model.Components.Schemas.GetOrZero(name).Schema()
+}
+
+func TestDecodeXML(t *testing.T) {
+	schema := xmlTestSchema(t, "Burger")
+
+	body := `<?xml version="1.0"?>
+<b:burger xmlns:b="https://pb33f.io/burgers" id="42">
+  <b:name> Big Mac </b:name>
+  <b:pattyCount>2</b:pattyCount>
+  <b:vegetarian>false</b:vegetarian>
+  <b:toppings><b:topping>cheese</b:topping><b:topping>pickles</b:topping></b:toppings>
+  <b:sauce>ketchup</b:sauce>
+  <b:sauce>mustard</b:sauce>
+  <b:drink size="large"/>
+  <b:side>fries</b:side>
+</b:burger>`
+
+	decoded, paths, err := DecodeXML([]byte(body), schema)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"id":         float64(42),
+		"name":       " Big Mac ",
+		"patties":    float64(2),
+		"vegetarian": false,
+		"toppings":   []any{"cheese", "pickles"},
+		"sauces":     []any{"ketchup", "mustard"},
+		"drink":      map[string]any{"size": "large"},
+		"side":       "fries",
+	}, decoded)
+
+	assert.Equal(t, "/burger", paths[""])
+	assert.Equal(t, "/burger/@id", paths["/id"])
+	assert.Equal(t, "/burger/pattyCount", paths["/patties"])
+	assert.Equal(t, "/burger/toppings/topping[2]", paths["/toppings/1"])
+	assert.Equal(t, "/burger/sauce[1]", paths["/sauces/0"])
+	assert.Equal(t, "/burger/drink/@size", paths["/drink/size"])
+	assert.Equal(t, "/burger/side[1]", paths["/side"])
+}
+
+func TestDecodeXML_Arrays(t *testing.T) {
+	schema := xmlTestSchema(t, "Burgers")
+
+	decoded, paths, err := DecodeXML([]byte(`<burgers><burger><name>one</name></burger>`+
+		`<drink/><burger><name>two</name></burger></burgers>`), schema)
+	require.NoError(t, err)
+	assert.Equal(t, []any{
+		map[string]any{"name": "one"},
+		map[string]any{"name": "two"},
+	}, decoded)
+	assert.Equal(t, "/burgers/burger[2]/name", paths["/1/name"])
+
+	// an empty wrapper is an empty list, a missing wrapper is a missing property.
+	schema = xmlTestSchema(t, "Burger")
+	decoded, _, err = DecodeXML([]byte(`<burger xmlns="https://pb33f.io/burgers"><toppings/></burger>`), schema)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"toppings": []any{}}, decoded)
+
+	// repeated elements that are not described by the schema become a list.
+	decoded, paths, err = DecodeXML([]byte(`<burger xmlns="https://pb33f.io/burgers"><side>fries</side>`+
+		`<side>salad</side></burger>`), schema)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"side": []any{"fries", "salad"}}, decoded)
+	assert.Equal(t, "/burger/side[2]", paths["/side/1"])
+}
+
+func TestDecodeXML_Errors(t *testing.T) {
+	schema := xmlTestSchema(t, "Burger")
+
+	_, _, err := DecodeXML([]byte(`<pizza xmlns="https://pb33f.io/burgers"/>`), schema)
+	assert.EqualError(t, err, "the root element is 'pizza', however 'burger' is required")
+
+	_, _, err = DecodeXML([]byte(`<burger xmlns="https://pb33f.io/pizza"/>`), schema)
+	assert.EqualError(t, err, "the root element 'burger' is in the namespace 'https://pb33f.io/pizza', "+
+		"however 'https://pb33f.io/burgers' is required")
+
+	_, _, err = DecodeXML([]byte(`<burger>`), schema)
+	assert.EqualError(t, err, "XML syntax error on line 1: unexpected EOF")
+
+	_, _, err = DecodeXML([]byte(`<burger/><burger/>`), nil)
+	assert.EqualError(t, err, "the document has more than one root element")
+
+	_, _, err = DecodeXML([]byte(`<?xml version="1.0"?>`), nil)
+	assert.EqualError(t, err, "the document has no root element")
+}
+
+func TestDecodeXML_EncodingDeclaration(t *testing.T) {
+	// the body has already been transcoded, so a known encoding is accepted as is.
+	decoded, _, err := DecodeXML([]byte(`<?xml version="1.0" encoding="ISO-8859-1"?><burger><name>Café</name></burger>`),
+		nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"name": "Café"}, decoded)
+
+	_, _, err = DecodeXML([]byte(`<?xml version="1.0" encoding="x-burger"?><burger/>`), nil)
+	assert.ErrorContains(t, err, "the charset 'x-burger' is not supported")
+}
+
+func TestDecodeXMLDocument(t *testing.T) {
+	// the body is transcoded from the encoding declaration of the document.
+	decoded, _, err := DecodeXMLDocument([]byte("<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?>"+
+		"<burger><name>Caf\xe9</name></burger>"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"name": "Café"}, decoded)
+
+	decoded, _, err = DecodeXMLDocument([]byte(`<burger><name>Café</name></burger>`), nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"name": "Café"}, decoded)
+
+	_, _, err = DecodeXMLDocument([]byte("<burger><name>Caf\xe9</name></burger>"), nil)
+	assert.ErrorContains(t, err, "invalid UTF-8")
+
+	_, _, err = DecodeXMLDocument([]byte(`<?xml version="1.0" encoding="x-burger"?><burger/>`), nil)
+	assert.ErrorContains(t, err, "the charset 'x-burger' is not supported")
+}
+
+func TestXMLPaths_Find(t *testing.T) {
+	paths := XMLPaths{"": "/burger", "/toppings": "/burger/toppings"}
+	assert.Equal(t, "/burger/toppings", paths.Find("/toppings"))
+	assert.Equal(t, "/burger/toppings", paths.Find("/toppings/3"))
+	assert.Equal(t, "/burger", paths.Find("/name"))
+	assert.Equal(t, "/burger", paths.Find(""))
+	assert.Equal(t, "", XMLPaths{}.Find("/name"))
+}
@@ -4,6 +4,7 @@
 package parameters
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -13,20 +14,49 @@ import (
 
 	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
 
+	"github.com/pb33f/libopenapi-validator/config"
 	"github.com/pb33f/libopenapi-validator/errors"
 	"github.com/pb33f/libopenapi-validator/helpers"
 	"github.com/pb33f/libopenapi-validator/paths"
 )
 
 func (v *paramValidator) ValidateCookieParams(request *http.Request) (bool, []*errors.ValidationError) {
-	pathItem, errs, foundPath := paths.FindPath(request, v.document)
-	if len(errs) > 0 {
-		return false, errs
-	}
-	return v.ValidateCookieParamsWithPathItem(request, pathItem, foundPath)
+	return v.ValidateCookieParamsCtx(context.Background(), request)
+}
+
+func (v *paramValidator) ValidateCookieParamsCtx(ctx context.Context, request *http.Request) (bool, []*errors.ValidationError) {
+	return errors.ValidateWithContext(ctx, request, func() (bool, []*errors.ValidationError) {
+		pathItem, errs, foundPath := paths.FindPath(request, v.document, config.WithExistingOpts(v.options))
+		if len(errs) > 0 {
+			return false, errs
+		}
+		return v.validateCookieParams(ctx, request, pathItem, foundPath)
+	})
 }
 
 func (v *paramValidator) ValidateCookieParamsWithPathItem(request *http.Request, pathItem *v3.PathItem, pathValue string) (bool, []*errors.ValidationError) {
+	return v.ValidateCookieParamsWithPathItemCtx(context.Background(), request, pathItem, pathValue)
+}
+
+func (v *paramValidator) ValidateCookieParamsWithPathItemCtx(
+	ctx context.Context,
+	request *http.Request,
+	pathItem *v3.PathItem,
+	pathValue string,
+) (bool, []*errors.ValidationError) {
+	return errors.ValidateWithContext(ctx, request, func() (bool, []*errors.ValidationError) {
+		return v.validateCookieParams(ctx, request, pathItem, pathValue)
+	})
+}
+
+// validateCookieParams validates the cookie parameters of the operation of the path item. The context is checked by the
+// caller, it is passed down to the schema loaders.
+func (v *paramValidator) validateCookieParams(
+	ctx context.Context,
+	request *http.Request,
+	pathItem *v3.PathItem,
+	pathValue string,
+) (bool, []*errors.ValidationError) {
 	if pathItem == nil {
 		return false, []*errors.ValidationError{{
 			ValidationType:    helpers.ParameterValidationPath,
@@ -88,7 +118,7 @@ func (v *paramValidator) ValidateCookieParamsWithPathItem(request *http.Request,
 								// if a schema was extracted
 								if sch != nil {
 									validationErrors = append(validationErrors,
-										ValidateParameterSchema(sch, encodedObj, "",
+										validateParameterValue(sch, v.compiledSchema(ctx, sch, p.Name, true), encodedObj, "",
 											"Cookie parameter",
 											"The cookie parameter",
 											p.Name,
@@ -4,6 +4,7 @@
 package parameters
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -13,20 +14,49 @@ import (
 
 	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
 
+	"github.com/pb33f/libopenapi-validator/config"
 	"github.com/pb33f/libopenapi-validator/errors"
 	"github.com/pb33f/libopenapi-validator/helpers"
 	"github.com/pb33f/libopenapi-validator/paths"
 )
 
 func (v *paramValidator) ValidateHeaderParams(request *http.Request) (bool, []*errors.ValidationError) {
-	pathItem, errs, foundPath := paths.FindPath(request, v.document)
-	if len(errs) > 0 {
-		return false, errs
-	}
-	return v.ValidateHeaderParamsWithPathItem(request, pathItem, foundPath)
+	return v.ValidateHeaderParamsCtx(context.Background(), request)
+}
+
+func (v *paramValidator) ValidateHeaderParamsCtx(ctx context.Context, request *http.Request) (bool, []*errors.ValidationError) {
+	return errors.ValidateWithContext(ctx, request, func() (bool, []*errors.ValidationError) {
+		pathItem, errs, foundPath := paths.FindPath(request, v.document, config.WithExistingOpts(v.options))
+		if len(errs) > 0 {
+			return false, errs
+		}
+		return v.validateHeaderParams(ctx, request, pathItem, foundPath)
+	})
 }
 
 func (v *paramValidator) ValidateHeaderParamsWithPathItem(request *http.Request, pathItem *v3.PathItem, pathValue string) (bool, []*errors.ValidationError) {
+	return v.ValidateHeaderParamsWithPathItemCtx(context.Background(), request, pathItem, pathValue)
+}
+
+func (v *paramValidator) ValidateHeaderParamsWithPathItemCtx(
+	ctx context.Context,
+	request *http.Request,
+	pathItem *v3.PathItem,
+	pathValue string,
+) (bool, []*errors.ValidationError) {
+	return errors.ValidateWithContext(ctx, request, func() (bool, []*errors.ValidationError) {
+		return v.validateHeaderParams(ctx, request, pathItem, pathValue)
+	})
+}
+
+// validateHeaderParams validates the header parameters of the operation of the path item. The context is checked by the
+// caller, it is passed down to the schema loaders.
+func (v *paramValidator) validateHeaderParams(
+	ctx context.Context,
+	request *http.Request,
+	pathItem *v3.PathItem,
+	pathValue string,
+) (bool, []*errors.ValidationError) {
 	if pathItem == nil {
 		return false, []*errors.ValidationError{{
 			ValidationType:    helpers.ParameterValidationPath,
@@ -92,10 +122,10 @@ func (v *paramValidator) ValidateHeaderParamsWithPathItem(request *http.Request,
 						var encodedObj map[string]interface{}
 						// we have found our header, check the explode type.
 						if p.IsDefaultHeaderEncoding() {
-							encodedObj = helpers.ConstructMapFromCSV(param)
+							encodedObj = helpers.DecodeHeaderObject(param, false)
 						} else {
 							if p.IsExploded() { // only option is to be exploded for KV extraction.
-								encodedObj = helpers.ConstructKVFromCSV(param)
+								encodedObj = helpers.DecodeHeaderObject(param, true)
 							}
 						}
 
@@ -108,7 +138,7 @@ func (v *paramValidator) ValidateHeaderParamsWithPathItem(request *http.Request,
 						// if a schema was extracted
 						if sch != nil {
 							validationErrors = append(validationErrors,
-								ValidateParameterSchema(sch,
+								validateParameterValue(sch, v.compiledSchema(ctx, sch, p.Name, true),
 									encodedObj,
 									"",
 									"Header parameter",
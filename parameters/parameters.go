@@ -4,11 +4,20 @@
 package parameters
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"slices"
+
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	"github.com/santhosh-tekuri/jsonschema/v6"
 
 	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
 
+	"github.com/pb33f/libopenapi-validator/config"
 	"github.com/pb33f/libopenapi-validator/errors"
+	"github.com/pb33f/libopenapi-validator/helpers"
+	"github.com/pb33f/libopenapi-validator/radix"
 )
 
 // ParameterValidator is an interface that defines the methods for validating parameters
@@ -63,13 +72,189 @@ type ParameterValidator interface {
 	// ValidateSecurityWithPathItem validates the security requirements for the operation. It returns a boolean stating true
 	// if validation passed (false for failed), and a slice of errors if validation failed.
 	ValidateSecurityWithPathItem(request *http.Request, pathItem *v3.PathItem, pathValue string) (bool, []*errors.ValidationError)
+
+	// ValidateSecurityResult validates the security requirements for the operation, and returns a SecurityResult
+	// that includes the requirement that was satisfied, and the principals resolved by any registered authenticators.
+	ValidateSecurityResult(request *http.Request) *SecurityResult
+
+	// ValidateSecurityResultWithPathItem validates the security requirements for the operation, and returns a SecurityResult
+	// that includes the requirement that was satisfied, and the principals resolved by any registered authenticators.
+	ValidateSecurityResultWithPathItem(request *http.Request, pathItem *v3.PathItem, pathValue string) *SecurityResult
+
+	// ValidateQueryParamsCtx validates the query parameters in the same way as ValidateQueryParams. Validation stops
+	// early once the context is done, and a single context error is returned. The context is supplied to schema
+	// loaders.
+	ValidateQueryParamsCtx(ctx context.Context, request *http.Request) (bool, []*errors.ValidationError)
+
+	// ValidateQueryParamsWithPathItemCtx validates the query parameters in the same way as
+	// ValidateQueryParamsWithPathItem, using the context in the same way as ValidateQueryParamsCtx.
+	ValidateQueryParamsWithPathItemCtx(ctx context.Context, request *http.Request, pathItem *v3.PathItem, pathValue string) (bool, []*errors.ValidationError)
+
+	// ValidateHeaderParamsCtx validates the header parameters in the same way as ValidateHeaderParams, using the
+	// context in the same way as ValidateQueryParamsCtx.
+	ValidateHeaderParamsCtx(ctx context.Context, request *http.Request) (bool, []*errors.ValidationError)
+
+	// ValidateHeaderParamsWithPathItemCtx validates the header parameters in the same way as
+	// ValidateHeaderParamsWithPathItem, using the context in the same way as ValidateQueryParamsCtx.
+	ValidateHeaderParamsWithPathItemCtx(ctx context.Context, request *http.Request, pathItem *v3.PathItem, pathValue string) (bool, []*errors.ValidationError)
+
+	// ValidateCookieParamsCtx validates the cookie parameters in the same way as ValidateCookieParams, using the
+	// context in the same way as ValidateQueryParamsCtx.
+	ValidateCookieParamsCtx(ctx context.Context, request *http.Request) (bool, []*errors.ValidationError)
+
+	// ValidateCookieParamsWithPathItemCtx validates the cookie parameters in the same way as
+	// ValidateCookieParamsWithPathItem, using the context in the same way as ValidateQueryParamsCtx.
+	ValidateCookieParamsWithPathItemCtx(ctx context.Context, request *http.Request, pathItem *v3.PathItem, pathValue string) (bool, []*errors.ValidationError)
+
+	// ValidatePathParamsCtx validates the path parameters in the same way as ValidatePathParams, using the context
+	// in the same way as ValidateQueryParamsCtx.
+	ValidatePathParamsCtx(ctx context.Context, request *http.Request) (bool, []*errors.ValidationError)
+
+	// ValidatePathParamsWithPathItemCtx validates the path parameters in the same way as
+	// ValidatePathParamsWithPathItem, using the context in the same way as ValidateQueryParamsCtx.
+	ValidatePathParamsWithPathItemCtx(ctx context.Context, request *http.Request, pathItem *v3.PathItem, pathValue string) (bool, []*errors.ValidationError)
+
+	// ValidateSecurityCtx validates the security requirements in the same way as ValidateSecurity. Validation stops
+	// early once the context is done, and a single context error is returned. The context is supplied to
+	// authenticators as config.AuthenticationInput.Context.
+	ValidateSecurityCtx(ctx context.Context, request *http.Request) (bool, []*errors.ValidationError)
+
+	// ValidateSecurityWithPathItemCtx validates the security requirements in the same way as
+	// ValidateSecurityWithPathItem, using the context in the same way as ValidateSecurityCtx.
+	ValidateSecurityWithPathItemCtx(ctx context.Context, request *http.Request, pathItem *v3.PathItem, pathValue string) (bool, []*errors.ValidationError)
+
+	// ValidateSecurityResultCtx validates the security requirements in the same way as ValidateSecurityResult, using
+	// the context in the same way as ValidateSecurityCtx. The result of a validation stopped by its context is not
+	// valid, and holds a single context error.
+	ValidateSecurityResultCtx(ctx context.Context, request *http.Request) *SecurityResult
+
+	// ValidateSecurityResultWithPathItemCtx validates the security requirements in the same way as
+	// ValidateSecurityResultWithPathItem, using the context in the same way as ValidateSecurityResultCtx.
+	ValidateSecurityResultWithPathItemCtx(ctx context.Context, request *http.Request, pathItem *v3.PathItem, pathValue string) *SecurityResult
 }
 
-// NewParameterValidator will create a new ParameterValidator from an OpenAPI 3+ document
-func NewParameterValidator(document *v3.Document) ParameterValidator {
-	return &paramValidator{document: document}
+// NewParameterValidator will create a new ParameterValidator from an OpenAPI 3+ document. Options can be
+// supplied to change the default behavior of the validator.
+func NewParameterValidator(document *v3.Document, opts ...config.Option) ParameterValidator {
+	options := config.NewValidationOptions(opts...)
+	if options.PathTree == nil {
+		options.PathTree = radix.NewPathTree(document)
+	}
+	if options.SchemaCache == nil {
+		options.SchemaCache = config.NewLRUSchemaCache(config.DefaultSchemaCacheSize)
+	}
+	return &paramValidator{document: document, options: options}
 }
 
 type paramValidator struct {
-	document *v3.Document
+	document   *v3.Document
+	options    *config.ValidationOptions
+	schemaKeys helpers.SchemaKeys
+}
+
+// schemaCacheKey identifies a compiled parameter schema, see helpers.CompiledSchemaKey. The name of the parameter
+// is part of the key, as it names the resource the schema is compiled from.
+type schemaCacheKey struct {
+	schema helpers.CompiledSchemaKey
+	name   string
+	inline bool
+}
+
+// renderedSchemaID identifies the rendered form of a parameter schema, for which the validator remembers the key.
+type renderedSchemaID struct {
+	schema *base.Schema
+	inline bool
+}
+
+// compiledParameterSchema is the result of compiling a parameter schema, a schema that failed to compile is cached
+// along with the error.
+type compiledParameterSchema struct {
+	schema *jsonschema.Schema
+	err    error
+}
+
+// compiledSchema returns the compiled form of a parameter schema, or nil if it cannot be compiled.
+func (v *paramValidator) compiledSchema(ctx context.Context, schema *base.Schema, name string, inline bool) *jsonschema.Schema {
+	jsch, _ := v.compileSchema(ctx, schema, name, inline)
+	return jsch
+}
+
+// compileSchema returns the compiled form of a parameter schema. The work of compiling is only performed once per
+// rendered schema, the result (including a schema that failed to compile) is held in the schema cache of the
+// validator. The schema is only rendered again when it is not found in the cache. The compiled schema is shared, so
+// the compilation is not stopped when the context is cancelled.
+func (v *paramValidator) compileSchema(
+	ctx context.Context,
+	schema *base.Schema,
+	name string,
+	inline bool,
+) (*jsonschema.Schema, error) {
+	ctx = context.WithoutCancel(ctx)
+	var jsonSchema []byte
+	compiledKey := v.schemaKeys.Key(renderedSchemaID{schema: schema, inline: inline}, func() []byte {
+		jsonSchema = renderParameterSchema(schema, inline)
+		return jsonSchema
+	}, v.options)
+	key := schemaCacheKey{schema: compiledKey, name: name, inline: inline}
+	if cacheHit, ok := v.options.SchemaCache.Load(key); ok {
+		compiled := cacheHit.(*compiledParameterSchema)
+		return compiled.schema, compiled.err
+	}
+	if jsonSchema == nil {
+		jsonSchema = renderParameterSchema(schema, inline)
+	}
+	jsch, err := compileSchema(ctx, name, jsonSchema, v.options)
+	v.options.SchemaCache.Store(key, &compiledParameterSchema{schema: jsch, err: err})
+	return jsch, err
+}
+
+// CompileSchemas compiles the schema of every parameter of every operation in the document, in the form the schema
+// is validated in, so the compiled schemas are cached before the first request is validated. A schema that cannot
+// be compiled is returned as a *helpers.SchemaCompileError. It is used by the validator to warm up eagerly, see
+// config.WithEagerSchemaCompilation.
+func (v *paramValidator) CompileSchemas() []error {
+	var jobs []func() error
+	for _, op := range helpers.ExtractDocumentOperations(v.document) {
+		params := append(slices.Clone(op.PathItem.Parameters), op.Operation.Parameters...)
+		for _, param := range params {
+			if param == nil || param.Schema == nil {
+				continue
+			}
+			sch := param.Schema.Schema()
+			if sch == nil {
+				continue
+			}
+			compile := func(schema *base.Schema, inline bool) {
+				jobs = append(jobs, func() error {
+					if _, err := v.compileSchema(context.Background(), schema, param.Name, inline); err != nil {
+						return &helpers.SchemaCompileError{
+							Path:     op.Path,
+							Method:   op.Method,
+							Location: fmt.Sprintf("%s parameter '%s'", param.In, param.Name),
+							Err:      err,
+						}
+					}
+					return nil
+				})
+			}
+
+			// objects are validated with every reference rendered inline, arrays of objects by item, and the single
+			// values of query and path parameters as they are rendered.
+			isValue := param.In == helpers.Query || param.In == helpers.Path
+			switch {
+			case slices.Contains(sch.Type, helpers.Object):
+				compile(sch, true)
+			case slices.Contains(sch.Type, helpers.Array):
+				if param.In == helpers.Query && sch.Items != nil && sch.Items.IsA() {
+					if items := sch.Items.A.Schema(); items != nil && slices.Contains(items.Type, helpers.Object) {
+						compile(items, true)
+					}
+				}
+			case isValue && (slices.Contains(sch.Type, helpers.String) || slices.Contains(sch.Type, helpers.Integer) ||
+				slices.Contains(sch.Type, helpers.Number)):
+				compile(sch, false)
+			}
+		}
+	}
+	return helpers.RunParallel(jobs)
 }
@@ -0,0 +1,145 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package parameters
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/pb33f/libopenapi"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+
+	"github.com/pb33f/libopenapi-validator/config"
+	"github.com/pb33f/libopenapi-validator/helpers"
+)
+
+const schemaCacheSpec = `openapi: 3.1.0
+paths:
+  /burgers/{burgerId}:
+    get:
+      parameters:
+        - name: burgerId
+          in: path
+          required: true
+          schema:
+            type: integer
+            minimum: 1
+        - name: fresh
+          in: query
+          schema:
+            type: boolean
+        - name: limit
+          in: query
+          schema:
+            type: integer
+            maximum: 10`
+
+func schemaCacheValidator(t testing.TB) (*paramValidator, *v3.Document) {
+	doc, err := libopenapi.NewDocument([]byte(schemaCacheSpec))
+	require.NoError(t, err)
+	m, errs := doc.BuildV3Model()
+	require.Empty(t, errs)
+	// an unbounded cache that can be inspected.
+	return NewParameterValidator(&m.Model, config.WithSchemaCache(&sync.Map{})).(*paramValidator), &m.Model
+}
+
+func cachedSchemas(v *paramValidator) map[schemaCacheKey]*jsonschema.Schema {
+	cached := make(map[schemaCacheKey]*jsonschema.Schema)
+	v.options.SchemaCache.(*sync.Map).Range(func(key, value any) bool {
+		cached[key.(schemaCacheKey)] = value.(*compiledParameterSchema).schema
+		return true
+	})
+	return cached
+}
+
+func TestParamValidator_SchemaCache(t *testing.T) {
+	v, _ := schemaCacheValidator(t)
+
+	request, _ := http.NewRequest(http.MethodGet, "https://things.com/burgers/3?fresh=true&limit=5", nil)
+	valid, errs := v.ValidateQueryParams(request)
+	assert.True(t, valid)
+	assert.Empty(t, errs)
+	valid, errs = v.ValidatePathParams(request)
+	assert.True(t, valid)
+	assert.Empty(t, errs)
+
+	first := cachedSchemas(v)
+	assert.Len(t, first, 2)
+
+	// the compiled schemas are reused, and still validate.
+	request, _ = http.NewRequest(http.MethodGet, "https://things.com/burgers/0?fresh=true&limit=50", nil)
+	valid, errs = v.ValidateQueryParams(request)
+	assert.False(t, valid)
+	assert.Len(t, errs, 1)
+	valid, errs = v.ValidatePathParams(request)
+	assert.False(t, valid)
+	assert.Len(t, errs, 1)
+
+	second := cachedSchemas(v)
+	require.Len(t, second, 2)
+	for key, jsch := range first {
+		assert.Same(t, jsch, second[key])
+	}
+}
+
+func TestParamValidator_SchemaCache_CompileFailure(t *testing.T) {
+	v, doc := schemaCacheValidator(t)
+
+	// a schema that cannot be compiled is cached as nil, so it is not compiled again.
+	sch := doc.Paths.PathItems.GetOrZero("/burgers/{burgerId}").Get.Parameters[2].Schema.Schema()
+	sch.Pattern = "["
+	assert.Nil(t, v.compiledSchema(context.Background(), sch, "limit", false))
+	assert.Len(t, cachedSchemas(v), 1)
+}
+
+func TestParamValidator_CompileSchemas(t *testing.T) {
+	v, _ := schemaCacheValidator(t)
+
+	// the schemas are compiled up front, in the form they are validated in.
+	assert.Empty(t, v.CompileSchemas())
+	compiled := cachedSchemas(v)
+	require.Len(t, compiled, 2)
+	for key, jsch := range compiled {
+		assert.NotNil(t, jsch)
+		assert.False(t, key.inline)
+	}
+
+	// validation uses the compiled schemas.
+	request, _ := http.NewRequest(http.MethodGet, "https://things.com/burgers/3?fresh=true&limit=5", nil)
+	valid, errs := v.ValidateQueryParams(request)
+	assert.True(t, valid)
+	assert.Empty(t, errs)
+	assert.Len(t, cachedSchemas(v), 2)
+}
+
+func TestParamValidator_CompileSchemas_Failure(t *testing.T) {
+	v, doc := schemaCacheValidator(t)
+	sch := doc.Paths.PathItems.GetOrZero("/burgers/{burgerId}").Get.Parameters[2].Schema.Schema()
+	sch.Pattern = "["
+
+	errs := v.CompileSchemas()
+	require.Len(t, errs, 1)
+	var compileErr *helpers.SchemaCompileError
+	require.ErrorAs(t, errs[0], &compileErr)
+	assert.Equal(t, "/burgers/{burgerId}", compileErr.Path)
+	assert.Equal(t, http.MethodGet, compileErr.Method)
+	assert.Equal(t, "query parameter 'limit'", compileErr.Location)
+}
+
+func BenchmarkParamValidator_ValidateQueryParams(b *testing.B) {
+	v, _ := schemaCacheValidator(b)
+	request, _ := http.NewRequest(http.MethodGet, "https://things.com/burgers/3?fresh=true&limit=5", nil)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		v.ValidateQueryParams(request)
+	}
+}
@@ -4,6 +4,7 @@
 package parameters
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -13,20 +14,50 @@ import (
 
 	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
 
+	"github.com/pb33f/libopenapi-validator/config"
 	"github.com/pb33f/libopenapi-validator/errors"
 	"github.com/pb33f/libopenapi-validator/helpers"
 	"github.com/pb33f/libopenapi-validator/paths"
+	"github.com/pb33f/libopenapi-validator/radix"
 )
 
 func (v *paramValidator) ValidatePathParams(request *http.Request) (bool, []*errors.ValidationError) {
-	pathItem, errs, foundPath := paths.FindPath(request, v.document)
-	if len(errs) > 0 {
-		return false, errs
-	}
-	return v.ValidatePathParamsWithPathItem(request, pathItem, foundPath)
+	return v.ValidatePathParamsCtx(context.Background(), request)
+}
+
+func (v *paramValidator) ValidatePathParamsCtx(ctx context.Context, request *http.Request) (bool, []*errors.ValidationError) {
+	return errors.ValidateWithContext(ctx, request, func() (bool, []*errors.ValidationError) {
+		pathItem, errs, foundPath := paths.FindPath(request, v.document, config.WithExistingOpts(v.options))
+		if len(errs) > 0 {
+			return false, errs
+		}
+		return v.validatePathParams(ctx, request, pathItem, foundPath)
+	})
 }
 
 func (v *paramValidator) ValidatePathParamsWithPathItem(request *http.Request, pathItem *v3.PathItem, pathValue string) (bool, []*errors.ValidationError) {
+	return v.ValidatePathParamsWithPathItemCtx(context.Background(), request, pathItem, pathValue)
+}
+
+func (v *paramValidator) ValidatePathParamsWithPathItemCtx(
+	ctx context.Context,
+	request *http.Request,
+	pathItem *v3.PathItem,
+	pathValue string,
+) (bool, []*errors.ValidationError) {
+	return errors.ValidateWithContext(ctx, request, func() (bool, []*errors.ValidationError) {
+		return v.validatePathParams(ctx, request, pathItem, pathValue)
+	})
+}
+
+// validatePathParams validates the path parameters of the operation of the path item. The context is checked by the
+// caller, it is passed down to the schema loaders.
+func (v *paramValidator) validatePathParams(
+	ctx context.Context,
+	request *http.Request,
+	pathItem *v3.PathItem,
+	pathValue string,
+) (bool, []*errors.ValidationError) {
 	if pathItem == nil {
 		return false, []*errors.ValidationError{{
 			ValidationType:    helpers.ParameterValidationPath,
@@ -41,7 +72,8 @@ func (v *paramValidator) ValidatePathParamsWithPathItem(request *http.Request, p
 		}}
 	}
 	// split the path into segments
-	submittedSegments := strings.Split(paths.StripRequestPath(request, v.document), helpers.Slash)
+	stripped := paths.StripRequestPathForPathItem(request, v.document, pathItem, config.WithPathTree(v.options.PathTree))
+	submittedSegments := strings.Split(stripped, helpers.Slash)
 	pathSegments := strings.Split(pathValue, helpers.Slash)
 
 	// extract params for the operation
@@ -54,40 +86,33 @@ func (v *paramValidator) ValidatePathParamsWithPathItem(request *http.Request, p
 				if pathSegments[x] == "" { // skip empty segments
 					continue
 				}
-				i := strings.IndexRune(pathSegments[x], '{')
-				if i > -1 {
-					isMatrix := false
-					isLabel := false
-					// isExplode := false
-					isSimple := true
-					paramTemplate := pathSegments[x][i+1 : len(pathSegments[x])-1]
-					paramName := paramTemplate
-					// check for an asterisk on the end of the parameter (explode)
-					if strings.HasSuffix(paramTemplate, helpers.Asterisk) {
-						// isExplode = true
-						paramName = paramTemplate[:len(paramTemplate)-1]
-					}
-					if strings.HasPrefix(paramTemplate, helpers.Period) {
-						isLabel = true
-						isSimple = false
-						paramName = paramName[1:]
-					}
-					if strings.HasPrefix(paramTemplate, helpers.SemiColon) {
-						isMatrix = true
-						isSimple = false
-						paramName = paramName[1:]
-					}
-
-					// does this param name match the current path segment param name
-					if paramName != p.Name {
-						continue
+				if !radix.IsTemplatedSegment(pathSegments[x]) {
+					continue
+				}
+				// a segment can hold more than one template, such as '{name}.{ext}'.
+				segment, err := radix.ParseSegment(pathSegments[x])
+				if err != nil {
+					continue
+				}
+				t := -1
+				for i := range segment.Params {
+					if segment.Params[i].Name == p.Name {
+						t = i
+						break
 					}
+				}
+				if t > -1 {
+					isMatrix := segment.Params[t].Matrix
+					isLabel := segment.Params[t].Label
+					isSimple := !isMatrix && !isLabel
 
 					paramValue := ""
 
 					// extract the parameter value from the path.
 					if x < len(submittedSegments) {
-						paramValue = submittedSegments[x]
+						if values, ok := segment.Match(submittedSegments[x]); ok {
+							paramValue = values[t]
+						}
 					}
 
 					if paramValue == "" {
@@ -131,8 +156,9 @@ func (v *paramValidator) ValidatePathParamsWithPathItem(request *http.Request, p
 									break
 								}
 								validationErrors = append(validationErrors,
-									ValidateSingleParameterSchema(
+									validateSingleParameterValue(
 										sch,
+										v.compiledSchema(ctx, sch, p.Name, false),
 										paramValue,
 										"Path parameter",
 										"The path parameter",
@@ -153,8 +179,9 @@ func (v *paramValidator) ValidatePathParamsWithPathItem(request *http.Request, p
 									enumCheck(rawParamValue)
 									break
 								}
-								validationErrors = append(validationErrors, ValidateSingleParameterSchema(
+								validationErrors = append(validationErrors, validateSingleParameterValue(
 									sch,
+									v.compiledSchema(ctx, sch, p.Name, false),
 									paramValueParsed,
 									"Path parameter",
 									"The path parameter",
@@ -214,7 +241,7 @@ func (v *paramValidator) ValidatePathParamsWithPathItem(request *http.Request, p
 								// if a schema was extracted
 								if sch != nil {
 									validationErrors = append(validationErrors,
-										ValidateParameterSchema(sch,
+										validateParameterValue(sch, v.compiledSchema(ctx, sch, p.Name, true),
 											encodedObject,
 											"",
 											"Path parameter",
@@ -1443,3 +1443,79 @@ paths:
 	assert.False(t, valid)
 	assert.Len(t, errors, 1)
 }
+
+func TestNewValidator_PathParamsPartialSegment(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /files/{name}.{ext}:
+    get:
+      parameters:
+        - name: name
+          in: path
+          required: true
+          schema:
+            type: string
+            minLength: 3
+        - name: ext
+          in: path
+          required: true
+          schema:
+            type: string
+            enum: [png, jpg]
+  /reports/report-{year}.csv:
+    get:
+      parameters:
+        - name: year
+          in: path
+          required: true
+          schema:
+            type: integer
+            minimum: 2000`
+
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+
+	m, _ := doc.BuildV3Model()
+
+	v := NewParameterValidator(&m.Model)
+
+	request, _ := http.NewRequest(http.MethodGet, "https://things.com/files/photo.png", nil)
+	valid, errors := v.ValidatePathParams(request)
+	assert.True(t, valid)
+	assert.Len(t, errors, 0)
+
+	request, _ = http.NewRequest(http.MethodGet, "https://things.com/files/photo.gif", nil)
+	valid, errors = v.ValidatePathParams(request)
+	assert.False(t, valid)
+	assert.Len(t, errors, 1)
+	assert.Equal(t, "Path parameter 'ext' does not match allowed values", errors[0].Message)
+
+	request, _ = http.NewRequest(http.MethodGet, "https://things.com/files/ph.png", nil)
+	valid, errors = v.ValidatePathParams(request)
+	assert.False(t, valid)
+	assert.Len(t, errors, 1)
+	assert.Equal(t, "Path parameter 'name' failed to validate", errors[0].Message)
+
+	request, _ = http.NewRequest(http.MethodGet, "https://things.com/reports/report-2024.csv", nil)
+	valid, errors = v.ValidatePathParams(request)
+	assert.True(t, valid)
+	assert.Len(t, errors, 0)
+
+	request, _ = http.NewRequest(http.MethodGet, "https://things.com/reports/report-1999.csv", nil)
+	valid, errors = v.ValidatePathParams(request)
+	assert.False(t, valid)
+	assert.Len(t, errors, 1)
+	assert.Equal(t, "Path parameter 'year' failed to validate", errors[0].Message)
+
+	request, _ = http.NewRequest(http.MethodGet, "https://things.com/reports/report-latest.csv", nil)
+	valid, errors = v.ValidatePathParams(request)
+	assert.False(t, valid)
+	assert.Len(t, errors, 1)
+	assert.Equal(t, "Path parameter 'year' is not a valid number", errors[0].Message)
+
+	// the segment does not match the template, so the path is not found.
+	request, _ = http.NewRequest(http.MethodGet, "https://things.com/reports/summary-2024.csv", nil)
+	valid, errors = v.ValidatePathParams(request)
+	assert.False(t, valid)
+	assert.Len(t, errors, 1)
+	assert.Equal(t, "GET Path '/reports/summary-2024.csv' not found", errors[0].Message)
+}
@@ -4,6 +4,7 @@
 package parameters
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -13,23 +14,53 @@ import (
 
 	"github.com/pb33f/libopenapi/datamodel/high/base"
 	"github.com/pb33f/libopenapi/orderedmap"
+	"github.com/santhosh-tekuri/jsonschema/v6"
 
 	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
 
+	"github.com/pb33f/libopenapi-validator/config"
 	"github.com/pb33f/libopenapi-validator/errors"
 	"github.com/pb33f/libopenapi-validator/helpers"
 	"github.com/pb33f/libopenapi-validator/paths"
 )
 
 func (v *paramValidator) ValidateQueryParams(request *http.Request) (bool, []*errors.ValidationError) {
-	pathItem, errs, foundPath := paths.FindPath(request, v.document)
-	if len(errs) > 0 {
-		return false, errs
-	}
-	return v.ValidateQueryParamsWithPathItem(request, pathItem, foundPath)
+	return v.ValidateQueryParamsCtx(context.Background(), request)
+}
+
+func (v *paramValidator) ValidateQueryParamsCtx(ctx context.Context, request *http.Request) (bool, []*errors.ValidationError) {
+	return errors.ValidateWithContext(ctx, request, func() (bool, []*errors.ValidationError) {
+		pathItem, errs, foundPath := paths.FindPath(request, v.document, config.WithExistingOpts(v.options))
+		if len(errs) > 0 {
+			return false, errs
+		}
+		return v.validateQueryParams(ctx, request, pathItem, foundPath)
+	})
 }
 
 func (v *paramValidator) ValidateQueryParamsWithPathItem(request *http.Request, pathItem *v3.PathItem, pathValue string) (bool, []*errors.ValidationError) {
+	return v.ValidateQueryParamsWithPathItemCtx(context.Background(), request, pathItem, pathValue)
+}
+
+func (v *paramValidator) ValidateQueryParamsWithPathItemCtx(
+	ctx context.Context,
+	request *http.Request,
+	pathItem *v3.PathItem,
+	pathValue string,
+) (bool, []*errors.ValidationError) {
+	return errors.ValidateWithContext(ctx, request, func() (bool, []*errors.ValidationError) {
+		return v.validateQueryParams(ctx, request, pathItem, pathValue)
+	})
+}
+
+// validateQueryParams validates the query parameters of the operation of the path item. The context is checked by the
+// caller, it is passed down to the schema loaders.
+func (v *paramValidator) validateQueryParams(
+	ctx context.Context,
+	request *http.Request,
+	pathItem *v3.PathItem,
+	pathValue string,
+) (bool, []*errors.ValidationError) {
 	if pathItem == nil {
 		return false, []*errors.ValidationError{{
 			ValidationType:    helpers.ParameterValidationPath,
@@ -118,7 +149,7 @@ doneLooking:
 							switch ty {
 
 							case helpers.String:
-								validationErrors = v.validateSimpleParam(sch, ef, ef, params[p])
+								validationErrors = v.validateSimpleParam(ctx, sch, ef, ef, params[p])
 							case helpers.Integer, helpers.Number:
 								efF, err := strconv.ParseFloat(ef, 64)
 								if err != nil {
@@ -126,7 +157,7 @@ doneLooking:
 										errors.InvalidQueryParamNumber(params[p], ef, sch))
 									break
 								}
-								validationErrors = v.validateSimpleParam(sch, ef, efF, params[p])
+								validationErrors = v.validateSimpleParam(ctx, sch, ef, efF, params[p])
 							case helpers.Boolean:
 								if _, err := strconv.ParseBool(ef); err != nil {
 									validationErrors = append(validationErrors,
@@ -167,7 +198,8 @@ doneLooking:
 
 								numErrors := len(validationErrors)
 								validationErrors = append(validationErrors,
-									ValidateParameterSchema(sch, encodedObj[params[p].Name].(map[string]interface{}),
+									validateParameterValue(sch, v.compiledSchema(ctx, sch, params[p].Name, true),
+										encodedObj[params[p].Name].(map[string]interface{}),
 										ef,
 										"Query parameter",
 										"The query parameter",
@@ -185,7 +217,10 @@ doneLooking:
 								// only check if items is a schema, not a boolean
 								if sch.Items != nil && sch.Items.IsA() {
 									validationErrors = append(validationErrors,
-										ValidateQueryArray(sch, params[p], ef, contentWrapped)...)
+										validateQueryArray(sch, params[p], ef, contentWrapped,
+											func(schema *base.Schema, name string, inline bool) *jsonschema.Schema {
+												return v.compiledSchema(ctx, schema, name, inline)
+											})...)
 								}
 							}
 						}
@@ -202,7 +237,7 @@ doneLooking:
 						// validate the schema.
 						decoded := helpers.ConstructParamMapFromQueryParamInput(queryParams)
 						validationErrors = append(validationErrors,
-							ValidateParameterSchema(sch,
+							validateParameterValue(sch, v.compiledSchema(ctx, sch, params[p].Name, true),
 								decoded,
 								"",
 								"Query array parameter",
@@ -229,7 +264,7 @@ doneLooking:
 	return true, nil
 }
 
-func (v *paramValidator) validateSimpleParam(sch *base.Schema, rawParam string, parsedParam any, parameter *v3.Parameter) (validationErrors []*errors.ValidationError) {
+func (v *paramValidator) validateSimpleParam(ctx context.Context, sch *base.Schema, rawParam string, parsedParam any, parameter *v3.Parameter) (validationErrors []*errors.ValidationError) {
 	// check if the param is within an enum
 	if sch.Enum != nil {
 		matchFound := false
@@ -244,8 +279,9 @@ func (v *paramValidator) validateSimpleParam(sch *base.Schema, rawParam string,
 		}
 	}
 
-	return ValidateSingleParameterSchema(
+	return validateSingleParameterValue(
 		sch,
+		v.compiledSchema(ctx, sch, parameter.Name, false),
 		parsedParam,
 		"Query parameter",
 		"The query parameter",
@@ -4,13 +4,17 @@
 package parameters
 
 import (
+	"context"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/pb33f/libopenapi"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/pb33f/libopenapi-validator/errors"
+	"github.com/pb33f/libopenapi-validator/helpers"
 	"github.com/pb33f/libopenapi-validator/paths"
 )
 
@@ -2826,3 +2830,47 @@ components:
 	assert.True(t, valid)
 	assert.Len(t, errors, 0)
 }
+
+func TestNewValidator_QueryParamsCtx(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /a/fishy/on/a/dishy:
+    get:
+      parameters:
+        - name: fishy
+          in: query
+          required: true
+          schema:
+            type: string
+      operationId: locateFishy
+`
+
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+
+	m, _ := doc.BuildV3Model()
+
+	v := NewParameterValidator(&m.Model)
+
+	request, _ := http.NewRequest(http.MethodGet, "https://things.com/a/fishy/on/a/dishy?fishy=cod", nil)
+	valid, errs := v.ValidateQueryParamsCtx(context.Background(), request)
+	assert.True(t, valid)
+	assert.Len(t, errs, 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+
+	for _, validate := range []func(context.Context, *http.Request) (bool, []*errors.ValidationError){
+		v.ValidateQueryParamsCtx,
+		v.ValidateHeaderParamsCtx,
+		v.ValidateCookieParamsCtx,
+		v.ValidatePathParamsCtx,
+		v.ValidateSecurityCtx,
+	} {
+		valid, errs := validate(ctx, request)
+		assert.False(t, valid)
+		require.Len(t, errs, 1)
+		assert.Equal(t, helpers.ContextDeadlineExceeded, errs[0].ValidationSubType)
+		assert.ErrorIs(t, errs[0], context.DeadlineExceeded)
+	}
+}
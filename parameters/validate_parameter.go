@@ -4,11 +4,11 @@
 package parameters
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/url"
 	"reflect"
-	"strings"
 
 	"github.com/pb33f/libopenapi/datamodel/high/base"
 	"github.com/pb33f/libopenapi/utils"
@@ -18,10 +18,13 @@ import (
 
 	stdError "errors"
 
+	"github.com/pb33f/libopenapi-validator/config"
 	"github.com/pb33f/libopenapi-validator/errors"
 	"github.com/pb33f/libopenapi-validator/helpers"
 )
 
+// ValidateSingleParameterSchema will validate a single (non-object) parameter value against a schema.
+// It will return a list of validation errors, if any. Options can be supplied to configure the schema compiler.
 func ValidateSingleParameterSchema(
 	schema *base.Schema,
 	rawObject any,
@@ -30,8 +33,28 @@ func ValidateSingleParameterSchema(
 	name string,
 	validationType string,
 	subValType string,
+	opts ...config.Option,
 ) (validationErrors []*errors.ValidationError) {
-	jsch := compileSchema(name, buildJsonRender(schema))
+	options := config.NewValidationOptions(opts...)
+	jsch, _ := compileParameterSchema(context.Background(), schema, name, false, options)
+	return validateSingleParameterValue(schema, jsch, rawObject, entity, reasonEntity, name, validationType, subValType)
+}
+
+// validateSingleParameterValue validates a single (non-object) parameter value against a schema that has already
+// been compiled. A schema that could not be compiled is not validated.
+func validateSingleParameterValue(
+	schema *base.Schema,
+	jsch *jsonschema.Schema,
+	rawObject any,
+	entity string,
+	reasonEntity string,
+	name string,
+	validationType string,
+	subValType string,
+) (validationErrors []*errors.ValidationError) {
+	if jsch == nil {
+		return validationErrors
+	}
 
 	scErrs := jsch.Validate(rawObject)
 	var werras *jsonschema.ValidationError
@@ -41,14 +64,46 @@ func ValidateSingleParameterSchema(
 	return validationErrors
 }
 
-// compileSchema create a new json schema compiler and add the schema to it.
-func compileSchema(name string, jsonSchema []byte) *jsonschema.Schema {
-	compiler := jsonschema.NewCompiler()
-	compiler.UseLoader(helpers.NewCompilerLoader())
-	decodedSchema, _ := jsonschema.UnmarshalJSON(strings.NewReader(string(jsonSchema))) // decode the schema into a json blob
-	_ = compiler.AddResource(fmt.Sprintf("%s.json", name), decodedSchema)
-	jsch, _ := compiler.Compile(fmt.Sprintf("%s.json", name))
-	return jsch
+// schemaCompiler returns the compiled form of a parameter schema, or nil if it cannot be compiled. When inline is
+// true, every reference of the schema is rendered inline before it is compiled.
+type schemaCompiler func(schema *base.Schema, name string, inline bool) *jsonschema.Schema
+
+// compileParameterSchema renders a parameter schema, converts it to JSON and compiles it. Single values are
+// validated against the schema as it is rendered, objects against the schema with every reference rendered inline.
+func compileParameterSchema(
+	ctx context.Context,
+	schema *base.Schema,
+	name string,
+	inline bool,
+	o *config.ValidationOptions,
+) (*jsonschema.Schema, error) {
+	return compileSchema(ctx, name, renderParameterSchema(schema, inline), o)
+}
+
+// renderParameterSchema renders a parameter schema as JSON, inline when inline is true.
+func renderParameterSchema(schema *base.Schema, inline bool) []byte {
+	if !inline {
+		return buildJsonRender(schema)
+	}
+	renderedSchema, _ := schema.RenderInline()
+	jsonSchema, _ := utils.ConvertYAMLtoJSON(renderedSchema)
+	return jsonSchema
+}
+
+// compileSchema create a new json schema compiler and add the schema to it. If the schema cannot be compiled,
+// the failure is logged and returned, along with a nil schema.
+func compileSchema(
+	ctx context.Context,
+	name string,
+	jsonSchema []byte,
+	o *config.ValidationOptions,
+) (*jsonschema.Schema, error) {
+	jsch, err := helpers.NewCompiledSchemaContext(ctx, name, jsonSchema, o)
+	if err != nil {
+		o.Logger.Error("unable to compile parameter schema", "parameter", name, "error", err.Error())
+		return nil, err
+	}
+	return jsch, nil
 }
 
 // buildJsonRender build a JSON render of the schema.
@@ -69,6 +124,7 @@ func buildJsonRender(schema *base.Schema) []byte {
 //	name: the name of the parameter
 //	validationType: the type of validation being performed
 //	subValType: the type of sub-validation being performed
+//	opts: options used to configure the schema compiler
 func ValidateParameterSchema(
 	schema *base.Schema,
 	rawObject any,
@@ -78,14 +134,30 @@ func ValidateParameterSchema(
 	name,
 	validationType,
 	subValType string,
+	opts ...config.Option,
 ) []*errors.ValidationError {
-	var validationErrors []*errors.ValidationError
+	options := config.NewValidationOptions(opts...)
+	jsch, _ := compileParameterSchema(context.Background(), schema, name, true, options)
+	return validateParameterValue(schema, jsch, rawObject, rawBlob, entity, reasonEntity, name, validationType,
+		subValType)
+}
 
-	// 1. build a JSON render of the schema.
-	renderedSchema, _ := schema.RenderInline()
-	jsonSchema, _ := utils.ConvertYAMLtoJSON(renderedSchema)
+// validateParameterValue validates a parameter against a raw object, or a blob of json/yaml, using a schema that
+// has already been compiled. See ValidateParameterSchema for the arguments.
+func validateParameterValue(
+	schema *base.Schema,
+	jsch *jsonschema.Schema,
+	rawObject any,
+	rawBlob,
+	entity,
+	reasonEntity,
+	name,
+	validationType,
+	subValType string,
+) []*errors.ValidationError {
+	var validationErrors []*errors.ValidationError
 
-	// 2. decode the object into a json blob.
+	// 1. decode the object into a json blob.
 	var decodedObj interface{}
 	rawIsMap := false
 	validEncoding := false
@@ -107,14 +179,7 @@ func ValidateParameterSchema(
 		_ = json.Unmarshal([]byte(decodedString), &decodedObj)
 		validEncoding = true
 	}
-	// 3. create a new json schema compiler and add the schema to it
-	compiler := jsonschema.NewCompiler()
-
-	decodedSchema, _ := jsonschema.UnmarshalJSON(strings.NewReader(string(jsonSchema)))
-	_ = compiler.AddResource(fmt.Sprintf("%s.json", name), decodedSchema)
-	jsch, _ := compiler.Compile(fmt.Sprintf("%s.json", name))
-
-	// 4. validate the object against the schema
+	// 2. validate the object against the schema
 	var scErrs error
 	if validEncoding {
 		p := decodedObj
@@ -125,7 +190,7 @@ func ValidateParameterSchema(
 				}
 			}
 		}
-		if p != nil {
+		if p != nil && jsch != nil {
 
 			// check if any of the items have an empty key
 			skip := false
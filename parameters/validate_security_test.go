@@ -4,12 +4,18 @@
 package parameters
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
 	"net/http"
 	"testing"
 
 	"github.com/pb33f/libopenapi"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/pb33f/libopenapi-validator/config"
 	"github.com/pb33f/libopenapi-validator/paths"
 )
 
@@ -261,7 +267,7 @@ components:
 	v := NewParameterValidator(&m.Model)
 
 	request, _ := http.NewRequest(http.MethodPost, "https://things.com/products", nil)
-	request.Header.Add("Authorization", "Basic 1234")
+	request.Header.Add("Authorization", "Basic cGIzM2Y6cGIzM2Y=")
 
 	valid, errors := v.ValidateSecurity(request)
 	assert.True(t, valid)
@@ -499,3 +505,560 @@ components:
 	assert.Equal(t, request.URL.Path, errors[1].RequestPath)
 	assert.Equal(t, "/products", errors[1].SpecPath)
 }
+
+func TestParamValidator_ValidateSecurity_DocumentLevel(t *testing.T) {
+	spec := `openapi: 3.1.0
+security:
+  - ApiKeyAuth: []
+paths:
+  /products:
+    post:
+      operationId: createProduct
+    get:
+      operationId: listProducts
+      security: []
+    put:
+      operationId: updateProduct
+      security:
+        - BasicAuth: []
+components:
+  securitySchemes:
+    ApiKeyAuth:
+      type: apiKey
+      in: header
+      name: X-API-Key
+    BasicAuth:
+      type: http
+      scheme: basic
+`
+
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+
+	m, _ := doc.BuildV3Model()
+
+	v := NewParameterValidator(&m.Model)
+
+	// the document security applies to operations without security.
+	request, _ := http.NewRequest(http.MethodPost, "https://things.com/products", nil)
+	valid, errors := v.ValidateSecurity(request)
+	assert.False(t, valid)
+	assert.Len(t, errors, 1)
+	assert.Equal(t, "API Key X-API-Key not found in header", errors[0].Message)
+	assert.Equal(t, "/products", errors[0].SpecPath)
+
+	request.Header.Set("X-API-Key", "1234")
+	valid, errors = v.ValidateSecurity(request)
+	assert.True(t, valid)
+	assert.Len(t, errors, 0)
+
+	// an empty list opts out of the document security.
+	request, _ = http.NewRequest(http.MethodGet, "https://things.com/products", nil)
+	valid, errors = v.ValidateSecurity(request)
+	assert.True(t, valid)
+	assert.Len(t, errors, 0)
+
+	// operation security overrides the document security.
+	request, _ = http.NewRequest(http.MethodPut, "https://things.com/products", nil)
+	request.Header.Set("X-API-Key", "1234")
+	valid, errors = v.ValidateSecurity(request)
+	assert.False(t, valid)
+	assert.Len(t, errors, 1)
+	assert.Equal(t, "Authorization header for 'basic' scheme", errors[0].Message)
+}
+
+func TestParamValidator_ValidateSecurity_AllSchemesRequired(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /products:
+    post:
+      security:
+        - ApiKeyAuth: []
+          BasicAuth: []
+        - ApiKeyQuery: []
+components:
+  securitySchemes:
+    ApiKeyAuth:
+      type: apiKey
+      in: header
+      name: X-API-Key
+    ApiKeyQuery:
+      type: apiKey
+      in: query
+      name: api_key
+    BasicAuth:
+      type: http
+      scheme: basic
+`
+
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+
+	m, _ := doc.BuildV3Model()
+
+	v := NewParameterValidator(&m.Model)
+
+	// only one of the two schemes in the first requirement is satisfied.
+	request, _ := http.NewRequest(http.MethodPost, "https://things.com/products", nil)
+	request.Header.Set("X-API-Key", "1234")
+	valid, errors := v.ValidateSecurity(request)
+	assert.False(t, valid)
+	assert.Len(t, errors, 2)
+	assert.Equal(t, "Authorization header for 'basic' scheme", errors[0].Message)
+	assert.Equal(t, "Authorization header was not found (security requirement 1 of 2, "+
+		"'ApiKeyAuth' and 'BasicAuth', was not satisfied)", errors[0].Reason)
+	assert.Equal(t, "API Key api_key not found in query", errors[1].Message)
+	assert.Equal(t, "API Key not found in URL query for security scheme 'apiKey' with type 'query' "+
+		"(security requirement 2 of 2, 'ApiKeyQuery', was not satisfied)", errors[1].Reason)
+	assert.Equal(t, "/products", errors[1].SpecPath)
+
+	// both schemes of the first requirement are satisfied.
+	request.Header.Set("Authorization", "Basic cGIzM2Y6cGIzM2Y=")
+	valid, errors = v.ValidateSecurity(request)
+	assert.True(t, valid)
+	assert.Len(t, errors, 0)
+
+	// the second requirement is satisfied.
+	request, _ = http.NewRequest(http.MethodPost, "https://things.com/products?api_key=1234", nil)
+	valid, errors = v.ValidateSecurity(request)
+	assert.True(t, valid)
+	assert.Len(t, errors, 0)
+}
+
+func TestParamValidator_ValidateSecurity_OAuth2Alternative(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /products:
+    post:
+      security:
+        - ApiKeyAuth: []
+        - OAuth:
+          - write:products
+    get:
+      security:
+        - OAuth:
+          - read:products
+components:
+  securitySchemes:
+    ApiKeyAuth:
+      type: apiKey
+      in: header
+      name: X-API-Key
+    OAuth:
+      type: oauth2
+      flows:
+        clientCredentials:
+          tokenUrl: https://things.com/token
+          scopes:
+            read:products: read
+            write:products: write
+`
+
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+
+	m, _ := doc.BuildV3Model()
+
+	v := NewParameterValidator(&m.Model)
+
+	// the api key is missing, and there is no token for the oauth2 alternative.
+	request, _ := http.NewRequest(http.MethodPost, "https://things.com/products", nil)
+	valid, errors := v.ValidateSecurity(request)
+	assert.False(t, valid)
+	require.Len(t, errors, 2)
+	assert.Equal(t, "API Key X-API-Key not found in header", errors[0].Message)
+	assert.Equal(t, "Authorization header for 'oauth2' scheme", errors[1].Message)
+	assert.Equal(t, "oauth2", errors[1].ValidationSubType)
+
+	// a bearer token satisfies the oauth2 alternative.
+	request.Header.Set("Authorization", "Bearer abc")
+	valid, errors = v.ValidateSecurity(request)
+	assert.True(t, valid)
+	assert.Len(t, errors, 0)
+
+	// an operation that only accepts oauth2 is not satisfied without a token.
+	request, _ = http.NewRequest(http.MethodGet, "https://things.com/products", nil)
+	valid, errors = v.ValidateSecurity(request)
+	assert.False(t, valid)
+	require.Len(t, errors, 1)
+	assert.Equal(t, "Authorization header was not found", errors[0].Reason)
+}
+
+func TestParamValidator_ValidateSecurity_OpenIDConnectWithAPIKey(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /products:
+    get:
+      security:
+        - ApiKeyAuth: []
+          OpenID: []
+components:
+  securitySchemes:
+    ApiKeyAuth:
+      type: apiKey
+      in: header
+      name: X-API-Key
+    OpenID:
+      type: openIdConnect
+      openIdConnectUrl: https://things.com/.well-known/openid-configuration
+`
+
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+
+	m, _ := doc.BuildV3Model()
+
+	v := NewParameterValidator(&m.Model)
+
+	// the api key alone does not satisfy a requirement that also lists openIdConnect.
+	request, _ := http.NewRequest(http.MethodGet, "https://things.com/products", nil)
+	request.Header.Set("X-API-Key", "1234")
+	result := v.ValidateSecurityResult(request)
+	assert.False(t, result.Valid)
+	assert.Nil(t, result.Requirement)
+	require.Len(t, result.Errors, 1)
+	assert.Equal(t, "Authorization header for 'openIdConnect' scheme", result.Errors[0].Message)
+	assert.Equal(t, "/products", result.Errors[0].SpecPath)
+
+	request.Header.Set("Authorization", "Bearer abc")
+	valid, errors := v.ValidateSecurity(request)
+	assert.True(t, valid)
+	assert.Empty(t, errors)
+}
+
+func TestParamValidator_ValidateSecurity_Authenticator(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /products:
+    post:
+      security:
+        - ApiKeyAuth:
+          - write:products
+components:
+  securitySchemes:
+    ApiKeyAuth:
+      type: apiKey
+      in: header
+      name: X-API-Key
+`
+
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+
+	m, _ := doc.BuildV3Model()
+
+	keys := map[string]string{"1234": "pb33f"}
+	var input *config.AuthenticationInput
+	v := NewParameterValidator(&m.Model, config.WithAuthenticator("ApiKeyAuth",
+		config.AuthenticatorFunc(func(in *config.AuthenticationInput) (any, error) {
+			input = in
+			if user, ok := keys[in.Request.Header.Get(in.Scheme.Name)]; ok {
+				return user, nil
+			}
+			return nil, &config.AuthenticationError{
+				SubType:  "unknownKey",
+				Reason:   "the API key is not known",
+				HowToFix: "Use a registered API key",
+			}
+		})))
+
+	request, _ := http.NewRequest(http.MethodPost, "https://things.com/products", nil)
+	request.Header.Set("X-API-Key", "1234")
+
+	result := v.ValidateSecurityResult(request)
+	assert.True(t, result.Valid)
+	assert.Empty(t, result.Errors)
+	assert.Equal(t, map[string]any{"ApiKeyAuth": "pb33f"}, result.Principals)
+	assert.NotNil(t, result.Requirement)
+
+	require.NotNil(t, input)
+	assert.Same(t, request, input.Request)
+	assert.Equal(t, "ApiKeyAuth", input.SchemeName)
+	assert.Equal(t, []string{"write:products"}, input.Scopes)
+
+	// the key is present, but rejected by the authenticator.
+	request.Header.Set("X-API-Key", "5678")
+	valid, errors := v.ValidateSecurity(request)
+	assert.False(t, valid)
+	require.Len(t, errors, 1)
+	assert.Equal(t, "Authentication failed for security scheme 'ApiKeyAuth'", errors[0].Message)
+	assert.Equal(t, "the API key is not known", errors[0].Reason)
+	assert.Equal(t, "unknownKey", errors[0].ValidationSubType)
+	assert.Equal(t, "Use a registered API key", errors[0].HowToFix)
+	assert.Equal(t, "/products", errors[0].SpecPath)
+
+	// the key is missing, the authenticator is not called.
+	input = nil
+	request.Header.Del("X-API-Key")
+	valid, errors = v.ValidateSecurity(request)
+	assert.False(t, valid)
+	require.Len(t, errors, 1)
+	assert.Equal(t, "API Key X-API-Key not found in header", errors[0].Message)
+	assert.Nil(t, input)
+}
+
+func TestParamValidator_ValidateSecurity_AuthenticatorByType(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /products:
+    get:
+      security:
+        - OAuth:
+          - read:products
+components:
+  securitySchemes:
+    OAuth:
+      type: oauth2
+      flows:
+        clientCredentials:
+          tokenUrl: https://things.com/token
+          scopes:
+            read:products: read
+`
+
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+
+	m, _ := doc.BuildV3Model()
+
+	v := NewParameterValidator(&m.Model, config.WithAuthenticator("oauth2",
+		config.AuthenticatorFunc(func(in *config.AuthenticationInput) (any, error) {
+			if in.Request.Header.Get("Authorization") != "Bearer good" {
+				return nil, fmt.Errorf("bad token")
+			}
+			return nil, nil
+		})))
+
+	// the token is missing, the authenticator of a token scheme decides how that is reported.
+	request, _ := http.NewRequest(http.MethodGet, "https://things.com/products", nil)
+	valid, errors := v.ValidateSecurity(request)
+	assert.False(t, valid)
+	require.Len(t, errors, 1)
+	assert.Equal(t, "bad token", errors[0].Reason)
+	assert.Equal(t, "oauth2", errors[0].ValidationSubType)
+
+	request.Header.Set("Authorization", "Bearer good")
+	result := v.ValidateSecurityResult(request)
+	assert.True(t, result.Valid)
+	assert.Nil(t, result.Principals)
+}
+
+func TestParamValidator_ValidateSecurity_HTTPSchemePrefix(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /products:
+    get:
+      security:
+        - BearerAuth: []
+components:
+  securitySchemes:
+    BearerAuth:
+      type: http
+      scheme: bearer
+`
+
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+
+	m, _ := doc.BuildV3Model()
+
+	v := NewParameterValidator(&m.Model)
+
+	// basic credentials do not satisfy a bearer requirement.
+	request, _ := http.NewRequest(http.MethodGet, "https://things.com/products", nil)
+	request.Header.Set("Authorization", "Basic cGIzM2Y6cGIzM2Y=")
+	valid, errors := v.ValidateSecurity(request)
+	assert.False(t, valid)
+	require.Len(t, errors, 1)
+	assert.Equal(t, "Authorization header for 'bearer' scheme", errors[0].Message)
+	assert.Equal(t, "Authorization header uses the 'Basic' scheme, however the 'bearer' scheme is required", errors[0].Reason)
+	assert.Equal(t, "bearer", errors[0].ValidationSubType)
+
+	request.Header.Set("Authorization", "Bearer")
+	valid, errors = v.ValidateSecurity(request)
+	assert.False(t, valid)
+	require.Len(t, errors, 1)
+	assert.Equal(t, "Malformed credentials for 'bearer' scheme", errors[0].Message)
+	assert.Equal(t, "Authorization header has no credentials for the 'bearer' scheme", errors[0].Reason)
+
+	// schemes are case-insensitive, and the token is opaque without a bearer format.
+	request.Header.Set("Authorization", "BEARER abc")
+	valid, _ = v.ValidateSecurity(request)
+	assert.True(t, valid)
+}
+
+func TestParamValidator_ValidateSecurity_BasicCredentials(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /products:
+    get:
+      security:
+        - BasicAuth: []
+components:
+  securitySchemes:
+    BasicAuth:
+      type: http
+      scheme: basic
+`
+
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+
+	m, _ := doc.BuildV3Model()
+
+	v := NewParameterValidator(&m.Model)
+
+	for credentials, reason := range map[string]string{
+		"not-base64!":      "Basic credentials are not valid base64",
+		"cGIzM2Y=":         "Basic credentials must be a user-id and password separated by a colon", // pb33f
+		"OnBiMzNm":         "Basic credentials must be a user-id and password separated by a colon", // :pb33f
+		"cGIzM2Y6cGIzM2Y=": "",                                                                      // pb33f:pb33f
+		"cGIzM2Y6":         "",                                                                      // pb33f:
+	} {
+		request, _ := http.NewRequest(http.MethodGet, "https://things.com/products", nil)
+		request.Header.Set("Authorization", "Basic "+credentials)
+		valid, errors := v.ValidateSecurity(request)
+		if reason == "" {
+			assert.True(t, valid, credentials)
+			continue
+		}
+		assert.False(t, valid, credentials)
+		require.Len(t, errors, 1)
+		assert.Equal(t, "Malformed credentials for 'basic' scheme", errors[0].Message)
+		assert.Equal(t, reason, errors[0].Reason)
+	}
+}
+
+func TestParamValidator_ValidateSecurity_BearerFormatJWT(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /products:
+    get:
+      security:
+        - JWTAuth: []
+components:
+  securitySchemes:
+    JWTAuth:
+      type: http
+      scheme: bearer
+      bearerFormat: JWT
+`
+
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+
+	m, _ := doc.BuildV3Model()
+
+	v := NewParameterValidator(&m.Model)
+
+	for token, reason := range map[string]string{
+		"abc":                                   "it must have three parts separated by '.'",
+		"!!.e30.abc":                            "the header is not valid base64url",
+		"WzFd.e30.abc":                          "the header is not a JSON object", // [1]
+		"eyJhbGciOiJIUzI1NiJ9.bnVsbA.abc":       "the claims is not a JSON object", // null
+		"e30.e30.abc":                           "the header has no 'alg'",
+		"eyJhbGciOiJIUzI1NiJ9.e30.!!":           "the signature is not valid base64url",
+		"eyJhbGciOiJIUzI1NiJ9.e30.c2lnbmF0dXJl": "",
+	} {
+		request, _ := http.NewRequest(http.MethodGet, "https://things.com/products", nil)
+		request.Header.Set("Authorization", "Bearer "+token)
+		valid, errors := v.ValidateSecurity(request)
+		if reason == "" {
+			assert.True(t, valid, token)
+			continue
+		}
+		assert.False(t, valid, token)
+		require.Len(t, errors, 1)
+		assert.Equal(t, "Bearer token is not a valid JWT: "+reason, errors[0].Reason)
+	}
+}
+
+func TestParamValidator_ValidateSecurity_MutualTLS(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /products:
+    get:
+      security:
+        - ClientCert: []
+components:
+  securitySchemes:
+    ClientCert:
+      type: mutualTLS
+`
+
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+
+	m, _ := doc.BuildV3Model()
+
+	v := NewParameterValidator(&m.Model)
+
+	request, _ := http.NewRequest(http.MethodGet, "https://things.com/products", nil)
+	valid, errors := v.ValidateSecurity(request)
+	assert.False(t, valid)
+	require.Len(t, errors, 1)
+	assert.Equal(t, "Client certificate for 'mutualTLS' scheme", errors[0].Message)
+	assert.Equal(t, "mutualTLS", errors[0].ValidationSubType)
+
+	// TLS without a client certificate.
+	request.TLS = &tls.ConnectionState{}
+	valid, _ = v.ValidateSecurity(request)
+	assert.False(t, valid)
+
+	request.TLS.PeerCertificates = []*x509.Certificate{{}}
+	valid, errors = v.ValidateSecurity(request)
+	assert.True(t, valid)
+	assert.Empty(t, errors)
+}
+
+func TestParamValidator_ValidateSecurityResultCtx(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /products:
+    post:
+      security:
+        - ApiKeyAuth: []
+components:
+  securitySchemes:
+    ApiKeyAuth:
+      type: apiKey
+      in: header
+      name: X-API-Key
+`
+
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+
+	m, _ := doc.BuildV3Model()
+
+	type key struct{}
+	var authCtx context.Context
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), key{}, "burger"))
+	v := NewParameterValidator(&m.Model, config.WithAuthenticator("ApiKeyAuth",
+		config.AuthenticatorFunc(func(in *config.AuthenticationInput) (any, error) {
+			authCtx = in.Context
+			return "pb33f", nil
+		})))
+
+	request, _ := http.NewRequest(http.MethodPost, "https://things.com/products", nil)
+	request.Header.Set("X-API-Key", "1234")
+
+	result := v.ValidateSecurityResultCtx(ctx, request)
+	assert.True(t, result.Valid)
+	require.NotNil(t, authCtx)
+	assert.Equal(t, "burger", authCtx.Value(key{}))
+
+	// the authenticator of a validation without a context is supplied with a background context.
+	result = v.ValidateSecurityResult(request)
+	assert.True(t, result.Valid)
+	assert.NotNil(t, authCtx)
+	assert.Nil(t, authCtx.Value(key{}))
+
+	// the validation is cancelled by the authenticator, the principal is discarded.
+	v = NewParameterValidator(&m.Model, config.WithAuthenticator("ApiKeyAuth",
+		config.AuthenticatorFunc(func(in *config.AuthenticationInput) (any, error) {
+			cancel()
+			return "pb33f", nil
+		})))
+	result = v.ValidateSecurityResultCtx(ctx, request)
+	assert.False(t, result.Valid)
+	assert.Nil(t, result.Principals)
+	require.Len(t, result.Errors, 1)
+	assert.True(t, result.Errors[0].IsContextError())
+	assert.ErrorIs(t, result.Errors[0], context.Canceled)
+
+	valid, errors := v.ValidateSecurityWithPathItemCtx(ctx, request, m.Model.Paths.PathItems.GetOrZero("/products"),
+		"/products")
+	assert.False(t, valid)
+	require.Len(t, errors, 1)
+	assert.True(t, errors[0].IsContextError())
+}
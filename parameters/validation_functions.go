@@ -4,6 +4,7 @@
 package parameters
 
 import (
+	"context"
 	"fmt"
 	"slices"
 	"strconv"
@@ -11,7 +12,9 @@ import (
 
 	"github.com/pb33f/libopenapi/datamodel/high/base"
 	"github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/santhosh-tekuri/jsonschema/v6"
 
+	"github.com/pb33f/libopenapi-validator/config"
 	"github.com/pb33f/libopenapi-validator/errors"
 	"github.com/pb33f/libopenapi-validator/helpers"
 )
@@ -24,7 +27,7 @@ func ValidateCookieArray(
 	itemsSchema := sch.Items.A.Schema()
 
 	// header arrays can only be encoded as CSV
-	items := helpers.ExplodeQueryValue(value, helpers.DefaultDelimited)
+	items := helpers.DecodeHeaderArray(value)
 
 	// now check each item in the array
 	for _, item := range items {
@@ -64,7 +67,7 @@ func ValidateHeaderArray(
 	itemsSchema := sch.Items.A.Schema()
 
 	// header arrays can only be encoded as CSV
-	items := helpers.ExplodeQueryValue(value, helpers.DefaultDelimited)
+	items := helpers.DecodeHeaderArray(value)
 
 	// now check each item in the array
 	for _, item := range items {
@@ -98,7 +101,20 @@ func ValidateHeaderArray(
 
 // ValidateQueryArray will validate a query parameter that is an array
 func ValidateQueryArray(
-	sch *base.Schema, param *v3.Parameter, ef string, contentWrapped bool,
+	sch *base.Schema, param *v3.Parameter, ef string, contentWrapped bool, opts ...config.Option,
+) []*errors.ValidationError {
+	options := config.NewValidationOptions(opts...)
+	return validateQueryArray(sch, param, ef, contentWrapped,
+		func(schema *base.Schema, name string, inline bool) *jsonschema.Schema {
+			jsch, _ := compileParameterSchema(context.Background(), schema, name, inline, options)
+			return jsch
+		})
+}
+
+// validateQueryArray validates a query parameter that is an array, using compile to obtain the schema of object
+// items.
+func validateQueryArray(
+	sch *base.Schema, param *v3.Parameter, ef string, contentWrapped bool, compile schemaCompiler,
 ) []*errors.ValidationError {
 	var validationErrors []*errors.ValidationError
 	itemsSchema := sch.Items.A.Schema()
@@ -167,7 +183,7 @@ func ValidateQueryArray(
 				}
 			case helpers.Object:
 				validationErrors = append(validationErrors,
-					ValidateParameterSchema(itemsSchema,
+					validateParameterValue(itemsSchema, compile(itemsSchema, param.Name, true),
 						nil,
 						item,
 						"Query array parameter",
@@ -6,7 +6,6 @@ package paths
 import (
 	"fmt"
 	"net/http"
-	"net/url"
 	"path/filepath"
 	"strings"
 
@@ -14,8 +13,10 @@ import (
 
 	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
 
+	"github.com/pb33f/libopenapi-validator/config"
 	"github.com/pb33f/libopenapi-validator/errors"
 	"github.com/pb33f/libopenapi-validator/helpers"
+	"github.com/pb33f/libopenapi-validator/radix"
 )
 
 // FindPath will find the path in the document that matches the request path. If a successful match was found, then
@@ -23,73 +24,33 @@ import (
 // that were picked up when locating the path.
 // The third return value will be the path that was found in the document, as it pertains to the contract, so all path
 // parameters will not have been replaced with their values from the request - allowing model lookups.
-func FindPath(request *http.Request, document *v3.Document) (*v3.PathItem, []*errors.ValidationError, string) {
-	basePaths := getBasePaths(document)
-	stripped := StripRequestPath(request, document)
+//
+// If the supplied options contain a pre-compiled PathTree, it will be used to locate the path, otherwise every
+// path in the document is checked in turn, and the servers of the document are compiled for every call.
+func FindPath(request *http.Request, document *v3.Document, opts ...config.Option) (*v3.PathItem, []*errors.ValidationError, string) {
+	pathItem, errs, foundPath, _ := FindPathWithServer(request, document, opts...)
+	return pathItem, errs, foundPath
+}
 
-	reqPathSegments := strings.Split(stripped, "/")
-	if reqPathSegments[0] == "" {
-		reqPathSegments = reqPathSegments[1:]
-	}
+// FindPathWithServer works the same as FindPath, but also returns the server that matched the request, along with
+// the values of the server variables. Server URLs are expanded using the enum values of their variables, and
+// matched against the scheme, host and base path of the request. Servers defined on an operation or a path
+// override the servers defined on the document. The returned server is nil when the request path was found
+// without a server base path.
+func FindPathWithServer(request *http.Request, document *v3.Document, opts ...config.Option) (*v3.PathItem, []*errors.ValidationError, string, *radix.ServerMatch) {
+	candidates, server, _ := resolveRequest(request, document, pathTree(opts))
 
+	// candidates are ranked most specific first, so a literal path wins over a templated path. If none of the
+	// candidates define the operation, the most specific path is reported.
 	var pItem *v3.PathItem
 	var foundPath string
-	for pair := orderedmap.First(document.Paths.PathItems); pair != nil; pair = pair.Next() {
-		path := pair.Key()
-		pathItem := pair.Value()
-
-		// if the stripped path has a fragment, then use that as part of the lookup
-		// if not, then strip off any fragments from the pathItem
-		if !strings.Contains(stripped, "#") {
-			if strings.Contains(path, "#") {
-				path = strings.Split(path, "#")[0]
-			}
-		}
-
-		segs := strings.Split(path, "/")
-		if segs[0] == "" {
-			segs = segs[1:]
-		}
-
-		ok := comparePaths(segs, reqPathSegments, basePaths)
-		if !ok {
-			continue
+	for i, candidate := range candidates {
+		if i == 0 {
+			pItem = candidate.PathItem
+			foundPath = candidate.Path
 		}
-		pItem = pathItem
-		foundPath = path
-		switch request.Method {
-		case http.MethodGet:
-			if pathItem.Get != nil {
-				return pathItem, nil, path
-			}
-		case http.MethodPost:
-			if pathItem.Post != nil {
-				return pathItem, nil, path
-			}
-		case http.MethodPut:
-			if pathItem.Put != nil {
-				return pathItem, nil, path
-			}
-		case http.MethodDelete:
-			if pathItem.Delete != nil {
-				return pathItem, nil, path
-			}
-		case http.MethodOptions:
-			if pathItem.Options != nil {
-				return pathItem, nil, path
-			}
-		case http.MethodHead:
-			if pathItem.Head != nil {
-				return pathItem, nil, path
-			}
-		case http.MethodPatch:
-			if pathItem.Patch != nil {
-				return pathItem, nil, path
-			}
-		case http.MethodTrace:
-			if pathItem.Trace != nil {
-				return pathItem, nil, path
-			}
+		if helpers.ExtractOperation(request, candidate.PathItem) != nil {
+			return candidate.PathItem, nil, candidate.Path, server
 		}
 	}
 	if pItem != nil {
@@ -104,7 +65,7 @@ func FindPath(request *http.Request, document *v3.Document) (*v3.PathItem, []*er
 			HowToFix: errors.HowToFixPath,
 		}}
 		errors.PopulateValidationErrors(validationErrors, request, foundPath)
-		return pItem, validationErrors, foundPath
+		return pItem, validationErrors, foundPath, server
 	}
 	validationErrors := []*errors.ValidationError{
 		{
@@ -120,49 +81,139 @@ func FindPath(request *http.Request, document *v3.Document) (*v3.PathItem, []*er
 		},
 	}
 	errors.PopulateValidationErrors(validationErrors, request, "")
-	return nil, validationErrors, ""
+	return nil, validationErrors, "", nil
 }
 
-func getBasePaths(document *v3.Document) []string {
-	// extract base path from document to check against paths.
-	var basePaths []string
-	for _, s := range document.Servers {
-		u, err := url.Parse(s.URL)
-		// if the host contains special characters, we should attempt to split and parse only the relative path
-		if err != nil {
-			// split at first occurrence
-			_, serverPath, _ := strings.Cut(strings.Replace(s.URL, "//", "", 1), "/")
-
-			if !strings.HasPrefix(serverPath, "/") {
-				serverPath = "/" + serverPath
-			}
-
-			u, _ = url.Parse(serverPath)
+// pathTree returns the PathTree of the options, without creating a full set of options for every request.
+func pathTree(opts []config.Option) *radix.PathTree {
+	if len(opts) == 0 {
+		return nil
+	}
+	var o config.ValidationOptions
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
 		}
+	}
+	return o.PathTree
+}
+
+// documentServers returns the compiled servers of a document, read from the tree when there is one. The tree
+// compiles the servers once, without a tree they are compiled for every call.
+func documentServers(document *v3.Document, tree *radix.PathTree) []*radix.ServerTemplate {
+	if tree != nil {
+		return tree.Servers()
+	}
+	if document == nil {
+		return nil
+	}
+	return radix.CompileServers(document)
+}
 
-		if u != nil && u.Path != "" {
-			basePaths = append(basePaths, u.Path)
+// resolveRequest finds the paths that match the request. The base path of each server that matches the request is
+// stripped in turn, best match first, and only paths served by that server are kept. If no server yields a match,
+// the request path is used as it is. The paths, the server and the stripped request path are returned.
+func resolveRequest(request *http.Request, document *v3.Document, tree *radix.PathTree) ([]*radix.Match, *radix.ServerMatch, string) {
+	servers := documentServers(document, tree)
+	var basePaths []string
+	if tree == nil {
+		basePaths = getBasePaths(document)
+	}
+	lookup := func(path string) []*radix.Match {
+		if tree != nil {
+			return tree.Lookup(path)
 		}
+		return scanPaths(path, document, basePaths)
 	}
 
-	return basePaths
+	for _, server := range radix.MatchServers(servers, request) {
+		stripped := withFragment(request, server.StripPath(request.URL.Path))
+		var served []*radix.Match
+		for _, candidate := range lookup(stripped) {
+			if isServedBy(document, candidate.PathItem, request.Method, server.Server) {
+				served = append(served, candidate)
+			}
+		}
+		if len(served) > 0 {
+			return served, server, stripped
+		}
+	}
+	stripped := withFragment(request, request.URL.Path)
+	return lookup(stripped), nil, stripped
 }
 
-// StripRequestPath strips the base path from the request path, based on the server paths provided in the specification
-func StripRequestPath(request *http.Request, document *v3.Document) string {
-	basePaths := getBasePaths(document)
+func isServedBy(document *v3.Document, pathItem *v3.PathItem, method string, server *v3.Server) bool {
+	for _, s := range radix.EffectiveServers(document, pathItem, method) {
+		if s == server {
+			return true
+		}
+	}
+	return false
+}
 
-	// strip any base path
-	stripped := stripBaseFromPath(request.URL.Path, basePaths)
+func withFragment(request *http.Request, path string) string {
 	if request.URL.Fragment != "" {
-		stripped = fmt.Sprintf("%s#%s", stripped, request.URL.Fragment)
+		path = fmt.Sprintf("%s#%s", path, request.URL.Fragment)
 	}
-	if len(stripped) > 0 && !strings.HasPrefix(stripped, "/") {
-		stripped = "/" + stripped
+	if len(path) > 0 && !strings.HasPrefix(path, "/") {
+		path = "/" + path
 	}
+	return path
+}
+
+// scanPaths checks every path in the document against the stripped request path and returns every match, most
+// specific first.
+func scanPaths(stripped string, document *v3.Document, basePaths []string) []*radix.Match {
+	reqPathSegments := radix.SplitPath(stripped)
+
+	var matches []*radix.Match
+	order := 0
+	for pair := orderedmap.First(document.Paths.PathItems); pair != nil; pair = pair.Next() {
+		order++
+		path := pair.Key()
+
+		// if the stripped path has a fragment, then use that as part of the lookup
+		// if not, then strip off any fragments from the pathItem
+		if !strings.Contains(stripped, "#") {
+			if strings.Contains(path, "#") {
+				path = strings.Split(path, "#")[0]
+			}
+		}
+
+		if comparePaths(radix.SplitPath(path), reqPathSegments, basePaths) {
+			matches = append(matches, radix.NewMatch(path, pair.Value(), order))
+		}
+	}
+	radix.SortMatches(matches)
+	return matches
+}
+
+func getBasePaths(document *v3.Document) []string {
+	// extract base path from document to check against paths.
+	return radix.ExtractBasePaths(document)
+}
+
+// StripRequestPath strips the base path from the request path, based on the servers provided in the specification.
+// The base path of the server that FindPath would match is removed, so the path is resolved in the same way as
+// FindPath. If the supplied options contain a pre-compiled PathTree, the servers are read from the tree. When the
+// path item has already been found, use StripRequestPathForPathItem, which does not resolve the path again.
+func StripRequestPath(request *http.Request, document *v3.Document, opts ...config.Option) string {
+	_, _, stripped := resolveRequest(request, document, pathTree(opts))
 	return stripped
 }
 
+// StripRequestPathForPathItem strips the base path from the request path, using the best matching server that
+// serves the operation of a path item that has already been found, such as by FindPath. The request path is
+// returned as it is when no server serves the operation.
+func StripRequestPathForPathItem(request *http.Request, document *v3.Document, pathItem *v3.PathItem, opts ...config.Option) string {
+	for _, server := range radix.MatchServers(documentServers(document, pathTree(opts)), request) {
+		if isServedBy(document, pathItem, request.Method, server.Server) {
+			return withFragment(request, server.StripPath(request.URL.Path))
+		}
+	}
+	return withFragment(request, request.URL.Path)
+}
+
 func checkPathAgainstBase(docPath, urlPath string, basePaths []string) bool {
 	if docPath == urlPath {
 		return true
@@ -179,15 +230,6 @@ func checkPathAgainstBase(docPath, urlPath string, basePaths []string) bool {
 	return false
 }
 
-func stripBaseFromPath(path string, basePaths []string) string {
-	for i := range basePaths {
-		if strings.HasPrefix(path, basePaths[i]) {
-			return path[len(basePaths[i]):]
-		}
-	}
-	return path
-}
-
 func comparePaths(mapped, requested, basePaths []string) bool {
 	if len(mapped) != len(requested) {
 		return false // short circuit out
@@ -196,6 +238,12 @@ func comparePaths(mapped, requested, basePaths []string) bool {
 	for i, seg := range mapped {
 		s := seg
 		if strings.Contains(seg, "{") {
+			// a partially templated segment, such as '{name}.{ext}', must match the literal parts of the template.
+			if st, err := radix.ParseSegment(seg); err == nil {
+				if _, ok := st.Match(requested[i]); !ok {
+					return false
+				}
+			}
 			s = requested[i]
 		}
 		imploded = append(imploded, s)
@@ -4,12 +4,20 @@
 package paths
 
 import (
+	"fmt"
 	"net/http"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/pb33f/libopenapi"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+
+	"github.com/pb33f/libopenapi-validator/config"
+	"github.com/pb33f/libopenapi-validator/radix"
 )
 
 func TestNewValidator_BadParam(t *testing.T) {
@@ -675,3 +683,322 @@ paths:
 
 	assert.Equal(t, expectedPaths, basePaths)
 }
+
+func TestFindPath_WithPathTree(t *testing.T) {
+	b, _ := os.ReadFile("../test_specs/petstorev3.json")
+	doc, _ := libopenapi.NewDocument(b)
+	m, _ := doc.BuildV3Model()
+
+	tree := radix.NewPathTree(&m.Model)
+
+	requests := []struct {
+		method string
+		url    string
+	}{
+		{http.MethodGet, "https://things.com/api/v3/pet/1234"},
+		{http.MethodPost, "https://things.com/api/v3/pet/1234"},
+		{http.MethodGet, "https://things.com/pet/findByStatus?status=sold"},
+		{http.MethodGet, "https://things.com/api/v3/store/inventory"},
+		{http.MethodDelete, "https://things.com/api/v3/store/order/12"},
+		{http.MethodPut, "https://things.com/api/v3/store/order/12"},
+		{http.MethodGet, "https://things.com/api/v3/user/login"},
+		{http.MethodGet, "https://things.com/api/v3/not/here"},
+		{http.MethodPost, "https://things.com/api/v3/pet/1234/uploadImage"},
+	}
+
+	// the tree must find exactly what a scan of the document finds.
+	for _, r := range requests {
+		request, _ := http.NewRequest(r.method, r.url, nil)
+		scanItem, scanErrs, scanPath := FindPath(request, &m.Model)
+		treeItem, treeErrs, treePath := FindPath(request, &m.Model, config.WithPathTree(tree))
+		assert.Equal(t, scanItem, treeItem, r.url)
+		assert.Equal(t, scanPath, treePath, r.url)
+		assert.Equal(t, len(scanErrs), len(treeErrs), r.url)
+	}
+}
+
+func TestFindPath_WithPathTree_OperationMissing(t *testing.T) {
+	spec := `openapi: 3.1.0
+servers:
+  - url: https://things.com/base
+paths:
+  /burgers/{burgerId}:
+    trace:
+      operationId: locateBurger
+`
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+	m, _ := doc.BuildV3Model()
+	opt := config.WithPathTree(radix.NewPathTree(&m.Model))
+
+	request, _ := http.NewRequest(http.MethodPut, "https://things.com/base/burgers/12345", nil)
+	pathItem, errs, foundPath := FindPath(request, &m.Model, opt)
+	assert.NotNil(t, pathItem)
+	assert.Len(t, errs, 1)
+	assert.True(t, errs[0].IsOperationMissingError())
+	assert.Equal(t, "/burgers/{burgerId}", foundPath)
+
+	request, _ = http.NewRequest(http.MethodTrace, "https://things.com/base/burgers/12345", nil)
+	pathItem, errs, _ = FindPath(request, &m.Model, opt)
+	assert.Len(t, errs, 0)
+	assert.Equal(t, "locateBurger", pathItem.Trace.OperationId)
+
+	assert.Equal(t, "/burgers/12345", StripRequestPath(request, &m.Model, opt))
+}
+
+func TestFindPath_LiteralBeforeTemplated(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /users/{userId}:
+    get:
+      operationId: getUser
+    delete:
+      operationId: deleteUser
+  /users/{userId}.json:
+    get:
+      operationId: getUserJson
+  /users/me:
+    get:
+      operationId: getMe
+`
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+	m, _ := doc.BuildV3Model()
+
+	for name, opts := range map[string][]config.Option{
+		"scan": nil,
+		"tree": {config.WithPathTree(radix.NewPathTree(&m.Model))},
+	} {
+		t.Run(name, func(t *testing.T) {
+			request, _ := http.NewRequest(http.MethodGet, "https://things.com/users/me", nil)
+			pathItem, errs, foundPath := FindPath(request, &m.Model, opts...)
+			assert.Empty(t, errs)
+			assert.Equal(t, "/users/me", foundPath)
+			assert.Equal(t, "getMe", pathItem.Get.OperationId)
+
+			request, _ = http.NewRequest(http.MethodGet, "https://things.com/users/me.json", nil)
+			pathItem, errs, foundPath = FindPath(request, &m.Model, opts...)
+			assert.Empty(t, errs)
+			assert.Equal(t, "/users/{userId}.json", foundPath)
+			assert.Equal(t, "getUserJson", pathItem.Get.OperationId)
+
+			request, _ = http.NewRequest(http.MethodGet, "https://things.com/users/1234", nil)
+			pathItem, errs, foundPath = FindPath(request, &m.Model, opts...)
+			assert.Empty(t, errs)
+			assert.Equal(t, "/users/{userId}", foundPath)
+			assert.Equal(t, "getUser", pathItem.Get.OperationId)
+
+			// the literal path has no delete operation, so the templated path is used.
+			request, _ = http.NewRequest(http.MethodDelete, "https://things.com/users/me", nil)
+			pathItem, errs, foundPath = FindPath(request, &m.Model, opts...)
+			assert.Empty(t, errs)
+			assert.Equal(t, "/users/{userId}", foundPath)
+			assert.Equal(t, "deleteUser", pathItem.Delete.OperationId)
+
+			// no path has a put operation, the most specific path is reported.
+			request, _ = http.NewRequest(http.MethodPut, "https://things.com/users/me", nil)
+			_, errs, foundPath = FindPath(request, &m.Model, opts...)
+			require.Len(t, errs, 1)
+			assert.True(t, errs[0].IsOperationMissingError())
+			assert.Equal(t, "/users/me", foundPath)
+		})
+	}
+}
+
+func TestFindPathWithServer_Variables(t *testing.T) {
+	spec := `openapi: 3.1.0
+servers:
+  - url: 'https://{region}.api.pb33f.io/{basePath}'
+    variables:
+      region:
+        default: us
+        enum: [us, eu]
+      basePath:
+        default: v1
+        enum: [v1, v2]
+paths:
+  /burgers/{burgerId}:
+    get:
+      operationId: getBurger
+`
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+	m, _ := doc.BuildV3Model()
+
+	for name, opts := range map[string][]config.Option{
+		"scan": nil,
+		"tree": {config.WithPathTree(radix.NewPathTree(&m.Model))},
+	} {
+		t.Run(name, func(t *testing.T) {
+			request, _ := http.NewRequest(http.MethodGet, "https://eu.api.pb33f.io/v2/burgers/1234", nil)
+			pathItem, errs, foundPath, server := FindPathWithServer(request, &m.Model, opts...)
+			assert.Empty(t, errs)
+			assert.Equal(t, "getBurger", pathItem.Get.OperationId)
+			assert.Equal(t, "/burgers/{burgerId}", foundPath)
+			require.NotNil(t, server)
+			assert.True(t, server.HostMatched)
+			assert.Equal(t, "/v2", server.BasePath)
+			assert.Equal(t, map[string]string{"region": "eu", "basePath": "v2"}, server.Variables)
+			assert.Equal(t, "/burgers/1234", StripRequestPath(request, &m.Model, opts...))
+			assert.Equal(t, "/burgers/1234", StripRequestPathForPathItem(request, &m.Model, pathItem, opts...))
+
+			// v3 is not a valid base path, so the request path is used as it is.
+			request, _ = http.NewRequest(http.MethodGet, "https://eu.api.pb33f.io/v3/burgers/1234", nil)
+			_, errs, _, server = FindPathWithServer(request, &m.Model, opts...)
+			assert.Len(t, errs, 1)
+			assert.Nil(t, server)
+
+			// the host does not match, but the base path does.
+			request, _ = http.NewRequest(http.MethodGet, "https://things.com/v1/burgers/1234", nil)
+			_, errs, _, server = FindPathWithServer(request, &m.Model, opts...)
+			assert.Empty(t, errs)
+			require.NotNil(t, server)
+			assert.False(t, server.HostMatched)
+			assert.Equal(t, map[string]string{"region": "us", "basePath": "v1"}, server.Variables)
+
+			// no base path at all.
+			request, _ = http.NewRequest(http.MethodGet, "https://things.com/burgers/1234", nil)
+			pathItem, errs, _, server = FindPathWithServer(request, &m.Model, opts...)
+			assert.Empty(t, errs)
+			assert.NotNil(t, pathItem)
+			assert.Nil(t, server)
+		})
+	}
+}
+
+func TestFindPathWithServer_Overrides(t *testing.T) {
+	spec := `openapi: 3.1.0
+servers:
+  - url: 'https://api.pb33f.io/v1'
+paths:
+  /burgers:
+    servers:
+      - url: 'https://api.pb33f.io/v2'
+    get:
+      operationId: listBurgers
+      servers:
+        - url: 'https://api.pb33f.io/v3'
+    post:
+      operationId: createBurger
+  /fries:
+    get:
+      operationId: listFries
+`
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+	m, _ := doc.BuildV3Model()
+
+	for name, opts := range map[string][]config.Option{
+		"scan": nil,
+		"tree": {config.WithPathTree(radix.NewPathTree(&m.Model))},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// the operation server overrides the path and document servers.
+			request, _ := http.NewRequest(http.MethodGet, "https://api.pb33f.io/v3/burgers", nil)
+			pathItem, errs, _, server := FindPathWithServer(request, &m.Model, opts...)
+			assert.Empty(t, errs)
+			assert.Equal(t, "listBurgers", pathItem.Get.OperationId)
+			assert.Equal(t, "https://api.pb33f.io/v3", server.Server.URL)
+
+			request, _ = http.NewRequest(http.MethodGet, "https://api.pb33f.io/v2/burgers", nil)
+			_, errs, _ = FindPath(request, &m.Model, opts...)
+			assert.Len(t, errs, 1)
+
+			// the path server overrides the document server.
+			request, _ = http.NewRequest(http.MethodPost, "https://api.pb33f.io/v2/burgers", nil)
+			pathItem, errs, _, server = FindPathWithServer(request, &m.Model, opts...)
+			assert.Empty(t, errs)
+			assert.Equal(t, "createBurger", pathItem.Post.OperationId)
+			assert.Equal(t, "https://api.pb33f.io/v2", server.Server.URL)
+
+			request, _ = http.NewRequest(http.MethodPost, "https://api.pb33f.io/v1/burgers", nil)
+			_, errs, _ = FindPath(request, &m.Model, opts...)
+			assert.Len(t, errs, 1)
+
+			// the document server is used when there are no overrides.
+			request, _ = http.NewRequest(http.MethodGet, "https://api.pb33f.io/v1/fries", nil)
+			pathItem, errs, _, server = FindPathWithServer(request, &m.Model, opts...)
+			assert.Empty(t, errs)
+			assert.Equal(t, "listFries", pathItem.Get.OperationId)
+			assert.Equal(t, "https://api.pb33f.io/v1", server.Server.URL)
+
+			request, _ = http.NewRequest(http.MethodGet, "https://api.pb33f.io/v2/fries", nil)
+			_, errs, _ = FindPath(request, &m.Model, opts...)
+			assert.Len(t, errs, 1)
+		})
+	}
+}
+
+// buildLargeSpec creates a specification with many paths, a mixture of literal and templated paths.
+func buildLargeSpec(size int) *v3.Document {
+	var sb strings.Builder
+	sb.WriteString("openapi: 3.1.0\nservers:\n  - url: https://things.com/api/v1\npaths:\n")
+	for i := 0; i < size/3; i++ {
+		sb.WriteString(fmt.Sprintf("  /resource%d:\n    get:\n      operationId: list%d\n", i, i))
+		sb.WriteString(fmt.Sprintf("  /resource%d/{id}:\n    get:\n      operationId: get%d\n", i, i))
+		sb.WriteString(fmt.Sprintf("  /resource%d/{id}/children/{childId}:\n    post:\n      operationId: child%d\n", i, i))
+	}
+	doc, _ := libopenapi.NewDocument([]byte(sb.String()))
+	m, _ := doc.BuildV3Model()
+	return &m.Model
+}
+
+func BenchmarkFindPath_Scan(b *testing.B) {
+	m := buildLargeSpec(900)
+	request, _ := http.NewRequest(http.MethodPost, "https://things.com/api/v1/resource299/1234/children/5678", nil)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _, _ = FindPath(request, m)
+	}
+}
+
+func BenchmarkFindPath_PathTree(b *testing.B) {
+	m := buildLargeSpec(900)
+	opt := config.WithPathTree(radix.NewPathTree(m))
+	request, _ := http.NewRequest(http.MethodPost, "https://things.com/api/v1/resource299/1234/children/5678", nil)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _, _ = FindPath(request, m, opt)
+	}
+}
+
+func BenchmarkNewPathTree(b *testing.B) {
+	m := buildLargeSpec(900)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = radix.NewPathTree(m)
+	}
+}
+
+func TestFindPath_CompiledServers(t *testing.T) {
+	spec := `openapi: 3.1.0
+servers:
+  - url: 'https://api.pb33f.io/{version}'
+    variables:
+      version:
+        default: v1
+        enum: [v1, v2]
+paths:
+  /burgers:
+    get:
+      operationId: listBurgers
+`
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+	m, _ := doc.BuildV3Model()
+
+	// the servers of the document are compiled once by the tree, and compiled for each call without one.
+	tree := radix.NewPathTree(&m.Model)
+	servers := documentServers(&m.Model, tree)
+	require.Len(t, servers, 1)
+	assert.Same(t, tree.Servers()[0], servers[0])
+	require.Len(t, documentServers(&m.Model, nil), 1)
+	assert.NotSame(t, servers[0], documentServers(&m.Model, nil)[0])
+
+	request, _ := http.NewRequest(http.MethodGet, "https://api.pb33f.io/v2/burgers", nil)
+	pathItem, errs, _ := FindPath(request, &m.Model)
+	assert.Empty(t, errs)
+	assert.Equal(t, "/burgers", StripRequestPathForPathItem(request, &m.Model, pathItem))
+
+	// the path item is not served from the request base path, so nothing is stripped.
+	request, _ = http.NewRequest(http.MethodGet, "https://api.pb33f.io/v3/burgers", nil)
+	assert.Equal(t, "/v3/burgers", StripRequestPathForPathItem(request, &m.Model, pathItem))
+}
@@ -0,0 +1,7 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+// Package radix contains a pre-compiled routing table for the paths of an OpenAPI 3+ document. The table is
+// built once, when a validator is created, and is then used to look up the path item for each request
+// without re-parsing the document.
+package radix
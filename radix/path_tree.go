@@ -0,0 +1,391 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package radix
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/pb33f/libopenapi/orderedmap"
+
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+// Match is a single path from the document that matches a looked up request path.
+type Match struct {
+	// Path is the path as defined in the document (with any fragment removed, if the lookup did not use one).
+	Path string
+
+	// PathItem is the path item defined for Path.
+	PathItem *v3.PathItem
+
+	// order is the position of the path in the document, used to break ties between equally specific matches.
+	order int
+
+	// specificity holds the kind of each segment of the path, used to rank matches.
+	specificity []segmentKind
+}
+
+// NewMatch creates a new Match for a path in the document. The order is the position of the path in the document.
+func NewMatch(path string, pathItem *v3.PathItem, order int) *Match {
+	segs := SplitPath(path)
+	specificity := make([]segmentKind, len(segs))
+	for i := range segs {
+		specificity[i] = kindOfSegment(segs[i])
+	}
+	return &Match{Path: path, PathItem: pathItem, order: order, specificity: specificity}
+}
+
+// segmentKind describes how specific a path segment is, lower values are more specific.
+type segmentKind int
+
+const (
+	// literalSegment contains no templates, for example 'users'.
+	literalSegment segmentKind = iota
+
+	// partialSegment mixes literal text and templates, for example '{id}.json'.
+	partialSegment
+
+	// templatedSegment is a single template, for example '{id}'.
+	templatedSegment
+)
+
+func kindOfSegment(segment string) segmentKind {
+	if !IsTemplatedSegment(segment) {
+		return literalSegment
+	}
+	st, err := ParseSegment(segment)
+	if err != nil || st.IsFullTemplate() {
+		// a segment with broken templates matches anything, as it always has.
+		return templatedSegment
+	}
+	return partialSegment
+}
+
+// SortMatches ranks matches by specificity, as required by the OpenAPI specification: when matching URLs,
+// concrete (non-templated) paths are matched before their templated counterparts. Segments are compared from
+// left to right, a literal segment ranks above a partially templated segment (such as '{id}.json'), which ranks
+// above a fully templated segment (such as '{id}'). Equally specific matches are kept in document order.
+func SortMatches(matches []*Match) {
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i].specificity, matches[j].specificity
+		for x := 0; x < len(a) && x < len(b); x++ {
+			if a[x] != b[x] {
+				return a[x] < b[x]
+			}
+		}
+		return matches[i].order < matches[j].order
+	})
+}
+
+// Ambiguity describes two paths in a document that can both match the same request.
+type Ambiguity struct {
+	// Path is the path that was defined later in the document.
+	Path string
+
+	// ConflictsWith is the path defined earlier in the document that Path conflicts with.
+	ConflictsWith string
+
+	// Identical is true when the paths only differ by the names of their templates, for example
+	// '/pets/{petId}' and '/pets/{name}'. The OpenAPI specification states that identical templated
+	// paths must not exist. When false, neither path is more specific than the other, for
+	// example '/{entity}/me' and '/books/{id}', so resolution depends on the order in which segments are compared.
+	Identical bool
+}
+
+func (a *Ambiguity) String() string {
+	if a.Identical {
+		return fmt.Sprintf("path '%s' is identical to '%s', only the template names differ", a.Path, a.ConflictsWith)
+	}
+	return fmt.Sprintf("path '%s' is ambiguous with '%s', both can match the same request", a.Path, a.ConflictsWith)
+}
+
+// PathTree is a tree of all the paths defined in an OpenAPI document. Each edge of the tree is a single
+// path segment, literal segments are stored in a map keyed by the segment, and templated segments
+// (such as '{id}') share a single wildcard edge. A lookup walks the tree one segment at a time,
+// so the cost of a lookup depends on the length of the request path, not the number of paths in the document.
+//
+// A PathTree is read-only once built and is safe for concurrent use.
+type PathTree struct {
+	// root contains every path, with any fragment stripped from the last segment.
+	root *node
+
+	// fragmentRoot contains every path, including fragments, used when a request carries a fragment.
+	fragmentRoot *node
+
+	// basePaths are the paths extracted from the servers defined in the document.
+	basePaths []string
+
+	// servers are the compiled servers defined in the document, including path and operation servers.
+	servers []*ServerTemplate
+
+	// ambiguities are the paths that can match the same requests as other paths.
+	ambiguities []*Ambiguity
+
+	size int
+}
+
+type partialEdge struct {
+	template *SegmentTemplate
+	next     *node
+}
+
+type node struct {
+	literals map[string]*node
+
+	// partials are the partially templated segments, such as '{id}.json', keyed by the segment with the
+	// template names removed, so '{id}.json' and '{name}.json' share an edge.
+	partials map[string]*partialEdge
+	wildcard *node
+	matches  []*Match
+}
+
+// NewPathTree compiles all the paths of the supplied document into a new PathTree.
+func NewPathTree(document *v3.Document) *PathTree {
+	tree := &PathTree{
+		root:         &node{},
+		fragmentRoot: &node{},
+	}
+	if document == nil {
+		return tree
+	}
+	tree.basePaths = ExtractBasePaths(document)
+	tree.servers = CompileServers(document)
+	if document.Paths == nil {
+		return tree
+	}
+	var all []*Match
+	for pair := orderedmap.First(document.Paths.PathItems); pair != nil; pair = pair.Next() {
+		all = append(all, tree.insert(pair.Key(), pair.Value()))
+	}
+	tree.ambiguities = findAmbiguities(all)
+	return tree
+}
+
+// Size returns the number of paths held by the tree.
+func (t *PathTree) Size() int {
+	return t.size
+}
+
+// BasePaths returns the base paths of every server defined in the document, in document order.
+func (t *PathTree) BasePaths() []string {
+	return t.basePaths
+}
+
+// Servers returns the compiled servers defined in the document, including path and operation servers.
+func (t *PathTree) Servers() []*ServerTemplate {
+	return t.servers
+}
+
+// Ambiguities returns every pair of paths in the document that can match the same request, without one being
+// more specific than the other.
+func (t *PathTree) Ambiguities() []*Ambiguity {
+	return t.ambiguities
+}
+
+// Lookup will return all the paths that match the supplied request path, most specific first. The request path
+// should already have any server base path removed. If the request path contains a fragment, then paths
+// are matched including their fragments, otherwise fragments are ignored.
+func (t *PathTree) Lookup(path string) []*Match {
+	root := t.root
+	if strings.Contains(path, "#") {
+		root = t.fragmentRoot
+	}
+	var found []*Match
+	collect(root, SplitPath(path), &found)
+	if len(found) > 1 {
+		SortMatches(found)
+	}
+	return found
+}
+
+func (t *PathTree) insert(path string, pathItem *v3.PathItem) *Match {
+	order := t.size
+	t.size++
+
+	match := NewMatch(path, pathItem, order)
+	t.fragmentRoot.add(SplitPath(path), match)
+
+	stripped := path
+	if strings.Contains(path, "#") {
+		stripped = strings.Split(path, "#")[0]
+	}
+	t.root.add(SplitPath(stripped), NewMatch(stripped, pathItem, order))
+	return match
+}
+
+// findAmbiguities compares every path with every other path of the same length. Two paths are ambiguous when
+// they can both match the same request and neither path is more specific than the other.
+func findAmbiguities(matches []*Match) []*Ambiguity {
+	byLength := make(map[int][]*Match)
+	for _, m := range matches {
+		byLength[len(m.specificity)] = append(byLength[len(m.specificity)], m)
+	}
+	var ambiguities []*Ambiguity
+	for _, m := range matches {
+		for _, earlier := range byLength[len(m.specificity)] {
+			if earlier.order >= m.order {
+				break
+			}
+			if identical, ambiguous := compareTemplates(earlier, m); ambiguous {
+				ambiguities = append(ambiguities, &Ambiguity{
+					Path:          m.Path,
+					ConflictsWith: earlier.Path,
+					Identical:     identical,
+				})
+			}
+		}
+	}
+	return ambiguities
+}
+
+func compareTemplates(a, b *Match) (identical, ambiguous bool) {
+	segsA, segsB := SplitPath(a.Path), SplitPath(b.Path)
+	identical = true
+	aWins, bWins := false, false
+	for i := range segsA {
+		kindA, kindB := a.specificity[i], b.specificity[i]
+		if kindA == literalSegment && kindB == literalSegment {
+			if segsA[i] != segsB[i] {
+				return false, false // these paths can never match the same request.
+			}
+			continue
+		}
+		if kindA == literalSegment && kindB == partialSegment && !matchesSegment(segsB[i], segsA[i]) ||
+			kindB == literalSegment && kindA == partialSegment && !matchesSegment(segsA[i], segsB[i]) {
+			return false, false // the literal segment can never match the partial template.
+		}
+		if kindA != kindB {
+			identical = false
+			if kindA < kindB {
+				aWins = true
+			} else {
+				bWins = true
+			}
+			continue
+		}
+		if kindA == partialSegment && normalizedSegment(segsA[i]) != normalizedSegment(segsB[i]) {
+			identical = false
+		}
+	}
+	if identical {
+		return true, true
+	}
+	return false, aWins && bWins
+}
+
+// matchesSegment reports whether a literal segment can be matched by a partially templated segment.
+func matchesSegment(template, segment string) bool {
+	st, err := ParseSegment(template)
+	if err != nil {
+		return true
+	}
+	_, ok := st.Match(segment)
+	return ok
+}
+
+// normalizedSegment returns a partially templated segment with its parameter names removed, so templates that
+// only differ by parameter names, such as '{id}.json' and '{name}.json', compare as identical.
+func normalizedSegment(segment string) string {
+	st, err := ParseSegment(segment)
+	if err != nil {
+		return segment
+	}
+	return st.normalized
+}
+
+func (n *node) add(segments []string, match *Match) {
+	current := n
+	for _, seg := range segments {
+		if kindOfSegment(seg) == partialSegment {
+			st, _ := ParseSegment(seg)
+			if current.partials == nil {
+				current.partials = make(map[string]*partialEdge)
+			}
+			edge, ok := current.partials[st.normalized]
+			if !ok {
+				edge = &partialEdge{template: st, next: &node{}}
+				current.partials[st.normalized] = edge
+			}
+			current = edge.next
+			continue
+		}
+		if IsTemplatedSegment(seg) {
+			if current.wildcard == nil {
+				current.wildcard = &node{}
+			}
+			current = current.wildcard
+			continue
+		}
+		if current.literals == nil {
+			current.literals = make(map[string]*node)
+		}
+		next, ok := current.literals[seg]
+		if !ok {
+			next = &node{}
+			current.literals[seg] = next
+		}
+		current = next
+	}
+	current.matches = append(current.matches, match)
+}
+
+func collect(n *node, segments []string, found *[]*Match) {
+	if len(segments) == 0 {
+		*found = append(*found, n.matches...)
+		return
+	}
+	if next, ok := n.literals[segments[0]]; ok {
+		collect(next, segments[1:], found)
+	}
+	for _, edge := range n.partials {
+		if _, ok := edge.template.Match(segments[0]); ok {
+			collect(edge.next, segments[1:], found)
+		}
+	}
+	if n.wildcard != nil {
+		collect(n.wildcard, segments[1:], found)
+	}
+}
+
+// IsTemplatedSegment returns true if the path segment contains a path parameter template, such as '{id}'.
+func IsTemplatedSegment(segment string) bool {
+	return strings.Contains(segment, "{")
+}
+
+// SplitPath splits a path into segments, ignoring the leading slash.
+func SplitPath(path string) []string {
+	segs := strings.Split(path, "/")
+	if segs[0] == "" {
+		segs = segs[1:]
+	}
+	return segs
+}
+
+// ExtractBasePaths extracts the base path of each server defined in the document. Server URLs that cannot be
+// parsed (for example, when the host contains variables) are cut at the first slash after the host.
+func ExtractBasePaths(document *v3.Document) []string {
+	var basePaths []string
+	for _, s := range document.Servers {
+		u, err := url.Parse(s.URL)
+		// if the host contains special characters, we should attempt to split and parse only the relative path
+		if err != nil {
+			// split at first occurrence
+			_, serverPath, _ := strings.Cut(strings.Replace(s.URL, "//", "", 1), "/")
+
+			if !strings.HasPrefix(serverPath, "/") {
+				serverPath = "/" + serverPath
+			}
+
+			u, _ = url.Parse(serverPath)
+		}
+
+		if u != nil && u.Path != "" {
+			basePaths = append(basePaths, u.Path)
+		}
+	}
+	return basePaths
+}
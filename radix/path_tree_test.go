@@ -0,0 +1,263 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package radix
+
+import (
+	"os"
+	"testing"
+
+	"github.com/pb33f/libopenapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+func buildModel(t *testing.T, spec string) *v3.Document {
+	doc, err := libopenapi.NewDocument([]byte(spec))
+	require.NoError(t, err)
+	m, errs := doc.BuildV3Model()
+	require.Empty(t, errs)
+	return &m.Model
+}
+
+func TestNewPathTree_Nil(t *testing.T) {
+	tree := NewPathTree(nil)
+	assert.Equal(t, 0, tree.Size())
+	assert.Empty(t, tree.Lookup("/anything"))
+	assert.Empty(t, tree.BasePaths())
+}
+
+func TestNewPathTree_NoPaths(t *testing.T) {
+	tree := NewPathTree(&v3.Document{})
+	assert.Equal(t, 0, tree.Size())
+	assert.Empty(t, tree.Lookup("/anything"))
+}
+
+func TestPathTree_Lookup(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /burgers:
+    get:
+      operationId: listBurgers
+  /burgers/{burgerId}:
+    get:
+      operationId: getBurger
+  /burgers/{burgerId}/dressings/{dressingId}:
+    get:
+      operationId: getDressing
+  /burgers/hot/dogs:
+    get:
+      operationId: hotDogs
+`
+	tree := NewPathTree(buildModel(t, spec))
+	assert.Equal(t, 4, tree.Size())
+
+	found := tree.Lookup("/burgers")
+	require.Len(t, found, 1)
+	assert.Equal(t, "/burgers", found[0].Path)
+	assert.Equal(t, "listBurgers", found[0].PathItem.Get.OperationId)
+
+	found = tree.Lookup("/burgers/1234")
+	require.Len(t, found, 1)
+	assert.Equal(t, "/burgers/{burgerId}", found[0].Path)
+
+	found = tree.Lookup("/burgers/1234/dressings/5678")
+	require.Len(t, found, 1)
+	assert.Equal(t, "getDressing", found[0].PathItem.Get.OperationId)
+
+	found = tree.Lookup("/burgers/hot/dogs")
+	require.Len(t, found, 1)
+	assert.Equal(t, "hotDogs", found[0].PathItem.Get.OperationId)
+
+	assert.Empty(t, tree.Lookup("/burgers/1234/dressings"))
+	assert.Empty(t, tree.Lookup("/pizza"))
+	assert.Empty(t, tree.Lookup("/burgers/hot/dogs/cold"))
+}
+
+func TestPathTree_Lookup_LiteralBeforeTemplated(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /pizza/{pizzaId}:
+    put:
+      operationId: updatePizza
+  /pizza/special:
+    get:
+      operationId: specialPizza
+  /pizza/{name}:
+    post:
+      operationId: namedPizza
+`
+	tree := NewPathTree(buildModel(t, spec))
+
+	found := tree.Lookup("/pizza/special")
+	require.Len(t, found, 3)
+	assert.Equal(t, "/pizza/special", found[0].Path)
+	assert.Equal(t, "/pizza/{pizzaId}", found[1].Path)
+	assert.Equal(t, "/pizza/{name}", found[2].Path)
+}
+
+func TestPathTree_Lookup_Specificity(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /{entity}/{id}:
+    get:
+      operationId: anything
+  /files/{id}:
+    get:
+      operationId: fileById
+  /files/{id}.json:
+    get:
+      operationId: fileAsJson
+  /files/latest.json:
+    get:
+      operationId: latestFile
+  /{entity}/latest.json:
+    get:
+      operationId: latestEntity
+`
+	tree := NewPathTree(buildModel(t, spec))
+
+	found := tree.Lookup("/files/latest.json")
+	require.Len(t, found, 5)
+	assert.Equal(t, "/files/latest.json", found[0].Path)
+	assert.Equal(t, "/files/{id}.json", found[1].Path)
+	assert.Equal(t, "/files/{id}", found[2].Path)
+	assert.Equal(t, "/{entity}/latest.json", found[3].Path)
+	assert.Equal(t, "/{entity}/{id}", found[4].Path)
+}
+
+func TestPathTree_Ambiguities(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /pets/{petId}:
+    get:
+      operationId: getPet
+  /pets/{name}:
+    get:
+      operationId: getPetByName
+  /pets/mine:
+    get:
+      operationId: myPet
+  /{entity}/me:
+    get:
+      operationId: me
+  /books/{id}:
+    get:
+      operationId: getBook
+  /books/{id}.json:
+    get:
+      operationId: getBookJson
+  /books/{isbn}.json:
+    get:
+      operationId: getBookIsbn
+`
+	tree := NewPathTree(buildModel(t, spec))
+
+	ambiguities := tree.Ambiguities()
+	require.Len(t, ambiguities, 5)
+
+	assert.Equal(t, "/pets/{name}", ambiguities[0].Path)
+	assert.Equal(t, "/pets/{petId}", ambiguities[0].ConflictsWith)
+	assert.True(t, ambiguities[0].Identical)
+	assert.Equal(t, "path '/pets/{name}' is identical to '/pets/{petId}', only the template names differ",
+		ambiguities[0].String())
+
+	// '/pets/me' matches both paths, neither is more specific.
+	assert.Equal(t, "/{entity}/me", ambiguities[1].Path)
+	assert.Equal(t, "/pets/{petId}", ambiguities[1].ConflictsWith)
+	assert.False(t, ambiguities[1].Identical)
+	assert.Equal(t, "path '/{entity}/me' is ambiguous with '/pets/{petId}', both can match the same request",
+		ambiguities[1].String())
+
+	assert.Equal(t, "/{entity}/me", ambiguities[2].Path)
+	assert.Equal(t, "/pets/{name}", ambiguities[2].ConflictsWith)
+
+	assert.Equal(t, "/books/{id}", ambiguities[3].Path)
+	assert.Equal(t, "/{entity}/me", ambiguities[3].ConflictsWith)
+
+	// '{id}.json' can never match 'me', so only the identical templates are reported.
+	assert.Equal(t, "/books/{isbn}.json", ambiguities[4].Path)
+	assert.Equal(t, "/books/{id}.json", ambiguities[4].ConflictsWith)
+	assert.True(t, ambiguities[4].Identical)
+}
+
+func TestPathTree_Lookup_Fragments(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /hashy#one:
+    post:
+      operationId: one
+  /hashy#two:
+    post:
+      operationId: two
+`
+	tree := NewPathTree(buildModel(t, spec))
+
+	found := tree.Lookup("/hashy#two")
+	require.Len(t, found, 1)
+	assert.Equal(t, "two", found[0].PathItem.Post.OperationId)
+	assert.Equal(t, "/hashy#two", found[0].Path)
+
+	// without a fragment, both paths match, with their fragments removed.
+	found = tree.Lookup("/hashy")
+	require.Len(t, found, 2)
+	assert.Equal(t, "/hashy", found[0].Path)
+	assert.Equal(t, "one", found[0].PathItem.Post.OperationId)
+}
+
+func TestPathTree_Lookup_TrailingSlash(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /pets/:
+    get:
+      operationId: listPets
+`
+	tree := NewPathTree(buildModel(t, spec))
+	assert.Len(t, tree.Lookup("/pets/"), 1)
+	assert.Empty(t, tree.Lookup("/pets"))
+}
+
+func TestPathTree_PetStore(t *testing.T) {
+	b, _ := os.ReadFile("../test_specs/petstorev3.json")
+	doc, _ := libopenapi.NewDocument(b)
+	m, _ := doc.BuildV3Model()
+
+	tree := NewPathTree(&m.Model)
+	assert.Equal(t, []string{"/api/v3"}, tree.BasePaths())
+	assert.Empty(t, tree.Ambiguities())
+
+	found := tree.Lookup("/pet/findByStatus")
+	require.NotEmpty(t, found)
+	assert.Equal(t, "/pet/findByStatus", found[0].Path)
+
+	found = tree.Lookup("/store/order/12")
+	require.Len(t, found, 1)
+	assert.Equal(t, "/store/order/{orderId}", found[0].Path)
+}
+
+func TestExtractBasePaths(t *testing.T) {
+	spec := `openapi: 3.1.0
+servers:
+  - url: 'https://things.com/'
+  - url: 'https://things.com/some/path'
+  - url: 'https://{invalid}.com//even//more//paths//please'
+  - url: 'https://things.com'
+`
+	basePaths := ExtractBasePaths(buildModel(t, spec))
+	assert.Equal(t, []string{"/", "/some/path", "/even//more//paths//please"}, basePaths)
+}
+
+func TestSplitPath(t *testing.T) {
+	assert.Equal(t, []string{"a", "b"}, SplitPath("/a/b"))
+	assert.Equal(t, []string{"a", ""}, SplitPath("/a/"))
+	assert.Equal(t, []string{"a"}, SplitPath("a"))
+	assert.Equal(t, []string{}, SplitPath(""))
+}
+
+func TestIsTemplatedSegment(t *testing.T) {
+	assert.True(t, IsTemplatedSegment("{id}"))
+	assert.True(t, IsTemplatedSegment("report-{year}.csv"))
+	assert.False(t, IsTemplatedSegment("burgers"))
+}
@@ -0,0 +1,150 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package radix
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// SegmentTemplate is a single path segment that has been parsed into literal text and templates, for example
+// 'report-{year}.csv' or '{name}.{ext}'. Each template captures part of the segment when matched.
+type SegmentTemplate struct {
+	// Segment is the segment as defined in the document.
+	Segment string
+
+	// Params are the templates found in the segment, in the order they appear.
+	Params []*TemplateParam
+
+	normalized string
+	rx         *regexp.Regexp
+}
+
+// TemplateParam is a single template in a path segment, such as '{id}', '{.id}' or '{;id*}'.
+type TemplateParam struct {
+	// Name is the name of the parameter, without any style prefix or explode suffix.
+	Name string
+
+	// Label is true when the template uses the label style prefix, for example '{.id}'.
+	Label bool
+
+	// Matrix is true when the template uses the matrix style prefix, for example '{;id}'.
+	Matrix bool
+
+	// Explode is true when the template has an explode suffix, for example '{id*}'.
+	Explode bool
+}
+
+// segmentTemplates caches parsed segments, the same segments are parsed many times when scanning paths.
+var segmentTemplates sync.Map
+
+// ParseSegment parses a path segment into a SegmentTemplate. A segment that is a single template, such as
+// '{id}', matches any value, including an empty one. When a segment mixes literal text and templates, every
+// template must match at least one character, and templates are matched from left to right, so '{name}.{ext}'
+// matched against 'archive.tar.gz' extracts 'archive' and 'tar.gz'. Parsed segments are cached.
+func ParseSegment(segment string) (*SegmentTemplate, error) {
+	if cached, ok := segmentTemplates.Load(segment); ok {
+		return cached.(*SegmentTemplate), nil
+	}
+
+	st := &SegmentTemplate{Segment: segment}
+	var pattern, normalized strings.Builder
+	pattern.WriteString("^")
+
+	rest := segment
+	for len(rest) > 0 {
+		open := strings.IndexAny(rest, "{}")
+		if open < 0 {
+			pattern.WriteString(regexp.QuoteMeta(rest))
+			normalized.WriteString(rest)
+			break
+		}
+		if rest[open] == '}' {
+			return nil, fmt.Errorf("path segment '%s' has a closing brace without an opening brace", segment)
+		}
+		closing := strings.IndexAny(rest[open+1:], "{}")
+		if closing < 0 || rest[open+1+closing] == '{' {
+			return nil, fmt.Errorf("path segment '%s' has an unclosed template", segment)
+		}
+		literal := rest[:open]
+		name := rest[open+1 : open+1+closing]
+		if name == "" {
+			return nil, fmt.Errorf("path segment '%s' has an empty template", segment)
+		}
+		pattern.WriteString(regexp.QuoteMeta(literal))
+		normalized.WriteString(literal)
+		normalized.WriteString("{}")
+
+		st.Params = append(st.Params, parseTemplateParam(name))
+		pattern.WriteString("(.+?)")
+		rest = rest[open+1+closing+1:]
+	}
+
+	st.normalized = normalized.String()
+	if st.IsFullTemplate() {
+		st.rx = regexp.MustCompile("^(.*)$")
+	} else {
+		pattern.WriteString("$")
+		st.rx = regexp.MustCompile(pattern.String())
+	}
+
+	cached, _ := segmentTemplates.LoadOrStore(segment, st)
+	return cached.(*SegmentTemplate), nil
+}
+
+func parseTemplateParam(template string) *TemplateParam {
+	param := &TemplateParam{Name: template}
+	if strings.HasSuffix(param.Name, "*") {
+		param.Explode = true
+		param.Name = param.Name[:len(param.Name)-1]
+	}
+	if strings.HasPrefix(param.Name, ".") {
+		param.Label = true
+		param.Name = param.Name[1:]
+	}
+	if strings.HasPrefix(param.Name, ";") {
+		param.Matrix = true
+		param.Name = param.Name[1:]
+	}
+	return param
+}
+
+// IsTemplated returns true if the segment contains at least one template.
+func (s *SegmentTemplate) IsTemplated() bool {
+	return len(s.Params) > 0
+}
+
+// IsFullTemplate returns true if the segment is a single template with no literal text, such as '{id}'.
+func (s *SegmentTemplate) IsFullTemplate() bool {
+	return s.normalized == "{}"
+}
+
+// Match matches a segment from a request against the template. If the segment matches, the values captured for
+// each template are returned in the same order as Params.
+func (s *SegmentTemplate) Match(segment string) ([]string, bool) {
+	if !s.IsTemplated() {
+		return nil, s.Segment == segment
+	}
+	found := s.rx.FindStringSubmatch(segment)
+	if found == nil {
+		return nil, false
+	}
+	return found[1:], true
+}
+
+// MatchParams matches a segment from a request against the template, and returns the captured values keyed by
+// the name of each template.
+func (s *SegmentTemplate) MatchParams(segment string) (map[string]string, bool) {
+	values, ok := s.Match(segment)
+	if !ok {
+		return nil, false
+	}
+	params := make(map[string]string, len(values))
+	for i := range values {
+		params[s.Params[i].Name] = values[i]
+	}
+	return params, true
+}
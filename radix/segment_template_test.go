@@ -0,0 +1,156 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package radix
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSegment_Literal(t *testing.T) {
+	st, err := ParseSegment("burgers")
+	require.NoError(t, err)
+	assert.False(t, st.IsTemplated())
+	assert.False(t, st.IsFullTemplate())
+
+	_, ok := st.Match("burgers")
+	assert.True(t, ok)
+	_, ok = st.Match("burger")
+	assert.False(t, ok)
+}
+
+func TestParseSegment_FullTemplate(t *testing.T) {
+	st, err := ParseSegment("{burgerId}")
+	require.NoError(t, err)
+	assert.True(t, st.IsTemplated())
+	assert.True(t, st.IsFullTemplate())
+	require.Len(t, st.Params, 1)
+	assert.Equal(t, "burgerId", st.Params[0].Name)
+
+	values, ok := st.Match("1234")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"1234"}, values)
+
+	values, ok = st.Match("")
+	assert.True(t, ok)
+	assert.Equal(t, []string{""}, values)
+}
+
+func TestParseSegment_Styles(t *testing.T) {
+	st, err := ParseSegment("{.burgerId*}")
+	require.NoError(t, err)
+	assert.Equal(t, &TemplateParam{Name: "burgerId", Label: true, Explode: true}, st.Params[0])
+
+	st, err = ParseSegment("{;burgerId}")
+	require.NoError(t, err)
+	assert.Equal(t, &TemplateParam{Name: "burgerId", Matrix: true}, st.Params[0])
+
+	values, ok := st.Match(";burgerId=1234")
+	assert.True(t, ok)
+	assert.Equal(t, []string{";burgerId=1234"}, values)
+}
+
+func TestParseSegment_PartialTemplate(t *testing.T) {
+	st, err := ParseSegment("report-{year}.csv")
+	require.NoError(t, err)
+	assert.True(t, st.IsTemplated())
+	assert.False(t, st.IsFullTemplate())
+
+	params, ok := st.MatchParams("report-2024.csv")
+	assert.True(t, ok)
+	assert.Equal(t, map[string]string{"year": "2024"}, params)
+
+	_, ok = st.Match("report-.csv")
+	assert.False(t, ok)
+	_, ok = st.Match("summary-2024.csv")
+	assert.False(t, ok)
+	_, ok = st.Match("report-2024.json")
+	assert.False(t, ok)
+}
+
+func TestParseSegment_MultipleTemplates(t *testing.T) {
+	st, err := ParseSegment("{name}.{ext}")
+	require.NoError(t, err)
+	require.Len(t, st.Params, 2)
+	assert.Equal(t, "name", st.Params[0].Name)
+	assert.Equal(t, "ext", st.Params[1].Name)
+
+	params, ok := st.MatchParams("photo.png")
+	assert.True(t, ok)
+	assert.Equal(t, map[string]string{"name": "photo", "ext": "png"}, params)
+
+	// templates are matched from left to right.
+	params, ok = st.MatchParams("archive.tar.gz")
+	assert.True(t, ok)
+	assert.Equal(t, map[string]string{"name": "archive", "ext": "tar.gz"}, params)
+
+	_, ok = st.Match("photo.")
+	assert.False(t, ok)
+	_, ok = st.Match("photo")
+	assert.False(t, ok)
+}
+
+func TestParseSegment_RegexCharacters(t *testing.T) {
+	st, err := ParseSegment("v1.({id})+")
+	require.NoError(t, err)
+
+	params, ok := st.MatchParams("v1.(abc)+")
+	assert.True(t, ok)
+	assert.Equal(t, map[string]string{"id": "abc"}, params)
+
+	_, ok = st.Match("v1x(abc)+")
+	assert.False(t, ok)
+}
+
+func TestParseSegment_Invalid(t *testing.T) {
+	_, err := ParseSegment("{id")
+	assert.EqualError(t, err, "path segment '{id' has an unclosed template")
+
+	_, err = ParseSegment("{i{d}")
+	assert.EqualError(t, err, "path segment '{i{d}' has an unclosed template")
+
+	_, err = ParseSegment("id}")
+	assert.EqualError(t, err, "path segment 'id}' has a closing brace without an opening brace")
+
+	_, err = ParseSegment("report-{}.csv")
+	assert.EqualError(t, err, "path segment 'report-{}.csv' has an empty template")
+}
+
+func TestParseSegment_Cached(t *testing.T) {
+	a, _ := ParseSegment("{name}.{ext}")
+	b, _ := ParseSegment("{name}.{ext}")
+	assert.Same(t, a, b)
+}
+
+func TestPathTree_Lookup_PartialTemplates(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /files/{name}.{ext}:
+    get:
+      operationId: getFile
+  /reports/report-{year}.csv:
+    get:
+      operationId: getReport
+  /reports/{reportId}:
+    get:
+      operationId: getReportById
+`
+	tree := NewPathTree(buildModel(t, spec))
+
+	found := tree.Lookup("/files/photo.png")
+	require.Len(t, found, 1)
+	assert.Equal(t, "getFile", found[0].PathItem.Get.OperationId)
+	assert.Empty(t, tree.Lookup("/files/photo"))
+
+	found = tree.Lookup("/reports/report-2024.csv")
+	require.Len(t, found, 2)
+	assert.Equal(t, "getReport", found[0].PathItem.Get.OperationId)
+	assert.Equal(t, "getReportById", found[1].PathItem.Get.OperationId)
+
+	found = tree.Lookup("/reports/summary-2024.csv")
+	require.Len(t, found, 1)
+	assert.Equal(t, "getReportById", found[0].PathItem.Get.OperationId)
+}
@@ -0,0 +1,329 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package radix
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pb33f/libopenapi/orderedmap"
+
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+// ServerTemplate is a server defined in the document, with the scheme, host and base path of the URL compiled
+// so they can be matched against a request. Server variables are expanded using their enum values, a variable
+// without an enum matches any value.
+type ServerTemplate struct {
+	// Server is the server as defined in the document.
+	Server *v3.Server
+
+	scheme   *urlTemplate
+	host     *urlTemplate
+	basePath *urlTemplate
+	order    int
+}
+
+// ServerMatch is a server that matched a request, with the values of the server variables.
+type ServerMatch struct {
+	// Server is the server as defined in the document.
+	Server *v3.Server
+
+	// Variables holds the value of every server variable. Values that could not be read from the request
+	// are set to the default value of the variable.
+	Variables map[string]string
+
+	// BasePath is the base path of the server, as it appears in the request.
+	BasePath string
+
+	// HostMatched is true when the scheme and host of the server match the request, or when the server
+	// URL is relative.
+	HostMatched bool
+
+	order int
+}
+
+type urlTemplate struct {
+	rx   *regexp.Regexp
+	vars []string
+
+	// literal is the template itself when it has no variables, it is compared without a regular expression.
+	literal string
+}
+
+// NewServerTemplate compiles the URL of a server, so it can be matched against requests.
+func NewServerTemplate(server *v3.Server) *ServerTemplate {
+	st := &ServerTemplate{Server: server}
+	rest := server.URL
+	if i := strings.Index(rest, "?"); i > -1 {
+		rest = rest[:i]
+	}
+	if i := strings.Index(rest, "://"); i > -1 {
+		st.scheme = compileURLTemplate(rest[:i], server, "^(?i)", "$")
+		rest = rest[i+1:]
+	}
+	if strings.HasPrefix(rest, "//") {
+		host, path, found := strings.Cut(rest[2:], "/")
+		st.host = compileURLTemplate(host, server, "^(?i)", "$")
+		rest = ""
+		if found {
+			rest = "/" + path
+		}
+	}
+	rest = strings.TrimSuffix(rest, "/")
+	if rest != "" {
+		if !strings.HasPrefix(rest, "/") {
+			rest = "/" + rest
+		}
+		// the base path ends on a segment boundary, so an enum value that is a prefix of another, such as 'v1'
+		// and 'v10', cannot match part of a segment.
+		st.basePath = compileURLTemplate(rest, server, "^", "(?:/|$)")
+	}
+	return st
+}
+
+func compileURLTemplate(template string, server *v3.Server, prefix, suffix string) *urlTemplate {
+	if !strings.Contains(template, "{") {
+		return &urlTemplate{literal: template}
+	}
+	ut := &urlTemplate{}
+	var pattern strings.Builder
+	pattern.WriteString(prefix)
+	for {
+		open := strings.Index(template, "{")
+		closing := strings.Index(template, "}")
+		if open < 0 || closing < open {
+			pattern.WriteString(regexp.QuoteMeta(template))
+			break
+		}
+		pattern.WriteString(regexp.QuoteMeta(template[:open]))
+		name := template[open+1 : closing]
+		ut.vars = append(ut.vars, name)
+
+		var variable *v3.ServerVariable
+		if server.Variables != nil {
+			variable = server.Variables.GetOrZero(name)
+		}
+		if variable != nil && len(variable.Enum) > 0 {
+			values := make([]string, len(variable.Enum))
+			for i := range variable.Enum {
+				values[i] = regexp.QuoteMeta(variable.Enum[i])
+			}
+			pattern.WriteString("(" + strings.Join(values, "|") + ")")
+		} else {
+			pattern.WriteString("([^/]+)")
+		}
+		template = template[closing+1:]
+	}
+	pattern.WriteString(suffix)
+	ut.rx = regexp.MustCompile(pattern.String())
+	return ut
+}
+
+// Match matches the server against a request. A server matches when the path of the request starts with the
+// base path of the server, nil is returned when it does not. The scheme and host of the server are checked
+// separately, the result is reported as HostMatched.
+func (s *ServerTemplate) Match(request *http.Request) *ServerMatch {
+	sm := &ServerMatch{Server: s.Server, HostMatched: true, order: s.order}
+	var found map[string]string
+
+	if s.basePath != nil {
+		path := request.URL.Path
+		loc := s.basePath.find(path, false)
+		if loc == nil || (loc[1] < len(path) && path[loc[1]] != '/') {
+			return nil
+		}
+		sm.BasePath = path[:loc[1]]
+		found = s.basePath.capture(path, loc, found)
+	}
+
+	if s.scheme != nil {
+		scheme := request.URL.Scheme
+		if scheme == "" {
+			scheme = "http"
+			if request.TLS != nil {
+				scheme = "https"
+			}
+		}
+		if loc := s.scheme.find(scheme, true); loc != nil {
+			found = s.scheme.capture(scheme, loc, found)
+		} else {
+			sm.HostMatched = false
+		}
+	}
+	if s.host != nil {
+		host := request.URL.Host
+		if host == "" {
+			host = request.Host
+		}
+		if loc := s.host.find(host, true); loc != nil && sm.HostMatched {
+			found = s.host.capture(host, loc, found)
+		} else {
+			sm.HostMatched = false
+		}
+	}
+
+	if s.Server.Variables != nil && s.Server.Variables.Len() > 0 {
+		sm.Variables = make(map[string]string, s.Server.Variables.Len())
+		for pair := orderedmap.First(s.Server.Variables); pair != nil; pair = pair.Next() {
+			sm.Variables[pair.Key()] = pair.Value().Default
+		}
+	}
+	for k, v := range found {
+		if sm.Variables == nil {
+			sm.Variables = make(map[string]string)
+		}
+		sm.Variables[k] = v
+	}
+	return sm
+}
+
+// find returns the location of the template in the value, in the same form as regexp.FindStringSubmatchIndex.
+// When whole is true, the template must match the entire value, ignoring case.
+func (u *urlTemplate) find(value string, whole bool) []int {
+	if u.rx != nil {
+		loc := u.rx.FindStringSubmatchIndex(value)
+		// the slash that ends a base path is not part of the match.
+		if loc != nil && !whole && loc[1] > loc[0] && value[loc[1]-1] == '/' {
+			loc[1]--
+		}
+		return loc
+	}
+	if whole {
+		if strings.EqualFold(value, u.literal) {
+			return []int{0, len(value)}
+		}
+		return nil
+	}
+	if strings.HasPrefix(value, u.literal) {
+		return []int{0, len(u.literal)}
+	}
+	return nil
+}
+
+func (u *urlTemplate) capture(value string, loc []int, found map[string]string) map[string]string {
+	for i, name := range u.vars {
+		if found == nil {
+			found = make(map[string]string)
+		}
+		found[name] = value[loc[2+i*2]:loc[3+i*2]]
+	}
+	return found
+}
+
+// StripPath removes the base path of the server from the path of a request.
+func (m *ServerMatch) StripPath(path string) string {
+	return strings.TrimPrefix(path, m.BasePath)
+}
+
+// CompileServers compiles every server defined in the document, including the servers defined on paths and
+// operations. The document servers come first, followed by the path and operation servers in document order.
+func CompileServers(document *v3.Document) []*ServerTemplate {
+	if document == nil {
+		return nil
+	}
+	var servers []*ServerTemplate
+	seen := make(map[*v3.Server]bool)
+	add := func(list []*v3.Server) {
+		for _, server := range list {
+			if server == nil || seen[server] {
+				continue
+			}
+			seen[server] = true
+			st := NewServerTemplate(server)
+			st.order = len(servers)
+			servers = append(servers, st)
+		}
+	}
+	add(document.Servers)
+	if document.Paths != nil {
+		for pair := orderedmap.First(document.Paths.PathItems); pair != nil; pair = pair.Next() {
+			pathItem := pair.Value()
+			add(pathItem.Servers)
+			for _, op := range operations(pathItem) {
+				add(op.Servers)
+			}
+		}
+	}
+	return servers
+}
+
+// MatchServers matches every server against the request, and returns the servers that match, best match first.
+// Servers with a matching scheme and host rank above those without, then servers with a longer base path rank
+// above those with a shorter one.
+func MatchServers(servers []*ServerTemplate, request *http.Request) []*ServerMatch {
+	var matches []*ServerMatch
+	for _, s := range servers {
+		if sm := s.Match(request); sm != nil {
+			matches = append(matches, sm)
+		}
+	}
+	if len(matches) < 2 {
+		return matches
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].HostMatched != matches[j].HostMatched {
+			return matches[i].HostMatched
+		}
+		if len(matches[i].BasePath) != len(matches[j].BasePath) {
+			return len(matches[i].BasePath) > len(matches[j].BasePath)
+		}
+		return matches[i].order < matches[j].order
+	})
+	return matches
+}
+
+// EffectiveServers returns the servers that serve an operation. Servers defined on the operation override those
+// defined on the path, which override those defined on the document.
+func EffectiveServers(document *v3.Document, pathItem *v3.PathItem, method string) []*v3.Server {
+	if pathItem != nil {
+		if op := operation(pathItem, method); op != nil && len(op.Servers) > 0 {
+			return op.Servers
+		}
+		if len(pathItem.Servers) > 0 {
+			return pathItem.Servers
+		}
+	}
+	if document != nil {
+		return document.Servers
+	}
+	return nil
+}
+
+func operation(pathItem *v3.PathItem, method string) *v3.Operation {
+	switch strings.ToUpper(method) {
+	case http.MethodGet:
+		return pathItem.Get
+	case http.MethodPost:
+		return pathItem.Post
+	case http.MethodPut:
+		return pathItem.Put
+	case http.MethodDelete:
+		return pathItem.Delete
+	case http.MethodOptions:
+		return pathItem.Options
+	case http.MethodHead:
+		return pathItem.Head
+	case http.MethodPatch:
+		return pathItem.Patch
+	case http.MethodTrace:
+		return pathItem.Trace
+	}
+	return nil
+}
+
+func operations(pathItem *v3.PathItem) []*v3.Operation {
+	var ops []*v3.Operation
+	for _, op := range []*v3.Operation{
+		pathItem.Get, pathItem.Post, pathItem.Put, pathItem.Delete,
+		pathItem.Options, pathItem.Head, pathItem.Patch, pathItem.Trace,
+	} {
+		if op != nil {
+			ops = append(ops, op)
+		}
+	}
+	return ops
+}
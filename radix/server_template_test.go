@@ -0,0 +1,191 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package radix
+
+import (
+	"crypto/tls"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerTemplate_Match_Variables(t *testing.T) {
+	spec := `openapi: 3.1.0
+servers:
+  - url: 'https://{region}.api.pb33f.io/{version}'
+    variables:
+      region:
+        default: us
+        enum: [us, eu]
+      version:
+        default: v1
+paths:
+  /burgers:
+    get:
+      operationId: listBurgers
+`
+	doc := buildModel(t, spec)
+	servers := CompileServers(doc)
+	require.Len(t, servers, 1)
+
+	request, _ := http.NewRequest(http.MethodGet, "https://eu.api.pb33f.io/v2/burgers", nil)
+	sm := servers[0].Match(request)
+	require.NotNil(t, sm)
+	assert.True(t, sm.HostMatched)
+	assert.Equal(t, "/v2", sm.BasePath)
+	assert.Equal(t, map[string]string{"region": "eu", "version": "v2"}, sm.Variables)
+	assert.Equal(t, "/burgers", sm.StripPath(request.URL.Path))
+
+	// 'ap' is not in the enum, so the host does not match and the default is used.
+	request, _ = http.NewRequest(http.MethodGet, "https://ap.api.pb33f.io/v2/burgers", nil)
+	sm = servers[0].Match(request)
+	require.NotNil(t, sm)
+	assert.False(t, sm.HostMatched)
+	assert.Equal(t, map[string]string{"region": "us", "version": "v2"}, sm.Variables)
+
+	// the scheme does not match.
+	request, _ = http.NewRequest(http.MethodGet, "http://eu.api.pb33f.io/v2/burgers", nil)
+	sm = servers[0].Match(request)
+	require.NotNil(t, sm)
+	assert.False(t, sm.HostMatched)
+}
+
+func TestServerTemplate_Match_BasePath(t *testing.T) {
+	spec := `openapi: 3.1.0
+servers:
+  - url: 'https://api.pb33f.io/api/v3/'
+`
+	servers := CompileServers(buildModel(t, spec))
+	require.Len(t, servers, 1)
+
+	request, _ := http.NewRequest(http.MethodGet, "https://api.pb33f.io/api/v3/pets", nil)
+	sm := servers[0].Match(request)
+	require.NotNil(t, sm)
+	assert.Equal(t, "/api/v3", sm.BasePath)
+	assert.Nil(t, sm.Variables)
+
+	request, _ = http.NewRequest(http.MethodGet, "https://api.pb33f.io/api/v3", nil)
+	assert.NotNil(t, servers[0].Match(request))
+
+	// the base path must end on a segment boundary.
+	request, _ = http.NewRequest(http.MethodGet, "https://api.pb33f.io/api/v3pets", nil)
+	assert.Nil(t, servers[0].Match(request))
+
+	request, _ = http.NewRequest(http.MethodGet, "https://api.pb33f.io/pets", nil)
+	assert.Nil(t, servers[0].Match(request))
+}
+
+func TestServerTemplate_Match_SchemeFromRequest(t *testing.T) {
+	spec := `openapi: 3.1.0
+servers:
+  - url: '{scheme}://api.pb33f.io'
+    variables:
+      scheme:
+        default: https
+        enum: [https]
+`
+	servers := CompileServers(buildModel(t, spec))
+
+	// server side requests have no scheme in the URL, TLS decides the scheme.
+	request, _ := http.NewRequest(http.MethodGet, "/pets", nil)
+	request.Host = "api.pb33f.io"
+	sm := servers[0].Match(request)
+	require.NotNil(t, sm)
+	assert.False(t, sm.HostMatched)
+
+	request.TLS = &tls.ConnectionState{}
+	sm = servers[0].Match(request)
+	require.NotNil(t, sm)
+	assert.True(t, sm.HostMatched)
+	assert.Equal(t, "", sm.BasePath)
+}
+
+func TestMatchServers_Ranking(t *testing.T) {
+	spec := `openapi: 3.1.0
+servers:
+  - url: 'https://other.pb33f.io/api/v3'
+  - url: 'https://api.pb33f.io/'
+  - url: 'https://api.pb33f.io/api'
+  - url: '/api/v3'
+`
+	servers := CompileServers(buildModel(t, spec))
+
+	request, _ := http.NewRequest(http.MethodGet, "https://api.pb33f.io/api/v3/pets", nil)
+	matches := MatchServers(servers, request)
+	require.Len(t, matches, 4)
+	assert.Equal(t, "/api/v3", matches[0].Server.URL)
+	assert.Equal(t, "https://api.pb33f.io/api", matches[1].Server.URL)
+	assert.Equal(t, "https://api.pb33f.io/", matches[2].Server.URL)
+	assert.Equal(t, "https://other.pb33f.io/api/v3", matches[3].Server.URL)
+	assert.False(t, matches[3].HostMatched)
+}
+
+func TestCompileServers_Overrides(t *testing.T) {
+	spec := `openapi: 3.1.0
+servers:
+  - url: 'https://api.pb33f.io/v1'
+paths:
+  /burgers:
+    servers:
+      - url: 'https://api.pb33f.io/v2'
+    get:
+      servers:
+        - url: 'https://api.pb33f.io/v3'
+    post:
+      operationId: createBurger
+`
+	doc := buildModel(t, spec)
+	servers := CompileServers(doc)
+	require.Len(t, servers, 3)
+	assert.Equal(t, "https://api.pb33f.io/v1", servers[0].Server.URL)
+	assert.Equal(t, "https://api.pb33f.io/v2", servers[1].Server.URL)
+	assert.Equal(t, "https://api.pb33f.io/v3", servers[2].Server.URL)
+
+	pathItem := doc.Paths.PathItems.GetOrZero("/burgers")
+	assert.Equal(t, "https://api.pb33f.io/v3", EffectiveServers(doc, pathItem, http.MethodGet)[0].URL)
+	assert.Equal(t, "https://api.pb33f.io/v2", EffectiveServers(doc, pathItem, http.MethodPost)[0].URL)
+	assert.Equal(t, "https://api.pb33f.io/v1", EffectiveServers(doc, nil, http.MethodPost)[0].URL)
+	assert.Nil(t, CompileServers(nil))
+}
+
+func TestServerTemplate_Match_PrefixEnumValues(t *testing.T) {
+	spec := `openapi: 3.1.0
+servers:
+  - url: 'https://api.example.com/{version}'
+    variables:
+      version:
+        default: v1
+        enum: [v1, v10]
+paths:
+  /pets:
+    get:
+      operationId: listPets
+`
+	servers := CompileServers(buildModel(t, spec))
+	require.Len(t, servers, 1)
+
+	request, _ := http.NewRequest(http.MethodGet, "https://api.example.com/v10/pets", nil)
+	sm := servers[0].Match(request)
+	require.NotNil(t, sm)
+	assert.Equal(t, "/v10", sm.BasePath)
+	assert.Equal(t, map[string]string{"version": "v10"}, sm.Variables)
+	assert.Equal(t, "/pets", sm.StripPath(request.URL.Path))
+
+	request, _ = http.NewRequest(http.MethodGet, "https://api.example.com/v1/pets", nil)
+	sm = servers[0].Match(request)
+	require.NotNil(t, sm)
+	assert.Equal(t, "/v1", sm.BasePath)
+	assert.Equal(t, map[string]string{"version": "v1"}, sm.Variables)
+
+	request, _ = http.NewRequest(http.MethodGet, "https://api.example.com/v10", nil)
+	sm = servers[0].Match(request)
+	require.NotNil(t, sm)
+	assert.Equal(t, "/v10", sm.BasePath)
+
+	// neither value ends on a segment boundary.
+	request, _ = http.NewRequest(http.MethodGet, "https://api.example.com/v100/pets", nil)
+	assert.Nil(t, servers[0].Match(request))
+}
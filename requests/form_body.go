@@ -0,0 +1,229 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package requests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	"github.com/pb33f/libopenapi/orderedmap"
+
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+
+	"github.com/pb33f/libopenapi-validator/helpers"
+)
+
+// DecodeFormBody decodes an 'application/x-www-form-urlencoded' request body into an object, so it can be
+// validated against the schema of the media type. Each property of the schema is decoded using its entry in the
+// encoding map of the media type:
+//
+//	style 'form' (the default)        - arrays are repeated keys when exploded (the default), or comma separated
+//	style 'spaceDelimited'            - arrays are space separated
+//	style 'pipeDelimited'             - arrays are pipe separated
+//	style 'deepObject'                - objects are sent as 'property[key]=value'
+//	contentType 'application/json'    - the value is decoded as JSON
+//
+// Values are converted to the types defined by the schema. Keys that are not defined by the schema are kept as
+// strings, so 'additionalProperties' can be checked.
+func DecodeFormBody(body []byte, schema *base.Schema, encoding *orderedmap.Map[string, *v3.Encoding]) (map[string]any, error) {
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, err
+	}
+	return decodeFormValues(values, schema, encoding)
+}
+
+// DecodeFormBodyCharset decodes a form body in the same way as DecodeFormBody, and transcodes each name and value
+// from the charset of the request to UTF-8 once it has been unescaped, as escaped bytes such as '%E9' are in that
+// charset. A form without a charset must be UTF-8, see helpers.DecodeCharset for the errors that are returned.
+func DecodeFormBodyCharset(body []byte, charset string, schema *base.Schema,
+	encoding *orderedmap.Map[string, *v3.Encoding],
+) (map[string]any, error) {
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, err
+	}
+	transcoded := make(url.Values, len(values))
+	for key, vals := range values {
+		name, err := helpers.DecodeCharset([]byte(key), charset)
+		if err != nil {
+			return nil, fmt.Errorf("the name of the form field '%s' cannot be decoded: %w", key, err)
+		}
+		for _, val := range vals {
+			value, err := helpers.DecodeCharset([]byte(val), charset)
+			if err != nil {
+				return nil, fmt.Errorf("the value of the form field '%s' cannot be decoded: %w", name, err)
+			}
+			transcoded[string(name)] = append(transcoded[string(name)], string(value))
+		}
+	}
+	return decodeFormValues(transcoded, schema, encoding)
+}
+
+func decodeFormValues(values url.Values, schema *base.Schema,
+	encoding *orderedmap.Map[string, *v3.Encoding],
+) (map[string]any, error) {
+	decoded := make(map[string]any)
+	consumed := make(map[string]bool)
+
+	if schema != nil {
+		for pair := orderedmap.First(schema.Properties); pair != nil; pair = pair.Next() {
+			name := pair.Key()
+			var propSchema *base.Schema
+			if pair.Value() != nil {
+				propSchema = pair.Value().Schema()
+			}
+			var enc *v3.Encoding
+			if encoding != nil {
+				enc = encoding.GetOrZero(name)
+			}
+			value, found, err := decodeFormProperty(name, values, propSchema, enc, consumed)
+			if err != nil {
+				return nil, err
+			}
+			if found {
+				decoded[name] = value
+			}
+		}
+	}
+
+	// anything left over is not defined by the schema.
+	for key, vals := range values {
+		if consumed[key] {
+			continue
+		}
+		if len(vals) == 1 {
+			decoded[key] = vals[0]
+			continue
+		}
+		items := make([]any, len(vals))
+		for i := range vals {
+			items[i] = vals[i]
+		}
+		decoded[key] = items
+	}
+	return decoded, nil
+}
+
+func decodeFormProperty(
+	name string,
+	values url.Values,
+	schema *base.Schema,
+	enc *v3.Encoding,
+	consumed map[string]bool,
+) (any, bool, error) {
+	style := helpers.Form
+	contentType := ""
+	if enc != nil {
+		if enc.Style != "" {
+			style = enc.Style
+		}
+		contentType = enc.ContentType
+	}
+	// form style is exploded by default, every other style is not.
+	explode := style == helpers.Form
+	if enc != nil && enc.Explode != nil {
+		explode = *enc.Explode
+	}
+
+	isArray := schema != nil && slices.Contains(schema.Type, helpers.Array)
+	isObject := schema != nil && slices.Contains(schema.Type, helpers.Object)
+
+	// objects spread across several keys.
+	if isObject && contentType == "" {
+		switch {
+		case style == helpers.DeepObject:
+			obj := make(map[string]any)
+			prefix := name + "["
+			for key, vals := range values {
+				if strings.HasPrefix(key, prefix) && strings.HasSuffix(key, "]") {
+					prop := key[len(prefix) : len(key)-1]
+					obj[prop] = helpers.CastToSchemaType(vals[0], formPropertySchema(schema, prop))
+					consumed[key] = true
+				}
+			}
+			return obj, len(obj) > 0, nil
+
+		case style == helpers.Form && explode:
+			obj := make(map[string]any)
+			for prop := orderedmap.First(schema.Properties); prop != nil; prop = prop.Next() {
+				if vals, ok := values[prop.Key()]; ok {
+					obj[prop.Key()] = helpers.CastToSchemaType(vals[0], formPropertySchema(schema, prop.Key()))
+					consumed[prop.Key()] = true
+				}
+			}
+			return obj, len(obj) > 0, nil
+		}
+	}
+
+	vals, ok := values[name]
+	if !ok {
+		return nil, false, nil
+	}
+	consumed[name] = true
+
+	if contentType != "" {
+		return decodeFormContent(name, vals[0], contentType)
+	}
+
+	switch {
+	case isArray:
+		var items *base.Schema
+		if schema.Items != nil && schema.Items.IsA() {
+			items = schema.Items.A.Schema()
+		}
+		var raw []string
+		if style == helpers.Form && explode {
+			raw = vals
+		} else {
+			for _, v := range vals {
+				raw = append(raw, helpers.ExplodeQueryValue(v, style)...)
+			}
+		}
+		decoded := make([]any, len(raw))
+		for i := range raw {
+			decoded[i] = helpers.CastToSchemaType(raw[i], items)
+		}
+		return decoded, true, nil
+
+	case isObject:
+		parts := helpers.ExplodeQueryValue(vals[0], style)
+		if len(parts)%2 != 0 {
+			return nil, false, fmt.Errorf("the form property '%s' must be a list of keys and values", name)
+		}
+		obj := make(map[string]any)
+		for i := 0; i < len(parts); i += 2 {
+			obj[parts[i]] = helpers.CastToSchemaType(parts[i+1], formPropertySchema(schema, parts[i]))
+		}
+		return obj, true, nil
+	}
+	return helpers.CastToSchemaType(vals[0], schema), true, nil
+}
+
+// decodeFormContent decodes a form value that has its own content type. JSON values are decoded, any other
+// content type is kept as a string.
+func decodeFormContent(name, value, contentType string) (any, bool, error) {
+	if !helpers.IsJSONContentType(contentType) {
+		return value, true, nil
+	}
+	var decoded any
+	if err := json.Unmarshal([]byte(value), &decoded); err != nil {
+		return nil, false, fmt.Errorf("the form property '%s' is not valid '%s': %s", name, contentType, err.Error())
+	}
+	return decoded, true, nil
+}
+
+func formPropertySchema(schema *base.Schema, name string) *base.Schema {
+	if schema == nil || schema.Properties == nil {
+		return nil
+	}
+	if p := schema.Properties.GetOrZero(name); p != nil {
+		return p.Schema()
+	}
+	return nil
+}
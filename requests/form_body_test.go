@@ -0,0 +1,224 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package requests
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/pb33f/libopenapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pb33f/libopenapi-validator/helpers"
+)
+
+const formSpec = `openapi: 3.1.0
+paths:
+  /burgers/createBurger:
+    post:
+      requestBody:
+        content:
+          application/x-www-form-urlencoded:
+            schema:
+              type: object
+              required: [name, patties]
+              additionalProperties: false
+              properties:
+                name:
+                  type: string
+                patties:
+                  type: integer
+                  maximum: 3
+                vegetarian:
+                  type: boolean
+                zip:
+                  type: string
+                toppings:
+                  type: array
+                  items:
+                    type: string
+                sauces:
+                  type: array
+                  items:
+                    type: string
+                sides:
+                  type: array
+                  items:
+                    type: integer
+                drink:
+                  type: object
+                  properties:
+                    size:
+                      type: string
+                    ice:
+                      type: boolean
+                meta:
+                  type: object
+                  required: [source]
+            encoding:
+              sauces:
+                style: form
+                explode: false
+              sides:
+                style: pipeDelimited
+              drink:
+                style: deepObject
+              meta:
+                contentType: application/json`
+
+func formRequest(body string) *http.Request {
+	request, _ := http.NewRequest(http.MethodPost, "https://things.com/burgers/createBurger",
+		strings.NewReader(body))
+	request.Header.Set(helpers.ContentTypeHeader, helpers.FormURLEncodedContentType)
+	return request
+}
+
+func TestValidateBody_FormEncoded_Valid(t *testing.T) {
+	doc, _ := libopenapi.NewDocument([]byte(formSpec))
+	m, _ := doc.BuildV3Model()
+	v := NewRequestBodyValidator(&m.Model)
+
+	body := "name=Big+Mac&patties=2&vegetarian=false&zip=01234&toppings=cheese&toppings=pickles" +
+		"&sauces=ketchup,mustard&sides=1|2&drink[size]=large&drink[ice]=true&meta=%7B%22source%22%3A%22app%22%7D"
+	request := formRequest(body)
+	valid, errs := v.ValidateRequestBody(request)
+	assert.True(t, valid)
+	assert.Empty(t, errs)
+
+	// the body can be read again.
+	b, _ := io.ReadAll(request.Body)
+	assert.Equal(t, body, string(b))
+}
+
+func TestValidateBody_FormEncoded_Invalid(t *testing.T) {
+	doc, _ := libopenapi.NewDocument([]byte(formSpec))
+	m, _ := doc.BuildV3Model()
+	v := NewRequestBodyValidator(&m.Model)
+
+	request := formRequest("name=Big+Mac&patties=4&vegetarian=maybe&sides=1|two&drink[ice]=lots&meta=%7B%7D&extra=1")
+	valid, errs := v.ValidateRequestBody(request)
+	assert.False(t, valid)
+	require.Len(t, errs, 1)
+	assert.Equal(t, helpers.RequestBodyValidation, errs[0].ValidationType)
+	assert.Equal(t, "/burgers/createBurger", errs[0].SpecPath)
+
+	var locations []string
+	for _, e := range errs[0].SchemaValidationErrors {
+		locations = append(locations, e.Location)
+	}
+	assert.ElementsMatch(t, []string{
+		"/additionalProperties",
+		"/properties/patties/maximum",
+		"/properties/vegetarian/type",
+		"/properties/sides/items/type",
+		"/properties/drink/properties/ice/type",
+		"/properties/meta/required",
+	}, locations)
+}
+
+func TestValidateBody_FormEncoded_Required(t *testing.T) {
+	doc, _ := libopenapi.NewDocument([]byte(formSpec))
+	m, _ := doc.BuildV3Model()
+	v := NewRequestBodyValidator(&m.Model)
+
+	valid, errs := v.ValidateRequestBody(formRequest(""))
+	assert.False(t, valid)
+	require.Len(t, errs, 1)
+	require.Len(t, errs[0].SchemaValidationErrors, 1)
+	assert.Equal(t, "missing properties 'name', 'patties'", errs[0].SchemaValidationErrors[0].Reason)
+}
+
+func TestValidateBody_FormEncoded_CannotBeDecoded(t *testing.T) {
+	doc, _ := libopenapi.NewDocument([]byte(formSpec))
+	m, _ := doc.BuildV3Model()
+	v := NewRequestBodyValidator(&m.Model)
+
+	valid, errs := v.ValidateRequestBody(formRequest("name=Big+Mac&patties=2&meta=%7B"))
+	assert.False(t, valid)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "POST request body for '/burgers/createBurger' cannot be decoded as "+
+		"'application/x-www-form-urlencoded'", errs[0].Message)
+	assert.Equal(t, "The request body cannot be decoded: the form property 'meta' is not valid "+
+		"'application/json': unexpected end of JSON input", errs[0].Reason)
+
+	valid, errs = v.ValidateRequestBody(formRequest("name=%zz"))
+	assert.False(t, valid)
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Reason, "invalid URL escape")
+}
+
+func TestDecodeFormBody_Styles(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /burgers:
+    post:
+      requestBody:
+        content:
+          application/x-www-form-urlencoded:
+            schema:
+              type: object
+              properties:
+                toppings:
+                  type: array
+                  items:
+                    type: string
+                drink:
+                  type: object
+                  properties:
+                    size:
+                      type: string
+                    cans:
+                      type: integer
+                sauce:
+                  type: object
+            encoding:
+              toppings:
+                style: spaceDelimited
+              drink:
+                style: form
+                explode: false`
+
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+	m, _ := doc.BuildV3Model()
+	mediaType := m.Model.Paths.PathItems.GetOrZero("/burgers").Post.RequestBody.Content.
+		GetOrZero(helpers.FormURLEncodedContentType)
+	schema := mediaType.Schema.Schema()
+
+	decoded, err := DecodeFormBody([]byte("toppings=cheese+pickles&drink=size,large,cans,2&other=a&other=b"),
+		schema, mediaType.Encoding)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"toppings": []any{"cheese", "pickles"},
+		"drink":    map[string]any{"size": "large", "cans": float64(2)},
+		"other":    []any{"a", "b"},
+	}, decoded)
+
+	_, err = DecodeFormBody([]byte("drink=size,large,cans"), schema, mediaType.Encoding)
+	assert.EqualError(t, err, "the form property 'drink' must be a list of keys and values")
+
+	// exploded form objects are spread across keys.
+	decoded, err = DecodeFormBody([]byte("size=large"), schema, nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"drink": map[string]any{"size": "large"}}, decoded)
+}
+
+func TestDecodeFormBodyCharset(t *testing.T) {
+	decoded, err := DecodeFormBodyCharset([]byte("name=Caf%E9&caf%E9=open"), "iso-8859-1", nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"name": "Café", "café": "open"}, decoded)
+
+	decoded, err = DecodeFormBodyCharset([]byte("name=Caf%C3%A9"), "", nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"name": "Café"}, decoded)
+
+	_, err = DecodeFormBodyCharset([]byte("name=Caf%E9"), "", nil, nil)
+	assert.EqualError(t, err, "the value of the form field 'name' cannot be decoded: "+
+		"the body is not valid UTF-8, the byte at offset 3 is invalid")
+
+	_, err = DecodeFormBodyCharset([]byte("name=Big+Mac"), "x-burger", nil, nil)
+	assert.ErrorContains(t, err, "the charset 'x-burger' is not supported")
+}
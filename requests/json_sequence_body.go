@@ -0,0 +1,121 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package requests
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+
+	"github.com/pb33f/libopenapi-validator/errors"
+	"github.com/pb33f/libopenapi-validator/helpers"
+)
+
+// validateJSONSequenceBody validates a newline delimited JSON, JSON Lines or JSON text sequence request body one
+// record at a time. When the schema is an array, each record is validated against the items schema, otherwise each
+// record is validated against the schema itself. Records are streamed from the body, one at a time. The bytes that
+// are read are kept, and the body is restored once it has been validated, unless config.WithStreamingBodies is
+// used, in which case the body is never held in memory, and is consumed by validation.
+func (v *requestBodyValidator) validateJSONSequenceBody(
+	ctx context.Context,
+	request *http.Request,
+	cached *schemaCache,
+	contentType string,
+	pathValue string,
+) (bool, []*errors.ValidationError) {
+	if request.Body == nil || request.Body == http.NoBody {
+		return true, nil
+	}
+
+	// unless bodies are streamed, the bytes that are read are kept, so the body can be read again once validated.
+	source := io.Reader(request.Body)
+	if v.options.StreamBodies {
+		defer func() {
+			_ = request.Body.Close()
+			request.Body = http.NoBody
+		}()
+	} else {
+		var read bytes.Buffer
+		source = io.TeeReader(request.Body, &read)
+		defer func() {
+			request.Body = helpers.ReplayBody(read.Bytes(), request.Body)
+		}()
+	}
+
+	// each record is an item of the array the stream represents.
+	if schema := cached.schema; slices.Contains(schema.Type, helpers.Array) && schema.Items != nil && schema.Items.IsA() {
+		cached = v.renderSchema(schema.Items.A)
+	}
+	schema, renderedInline := cached.schema, cached.renderedInline
+
+	// the schema is compiled once for the whole stream.
+	jsch, err := cached.compile(ctx, v.options)
+	if err != nil {
+		return false, []*errors.ValidationError{{
+			ValidationType:    helpers.RequestBodyValidation,
+			ValidationSubType: helpers.Schema,
+			Message:           err.Error(),
+			Reason:            "Failed to compile the request body schema.",
+			Context:           string(cached.renderedJSON),
+		}}
+	}
+
+	var validationErrors []*errors.ValidationError
+	// the maximum body size and the content length apply, even when the body is not held in memory.
+	body := helpers.NewLimitedReader(helpers.NewContextReader(ctx, source), v.options.MaxBodySize)
+	content, err := helpers.NewContentReader(ctx, body, request.Header.Get(helpers.ContentEncodingHeader), v.options)
+	if err != nil {
+		validationErrors = append(validationErrors, requestContentEncodingError(request, err, pathValue))
+		errors.PopulateValidationErrors(validationErrors, request, pathValue)
+		return false, validationErrors
+	}
+	_, charset, _ := helpers.ExtractContentType(request.Header.Get(helpers.ContentTypeHeader))
+	if content, err = helpers.NewCharsetReader(content, charset); err != nil {
+		validationErrors = append(validationErrors,
+			errors.RequestBodyCannotBeDecoded(request, contentType, err.Error(), pathValue))
+		errors.PopulateValidationErrors(validationErrors, request, pathValue)
+		return false, validationErrors
+	}
+	records := helpers.NewJSONSequenceReader(content, contentType, v.options.MaxRecordSize)
+	for {
+		decoded, record, readErr := records.Next()
+		if readErr == io.EOF {
+			if mismatch, ok := body.CheckContentLength(request.ContentLength).(*helpers.ContentLengthError); ok {
+				validationErrors = append(validationErrors,
+					errors.RequestContentLengthMismatch(request, mismatch, pathValue))
+			}
+			break
+		}
+		index := records.Index()
+		if tooLarge, ok := readErr.(*helpers.BodyTooLargeError); ok {
+			validationErrors = append(validationErrors, errors.RequestBodyTooLarge(request, tooLarge, pathValue))
+			break
+		}
+		if readErr != nil {
+			decodeErr := errors.RequestBodyCannotBeDecoded(request, contentType, readErr.Error(), pathValue)
+			if _, skipped := readErr.(*helpers.RecordTooLargeError); record == nil && !skipped {
+				// the body itself could not be read, there is nothing more to validate.
+				validationErrors = append(validationErrors, decodeErr)
+				break
+			}
+			decodeErr.RecordIndex = &index
+			validationErrors = append(validationErrors, decodeErr)
+			continue
+		}
+
+		_, recordErrors := validateRequestInstance(request, schema, renderedInline, jsch, decoded, record, nil, nil)
+		for _, recordErr := range recordErrors {
+			recordErr.Message = fmt.Sprintf("%s request body record %d for '%s' failed to validate schema",
+				request.Method, index, request.URL.Path)
+			recordErr.RecordIndex = &index
+		}
+		validationErrors = append(validationErrors, recordErrors...)
+	}
+
+	errors.PopulateValidationErrors(validationErrors, request, pathValue)
+	return len(validationErrors) == 0, validationErrors
+}
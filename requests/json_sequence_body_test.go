@@ -0,0 +1,201 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package requests
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/pb33f/libopenapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pb33f/libopenapi-validator/config"
+	"github.com/pb33f/libopenapi-validator/helpers"
+)
+
+const jsonSequenceSpec = `openapi: 3.1.0
+paths:
+  /burgers/import:
+    post:
+      requestBody:
+        content:
+          application/x-ndjson:
+            schema:
+              type: array
+              items:
+                type: object
+                required: [name]
+                properties:
+                  name:
+                    type: string
+                  patties:
+                    type: integer
+                    maximum: 3
+  /burgers/events:
+    post:
+      requestBody:
+        content:
+          application/json-seq:
+            schema:
+              type: object
+              required: [event]`
+
+func jsonSequenceValidator(t *testing.T) RequestBodyValidator {
+	doc, err := libopenapi.NewDocument([]byte(jsonSequenceSpec))
+	require.NoError(t, err)
+	m, errs := doc.BuildV3Model()
+	require.Empty(t, errs)
+	return NewRequestBodyValidator(&m.Model)
+}
+
+func TestValidateBody_JSONSequence(t *testing.T) {
+	v := jsonSequenceValidator(t)
+
+	body := `{"name": "Big Mac", "patties": 2}
+{"name": "Quarter Pounder"}
+`
+	request, _ := http.NewRequest(http.MethodPost, "https://things.com/burgers/import", strings.NewReader(body))
+	request.Header.Set(helpers.ContentTypeHeader, "application/x-ndjson")
+
+	valid, errs := v.ValidateRequestBody(request)
+	assert.True(t, valid)
+	assert.Len(t, errs, 0)
+
+	// the body is restored, so it can be read again.
+	restored, _ := io.ReadAll(request.Body)
+	assert.Equal(t, body, string(restored))
+}
+
+func TestValidateBody_JSONSequence_StreamBodies(t *testing.T) {
+	doc, _ := libopenapi.NewDocument([]byte(jsonSequenceSpec))
+	m, _ := doc.BuildV3Model()
+	v := NewRequestBodyValidator(&m.Model, config.WithStreamingBodies())
+
+	request, _ := http.NewRequest(http.MethodPost, "https://things.com/burgers/import",
+		strings.NewReader("{\"name\": \"Big Mac\"}\n"))
+	request.Header.Set(helpers.ContentTypeHeader, "application/x-ndjson")
+
+	valid, errs := v.ValidateRequestBody(request)
+	assert.True(t, valid)
+	assert.Len(t, errs, 0)
+
+	// the body is streamed through validation, it is not restored.
+	assert.Equal(t, http.NoBody, request.Body)
+}
+
+func TestValidateBody_JSONSequence_RecordTooLarge(t *testing.T) {
+	doc, _ := libopenapi.NewDocument([]byte(jsonSequenceSpec))
+	m, _ := doc.BuildV3Model()
+	v := NewRequestBodyValidator(&m.Model, config.WithMaxRecordSize(32))
+
+	body := "{\"name\": \"Big Mac\"}\n{\"name\": \"" + strings.Repeat("x", 64) + "\"}\n{\"patties\": 2}\n"
+	request, _ := http.NewRequest(http.MethodPost, "https://things.com/burgers/import", strings.NewReader(body))
+	request.Header.Set(helpers.ContentTypeHeader, "application/x-ndjson")
+
+	// the record that is too large is skipped, the records that follow it are still validated.
+	valid, errs := v.ValidateRequestBody(request)
+	assert.False(t, valid)
+	require.Len(t, errs, 2)
+	require.NotNil(t, errs[0].RecordIndex)
+	assert.Equal(t, 1, *errs[0].RecordIndex)
+	assert.Contains(t, errs[0].Reason, "record 1 is larger than the limit of 32 bytes")
+	require.NotNil(t, errs[1].RecordIndex)
+	assert.Equal(t, 2, *errs[1].RecordIndex)
+	assert.Equal(t, "missing property 'name'", errs[1].SchemaValidationErrors[0].Reason)
+}
+
+func TestValidateBody_JSONSequence_ContentEncoding(t *testing.T) {
+	v := jsonSequenceValidator(t)
+
+	var body bytes.Buffer
+	w := gzip.NewWriter(&body)
+	_, _ = w.Write([]byte("{\"name\": \"Big Mac\"}\n{\"patties\": 2}\n"))
+	_ = w.Close()
+
+	// the records are decompressed as they are streamed.
+	request, _ := http.NewRequest(http.MethodPost, "https://things.com/burgers/import", &body)
+	request.Header.Set(helpers.ContentTypeHeader, "application/x-ndjson")
+	request.Header.Set(helpers.ContentEncodingHeader, "gzip")
+
+	valid, errs := v.ValidateRequestBody(request)
+	assert.False(t, valid)
+	require.Len(t, errs, 1)
+	require.NotNil(t, errs[0].RecordIndex)
+	assert.Equal(t, 1, *errs[0].RecordIndex)
+	assert.Equal(t, "missing property 'name'", errs[0].SchemaValidationErrors[0].Reason)
+}
+
+func TestValidateBody_JSONSequence_Charset(t *testing.T) {
+	v := jsonSequenceValidator(t)
+
+	request, _ := http.NewRequest(http.MethodPost, "https://things.com/burgers/import",
+		strings.NewReader("{\"name\": \"Big Mac\"}\n{\"name\": \"Caf\xe9\"}\n"))
+	request.Header.Set(helpers.ContentTypeHeader, "application/x-ndjson")
+
+	valid, errs := v.ValidateRequestBody(request)
+	assert.False(t, valid)
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Reason, "the byte at offset 33 is invalid")
+
+	request, _ = http.NewRequest(http.MethodPost, "https://things.com/burgers/import",
+		strings.NewReader("{\"name\": \"Big Mac\"}\n{\"name\": \"Caf\xe9\"}\n"))
+	request.Header.Set(helpers.ContentTypeHeader, "application/x-ndjson; charset=iso-8859-1")
+
+	valid, errs = v.ValidateRequestBody(request)
+	assert.True(t, valid)
+	assert.Len(t, errs, 0)
+}
+
+func TestValidateBody_JSONSequence_Invalid(t *testing.T) {
+	v := jsonSequenceValidator(t)
+
+	body := `{"name": "Big Mac", "patties": 2}
+{"patties": 2}
+{"name": "Monster", "patties": 5}
+{"name": broken}
+`
+	request, _ := http.NewRequest(http.MethodPost, "https://things.com/burgers/import", strings.NewReader(body))
+	request.Header.Set(helpers.ContentTypeHeader, "application/x-ndjson")
+
+	valid, errs := v.ValidateRequestBody(request)
+	assert.False(t, valid)
+	require.Len(t, errs, 3)
+
+	require.NotNil(t, errs[0].RecordIndex)
+	assert.Equal(t, 1, *errs[0].RecordIndex)
+	assert.Equal(t, "POST request body record 1 for '/burgers/import' failed to validate schema", errs[0].Message)
+	assert.Equal(t, "missing property 'name'", errs[0].SchemaValidationErrors[0].Reason)
+	assert.Equal(t, `{"patties": 2}`, errs[0].SchemaValidationErrors[0].ReferenceObject)
+	assert.Equal(t, "/burgers/import", errs[0].SpecPath)
+
+	require.NotNil(t, errs[1].RecordIndex)
+	assert.Equal(t, 2, *errs[1].RecordIndex)
+	assert.Equal(t, "maximum: got 5, want 3", errs[1].SchemaValidationErrors[0].Reason)
+
+	require.NotNil(t, errs[2].RecordIndex)
+	assert.Equal(t, 3, *errs[2].RecordIndex)
+	assert.Equal(t, "POST request body for '/burgers/import' cannot be decoded as 'application/x-ndjson'", errs[2].Message)
+	assert.Contains(t, errs[2].Reason, "record 3 is not valid JSON")
+}
+
+func TestValidateBody_JSONSequence_RecordSeparator(t *testing.T) {
+	v := jsonSequenceValidator(t)
+
+	body := "\x1e{\"event\": \"cooked\"}\n\x1e{\"burger\": \"Big Mac\"}\n"
+	request, _ := http.NewRequest(http.MethodPost, "https://things.com/burgers/events", strings.NewReader(body))
+	request.Header.Set(helpers.ContentTypeHeader, "application/json-seq")
+
+	// the schema is not an array, so each record is validated against the schema itself.
+	valid, errs := v.ValidateRequestBody(request)
+	assert.False(t, valid)
+	require.Len(t, errs, 1)
+	require.NotNil(t, errs[0].RecordIndex)
+	assert.Equal(t, 1, *errs[0].RecordIndex)
+	assert.Equal(t, "missing property 'event'", errs[0].SchemaValidationErrors[0].Reason)
+}
@@ -0,0 +1,211 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package requests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"slices"
+	"strings"
+
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	"github.com/pb33f/libopenapi/orderedmap"
+
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+
+	"github.com/pb33f/libopenapi-validator/helpers"
+)
+
+// multipartPart records the name and content type of a part, so the content type can be checked against the
+// encoding of the media type once the body has been decoded. The size of a binary part is checked against its
+// schema, which is only set for binary parts.
+type multipartPart struct {
+	name        string
+	contentType string
+	size        int64
+	binary      *base.Schema
+}
+
+// decodeMultipartBody decodes a 'multipart/form-data' request body into an object, so it can be validated against
+// the schema of the media type. Each part is mapped to the schema property with the same name:
+//
+//	binary properties ('format: binary' or 'contentMediaType') - an empty string that is not validated, the part
+//	                                                            size is checked against 'minLength' and 'maxLength'
+//	                                                            separately, see binaryPartLocations
+//	JSON parts                                                  - the content is decoded as JSON
+//	array properties                                            - every part with the name is an item
+//	anything else                                               - converted to the type defined by the schema
+//
+// A part without a Content-Type header is 'text/plain', unless the property is an object, which defaults to
+// 'application/json'.
+func decodeMultipartBody(
+	body []byte,
+	boundary string,
+	schema *base.Schema,
+	encoding *orderedmap.Map[string, *v3.Encoding],
+) (map[string]any, []multipartPart, error) {
+	if boundary == "" {
+		return nil, nil, fmt.Errorf("the content type has no '%s' parameter", helpers.Boundary)
+	}
+
+	reader := multipart.NewReader(bytes.NewReader(body), boundary)
+	values := make(map[string][]any)
+	var names []string
+	var parts []multipartPart
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		name := part.FormName()
+		if name == "" {
+			// only form-data parts with a name can be mapped to a property.
+			continue
+		}
+		content, err := io.ReadAll(part)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		declared := part.Header.Get(helpers.ContentTypeHeader)
+		contentType := declared
+		if contentType == "" {
+			contentType = helpers.TextPlainContentType
+		}
+
+		propSchema := formPropertySchema(schema, name)
+		isArray := propSchema != nil && slices.Contains(propSchema.Type, helpers.Array)
+		itemSchema := propSchema
+		if isArray {
+			itemSchema = nil
+			if propSchema.Items != nil && propSchema.Items.IsA() {
+				itemSchema = propSchema.Items.A.Schema()
+			}
+		}
+		mp := multipartPart{name: name, contentType: contentType, size: int64(len(content))}
+		if isBinarySchema(itemSchema) {
+			mp.binary = itemSchema
+		}
+		parts = append(parts, mp)
+		var enc *v3.Encoding
+		if encoding != nil {
+			enc = encoding.GetOrZero(name)
+		}
+
+		value, err := decodeMultipartValue(name, content, declared, itemSchema, enc)
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, seen := values[name]; !seen {
+			names = append(names, name)
+		}
+		// a JSON array sent as a single part holds all the items.
+		if items, ok := value.([]any); ok && isArray {
+			values[name] = append(values[name], items...)
+			continue
+		}
+		values[name] = append(values[name], value)
+	}
+
+	decoded := make(map[string]any, len(names))
+	for _, name := range names {
+		propSchema := formPropertySchema(schema, name)
+		if (propSchema != nil && slices.Contains(propSchema.Type, helpers.Array)) || len(values[name]) > 1 {
+			decoded[name] = values[name]
+			continue
+		}
+		decoded[name] = values[name][0]
+	}
+	return decoded, parts, nil
+}
+
+// decodeMultipartValue decodes the content of a single part, using the schema and the declared content type.
+func decodeMultipartValue(name string, content []byte, declared string, schema *base.Schema, enc *v3.Encoding) (any, error) {
+	if isBinarySchema(schema) {
+		// the bytes are not text, the value is only present so 'required' is satisfied.
+		return "", nil
+	}
+
+	contentType := declared
+	if contentType == "" && enc != nil {
+		// the encoding may list several content types, the first is the one a client is expected to send.
+		contentType, _, _ = strings.Cut(enc.ContentType, helpers.Comma)
+	}
+	isJSON := helpers.IsJSONContentType(contentType)
+	if contentType == "" && schema != nil && slices.Contains(schema.Type, helpers.Object) {
+		isJSON = true
+		contentType = helpers.JSONContentType
+	}
+	if isJSON {
+		var decoded any
+		if err := json.Unmarshal(content, &decoded); err != nil {
+			return nil, fmt.Errorf("the multipart part '%s' is not valid '%s': %s", name, contentType, err.Error())
+		}
+		return decoded, nil
+	}
+	return helpers.CastToSchemaType(string(content), schema), nil
+}
+
+// binaryPartLocations returns a function that reports whether an instance location is the value of a binary part,
+// so schema failures for the value are not reported. The value is not the content of the part, the size of the
+// part is checked by binaryPartSizeValid instead. Nil is returned when there are no binary parts.
+func binaryPartLocations(parts []multipartPart, schema *base.Schema) func(instanceLocation string) bool {
+	// a binary array property only holds binary items, the array itself is still validated.
+	locations := make(map[string]bool)
+	for _, part := range parts {
+		if part.binary == nil {
+			continue
+		}
+		propSchema := formPropertySchema(schema, part.name)
+		isArray := propSchema != nil && slices.Contains(propSchema.Type, helpers.Array)
+		locations[helpers.Slash+jsonPointerEscaper.Replace(part.name)] = !isArray
+	}
+	if len(locations) == 0 {
+		return nil
+	}
+	return func(instanceLocation string) bool {
+		for location, exact := range locations {
+			if (exact && instanceLocation == location) ||
+				strings.HasPrefix(instanceLocation, location+helpers.Slash) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// jsonPointerEscaper escapes a property name for use in a JSON pointer.
+var jsonPointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// binaryPartSizeValid returns true if the size of a binary part, in bytes, is within the 'minLength' and
+// 'maxLength' of its schema.
+func binaryPartSizeValid(size int64, schema *base.Schema) bool {
+	if schema.MinLength != nil && size < *schema.MinLength {
+		return false
+	}
+	return schema.MaxLength == nil || size <= *schema.MaxLength
+}
+
+// isBinarySchema returns true if the schema describes raw bytes, rather than text. A 'contentMediaType' that is
+// JSON is text that can be checked using 'contentSchema', so it is not binary.
+func isBinarySchema(schema *base.Schema) bool {
+	if schema == nil {
+		return false
+	}
+	if schema.Format == "binary" {
+		return true
+	}
+	// the high level model does not carry 'contentMediaType', so it's read from the low level model.
+	low := schema.GoLow()
+	if low == nil || low.ContentMediaType.IsEmpty() {
+		return false
+	}
+	return !helpers.IsJSONContentType(low.ContentMediaType.Value)
+}
@@ -0,0 +1,359 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package requests
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/pb33f/libopenapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pb33f/libopenapi-validator/config"
+	"github.com/pb33f/libopenapi-validator/errors"
+	"github.com/pb33f/libopenapi-validator/helpers"
+)
+
+const multipartSpec = `openapi: 3.1.0
+paths:
+  /burgers/photos:
+    post:
+      requestBody:
+        content:
+          multipart/form-data:
+            schema:
+              type: object
+              required: [name, photo]
+              properties:
+                name:
+                  type: string
+                rating:
+                  type: integer
+                  maximum: 5
+                photo:
+                  type: string
+                  format: binary
+                  maxLength: 16
+                receipt:
+                  type: string
+                  contentMediaType: application/pdf
+                tags:
+                  type: array
+                  items:
+                    type: string
+                meta:
+                  type: object
+                  required: [source]
+                  properties:
+                    source:
+                      type: string
+            encoding:
+              photo:
+                contentType: image/png, image/jpeg
+              receipt:
+                contentType: application/*`
+
+type multipartField struct {
+	name        string
+	contentType string
+	content     string
+}
+
+func multipartRequest(t *testing.T, fields ...multipartField) (*http.Request, string) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	for _, f := range fields {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", `form-data; name="`+f.name+`"`)
+		if f.contentType != "" {
+			header.Set(helpers.ContentTypeHeader, f.contentType)
+		}
+		part, err := writer.CreatePart(header)
+		require.NoError(t, err)
+		_, _ = part.Write([]byte(f.content))
+	}
+	require.NoError(t, writer.Close())
+
+	body := buf.String()
+	request, _ := http.NewRequest(http.MethodPost, "https://things.com/burgers/photos", strings.NewReader(body))
+	request.Header.Set(helpers.ContentTypeHeader, writer.FormDataContentType())
+	return request, body
+}
+
+func TestValidateBody_Multipart_Valid(t *testing.T) {
+	doc, _ := libopenapi.NewDocument([]byte(multipartSpec))
+	m, _ := doc.BuildV3Model()
+	v := NewRequestBodyValidator(&m.Model)
+
+	request, body := multipartRequest(t,
+		multipartField{name: "name", content: "Big Mac"},
+		multipartField{name: "rating", content: "4"},
+		multipartField{name: "photo", contentType: "image/png", content: "\x89PNG\r\n\x1a\n\xff\xfe\xfd"},
+		multipartField{name: "receipt", contentType: "application/pdf", content: "%PDF-1.7"},
+		multipartField{name: "tags", content: "tasty"},
+		multipartField{name: "tags", content: "cheesy"},
+		multipartField{name: "meta", content: `{"source": "app"}`},
+	)
+	valid, errs := v.ValidateRequestBody(request)
+	assert.True(t, valid)
+	assert.Empty(t, errs)
+
+	// the body can be read again.
+	b, _ := io.ReadAll(request.Body)
+	assert.Equal(t, body, string(b))
+}
+
+func TestValidateBody_Multipart_Invalid(t *testing.T) {
+	doc, _ := libopenapi.NewDocument([]byte(multipartSpec))
+	m, _ := doc.BuildV3Model()
+	v := NewRequestBodyValidator(&m.Model)
+
+	request, _ := multipartRequest(t,
+		multipartField{name: "name", content: "Big Mac"},
+		multipartField{name: "rating", content: "6"},
+		// seventeen bytes is larger than the 'maxLength' of the photo.
+		multipartField{name: "photo", contentType: "image/gif", content: "GIF89a\x00\x01\x02\x03\x04\x05\x06\x07\x08\x09\xff"},
+		multipartField{name: "meta", contentType: helpers.JSONContentType, content: `{}`},
+	)
+	valid, errs := v.ValidateRequestBody(request)
+	assert.False(t, valid)
+	require.Len(t, errs, 3)
+
+	assert.Equal(t, helpers.PartSize, errs[0].ValidationSubType)
+	assert.Equal(t, "POST request body part 'photo' has an invalid size of 17 bytes", errs[0].Message)
+	assert.Equal(t, "The 'photo' part is 17 bytes, however the schema requires no more than 16 bytes", errs[0].Reason)
+	assert.Equal(t, 20, errs[0].SpecLine)
+
+	assert.Equal(t, helpers.RequestBodyContentType, errs[1].ValidationSubType)
+	assert.Equal(t, "POST request body part 'photo' has an invalid content type 'image/gif'", errs[1].Message)
+	assert.Equal(t, "The content type 'image/gif' of the 'photo' part is not one of the content types defined "+
+		"by the encoding: 'image/png, image/jpeg'", errs[1].Reason)
+	assert.Equal(t, 36, errs[1].SpecLine)
+	assert.Equal(t, "/burgers/photos", errs[1].SpecPath)
+
+	// the bytes of the photo are not validated by the schema.
+	var locations []string
+	for _, e := range errs[2].SchemaValidationErrors {
+		locations = append(locations, e.Location)
+	}
+	assert.ElementsMatch(t, []string{
+		"/properties/rating/maximum",
+		"/properties/meta/required",
+	}, locations)
+}
+
+func TestValidateBody_Multipart_BinaryPartSize(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /burgers/photos:
+    post:
+      requestBody:
+        content:
+          multipart/form-data:
+            schema:
+              type: object
+              properties:
+                photo:
+                  type: string
+                  format: binary
+                  minLength: 4
+                  maxLength: 8`
+
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+	m, _ := doc.BuildV3Model()
+	v := NewRequestBodyValidator(&m.Model)
+
+	// bytes that are not valid UTF-8 are counted as bytes.
+	request, _ := multipartRequest(t, multipartField{name: "photo", content: "\xff\xfe\xfd\xfc\xfb\xfa\xf9\xf8"})
+	valid, errs := v.ValidateRequestBody(request)
+	assert.True(t, valid)
+	assert.Empty(t, errs)
+
+	request, _ = multipartRequest(t, multipartField{name: "photo", content: "\xff\xfe"})
+	valid, errs = v.ValidateRequestBody(request)
+	assert.False(t, valid)
+	require.Len(t, errs, 1)
+	assert.Equal(t, helpers.PartSize, errs[0].ValidationSubType)
+	assert.Equal(t, "The 'photo' part is 2 bytes, however the schema requires at least 4 bytes", errs[0].Reason)
+	assert.Equal(t, "Send a 'photo' part of at least 4 bytes", errs[0].HowToFix)
+	assert.Equal(t, 14, errs[0].SpecLine)
+	assert.Empty(t, errs[0].SchemaValidationErrors)
+
+	request, _ = multipartRequest(t, multipartField{name: "photo", content: "\xff\xfe\xfd\xfc\xfb\xfa\xf9\xf8\xf7"})
+	valid, errs = v.ValidateRequestBody(request)
+	assert.False(t, valid)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "The 'photo' part is 9 bytes, however the schema requires no more than 8 bytes", errs[0].Reason)
+}
+
+func TestValidateBody_Multipart_BinaryPartNotValidated(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /burgers/photos:
+    post:
+      requestBody:
+        content:
+          multipart/form-data:
+            schema:
+              type: object
+              required: [photo]
+              properties:
+                photo:
+                  type: string
+                  format: binary
+                  minLength: 1099511627776
+                  pattern: '^[a-z]+$'
+                photos/extra:
+                  type: array
+                  minItems: 2
+                  items:
+                    type: string
+                    format: binary
+                    pattern: '^[a-z]+$'`
+
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+	m, _ := doc.BuildV3Model()
+	v := NewRequestBodyValidator(&m.Model)
+
+	// the value of a binary part is not validated against the schema, only its size is checked.
+	request, _ := multipartRequest(t,
+		multipartField{name: "photo", content: "\x89PNG"},
+		multipartField{name: "photos/extra", content: "\x89PNG"},
+		multipartField{name: "photos/extra", content: "\x89PNG"},
+	)
+	valid, errs := v.ValidateRequestBody(request)
+	assert.False(t, valid)
+	require.Len(t, errs, 1)
+	assert.Equal(t, helpers.PartSize, errs[0].ValidationSubType)
+
+	// the array of binary parts is still validated.
+	request, _ = multipartRequest(t, multipartField{name: "photos/extra", content: "\x89PNG"})
+	valid, errs = v.ValidateRequestBody(request)
+	assert.False(t, valid)
+	require.Len(t, errs, 1)
+	require.Len(t, errs[0].SchemaValidationErrors, 2)
+	assert.Equal(t, "missing property 'photo'", errs[0].SchemaValidationErrors[0].Reason)
+	assert.Equal(t, "minItems: got 1, want 2", errs[0].SchemaValidationErrors[1].Reason)
+}
+
+func TestValidateBody_Multipart_MissingBinaryPart(t *testing.T) {
+	doc, _ := libopenapi.NewDocument([]byte(multipartSpec))
+	m, _ := doc.BuildV3Model()
+	v := NewRequestBodyValidator(&m.Model)
+
+	request, _ := multipartRequest(t,
+		multipartField{name: "name", content: "Big Mac"},
+		multipartField{name: "receipt", contentType: "text/plain", content: "receipt"},
+	)
+	valid, errs := v.ValidateRequestBody(request)
+	assert.False(t, valid)
+	require.Len(t, errs, 2)
+	assert.Equal(t, "POST request body part 'receipt' has an invalid content type 'text/plain'", errs[0].Message)
+	require.Len(t, errs[1].SchemaValidationErrors, 1)
+	assert.Equal(t, "missing property 'photo'", errs[1].SchemaValidationErrors[0].Reason)
+}
+
+func TestValidateBody_Multipart_CannotBeDecoded(t *testing.T) {
+	doc, _ := libopenapi.NewDocument([]byte(multipartSpec))
+	m, _ := doc.BuildV3Model()
+	v := NewRequestBodyValidator(&m.Model)
+
+	request, _ := multipartRequest(t,
+		multipartField{name: "name", content: "Big Mac"},
+		multipartField{name: "meta", content: `{"source":`},
+	)
+	valid, errs := v.ValidateRequestBody(request)
+	assert.False(t, valid)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "POST request body for '/burgers/photos' cannot be decoded as 'multipart/form-data'",
+		errs[0].Message)
+	assert.Equal(t, "The request body cannot be decoded: the multipart part 'meta' is not valid "+
+		"'application/json': unexpected end of JSON input", errs[0].Reason)
+
+	// without a boundary, the parts cannot be found.
+	request, _ = multipartRequest(t, multipartField{name: "name", content: "Big Mac"})
+	request.Header.Set(helpers.ContentTypeHeader, helpers.MultipartFormDataType)
+	valid, errs = v.ValidateRequestBody(request)
+	assert.False(t, valid)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "The request body cannot be decoded: the content type has no 'boundary' parameter",
+		errs[0].Reason)
+}
+
+func TestValidateBody_Multipart_MemoryLimit(t *testing.T) {
+	doc, _ := libopenapi.NewDocument([]byte(multipartSpec))
+	m, _ := doc.BuildV3Model()
+	v := NewRequestBodyValidator(&m.Model, config.WithMultipartMemoryLimit(64))
+
+	request, body := multipartRequest(t,
+		multipartField{name: "name", content: strings.Repeat("Big Mac ", 32)},
+		multipartField{name: "photo", contentType: "image/png", content: "\x89PNG"},
+	)
+	valid, errs := v.ValidateRequestBody(request)
+	assert.False(t, valid)
+	require.Len(t, errs, 1)
+	assert.Equal(t, helpers.BodyTooLarge, errs[0].ValidationSubType)
+	assert.Equal(t, "POST request body for '/burgers/photos' is too large", errs[0].Message)
+	assert.Equal(t, "The request body cannot be validated: the multipart body is larger than the memory limit "+
+		"of 64 bytes", errs[0].Reason)
+	assert.Equal(t, fmt.Sprintf(errors.HowToFixMultipartLimit, 64), errs[0].HowToFix)
+
+	// the whole body can still be read.
+	b, _ := io.ReadAll(request.Body)
+	assert.Equal(t, body, string(b))
+
+	// a limit of zero removes the limit.
+	v = NewRequestBodyValidator(&m.Model, config.WithMultipartMemoryLimit(0))
+	request, _ = multipartRequest(t,
+		multipartField{name: "name", content: strings.Repeat("Big Mac ", 32)},
+		multipartField{name: "photo", contentType: "image/png", content: "\x89PNG"},
+	)
+	valid, errs = v.ValidateRequestBody(request)
+	assert.True(t, valid)
+	assert.Empty(t, errs)
+}
+
+func TestValidateBody_Multipart_MaxBodySize(t *testing.T) {
+	doc, _ := libopenapi.NewDocument([]byte(multipartSpec))
+	m, _ := doc.BuildV3Model()
+	v := NewRequestBodyValidator(&m.Model, config.WithMaxBodySize(64), config.WithMultipartMemoryLimit(1024))
+
+	request, body := multipartRequest(t,
+		multipartField{name: "name", content: strings.Repeat("Big Mac ", 32)},
+		multipartField{name: "photo", contentType: "image/png", content: "\x89PNG"},
+	)
+	valid, errs := v.ValidateRequestBody(request)
+	assert.False(t, valid)
+	require.Len(t, errs, 1)
+	assert.Equal(t, helpers.BodyTooLarge, errs[0].ValidationSubType)
+	assert.Equal(t, fmt.Sprintf(errors.HowToFixBodyTooLarge, 64), errs[0].HowToFix)
+
+	// the whole body can still be read.
+	b, _ := io.ReadAll(request.Body)
+	assert.Equal(t, body, string(b))
+}
+
+func TestValidateBody_Multipart_ContentLengthMismatch(t *testing.T) {
+	doc, _ := libopenapi.NewDocument([]byte(multipartSpec))
+	m, _ := doc.BuildV3Model()
+	v := NewRequestBodyValidator(&m.Model)
+
+	request, body := multipartRequest(t,
+		multipartField{name: "name", content: "Big Mac"},
+		multipartField{name: "photo", contentType: "image/png", content: "\x89PNG"},
+	)
+	request.ContentLength = int64(len(body)) + 10
+	valid, errs := v.ValidateRequestBody(request)
+	assert.False(t, valid)
+	require.Len(t, errs, 1)
+	assert.Equal(t, helpers.BodyContentLength, errs[0].ValidationSubType)
+	assert.Equal(t, errors.HowToFixContentLength, errs[0].HowToFix)
+}
@@ -4,13 +4,20 @@
 package requests
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"slices"
 	"sync"
 
 	"github.com/pb33f/libopenapi/datamodel/high/base"
 	"github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/santhosh-tekuri/jsonschema/v6"
 
+	"github.com/pb33f/libopenapi-validator/config"
 	"github.com/pb33f/libopenapi-validator/errors"
+	"github.com/pb33f/libopenapi-validator/helpers"
+	"github.com/pb33f/libopenapi-validator/radix"
 )
 
 // RequestBodyValidator is an interface that defines the methods for validating request bodies for Operations.
@@ -27,20 +34,108 @@ type RequestBodyValidator interface {
 	// request body is valid, false if it is not. The second return value will be a slice of ValidationError pointers if
 	// the body is not valid.
 	ValidateRequestBodyWithPathItem(request *http.Request, pathItem *v3.PathItem, pathValue string) (bool, []*errors.ValidationError)
+
+	// ValidateRequestBodyCtx will validate the request body for an operation in the same way as ValidateRequestBody.
+	// Validation stops early once the context is done, and a single context error is returned. The context is
+	// supplied to body decoders, content decoders and schema loaders.
+	ValidateRequestBodyCtx(ctx context.Context, request *http.Request) (bool, []*errors.ValidationError)
+
+	// ValidateRequestBodyWithPathItemCtx will validate the request body for an operation in the same way as
+	// ValidateRequestBodyWithPathItem, using the context in the same way as ValidateRequestBodyCtx.
+	ValidateRequestBodyWithPathItemCtx(ctx context.Context, request *http.Request, pathItem *v3.PathItem, pathValue string) (bool, []*errors.ValidationError)
 }
 
-// NewRequestBodyValidator will create a new RequestBodyValidator from an OpenAPI 3+ document
-func NewRequestBodyValidator(document *v3.Document) RequestBodyValidator {
-	return &requestBodyValidator{document: document, schemaCache: &sync.Map{}}
+// NewRequestBodyValidator will create a new RequestBodyValidator from an OpenAPI 3+ document. Options can be
+// supplied to change the default behavior of the validator.
+func NewRequestBodyValidator(document *v3.Document, opts ...config.Option) RequestBodyValidator {
+	options := config.NewValidationOptions(opts...)
+	if options.PathTree == nil {
+		options.PathTree = radix.NewPathTree(document)
+	}
+	if options.SchemaCache == nil {
+		options.SchemaCache = config.NewLRUSchemaCache(config.DefaultSchemaCacheSize)
+	}
+	return &requestBodyValidator{
+		document: document,
+		options:  options,
+	}
 }
 
+// schemaCache is a schema of the document, along with its rendered and compiled forms. The rendered and compiled
+// forms are held in the schema cache of the validator, and shared by every schema with the same hash.
 type schemaCache struct {
-	schema         *base.Schema
+	schema *base.Schema
+	*sharedSchema
+}
+
+// sharedSchema holds the rendered and compiled forms of a schema, so the work is only performed once per schema.
+type sharedSchema struct {
 	renderedInline []byte
 	renderedJSON   []byte
+
+	once       sync.Once
+	compiled   *jsonschema.Schema
+	compileErr error
+}
+
+// sharedSchemaKey identifies the rendered form of a schema in the schema cache, see helpers.CompiledSchemaKey.
+type sharedSchemaKey helpers.CompiledSchemaKey
+
+// compile compiles the rendered schema the first time it is needed, every call after that returns the same result.
+// The compiled schema is shared, so the compilation is not stopped when the context is cancelled.
+func (c *sharedSchema) compile(ctx context.Context, options *config.ValidationOptions) (*jsonschema.Schema, error) {
+	c.once.Do(func() {
+		c.compiled, c.compileErr = helpers.NewCompiledSchemaContext(context.WithoutCancel(ctx),
+			helpers.RequestBodyValidation, c.renderedJSON, options)
+	})
+	return c.compiled, c.compileErr
 }
 
 type requestBodyValidator struct {
-	document    *v3.Document
-	schemaCache *sync.Map
+	document   *v3.Document
+	options    *config.ValidationOptions
+	schemaKeys helpers.SchemaKeys
+}
+
+// CompileSchemas compiles the schema of every request body of every operation in the document, so the compiled
+// schemas are cached before the first request is validated. The item schemas of streamed media types are compiled
+// as well. A schema that cannot be compiled is returned as a *helpers.SchemaCompileError. It is used by the
+// validator to warm up eagerly, see config.WithEagerSchemaCompilation.
+func (v *requestBodyValidator) CompileSchemas() []error {
+	var jobs []func() error
+	for _, op := range helpers.ExtractDocumentOperations(v.document) {
+		if op.Operation.RequestBody == nil || op.Operation.RequestBody.Content == nil {
+			continue
+		}
+		for pair := op.Operation.RequestBody.Content.First(); pair != nil; pair = pair.Next() {
+			contentType, mediaType := pair.Key(), pair.Value()
+			if mediaType == nil || mediaType.Schema == nil {
+				continue
+			}
+			jobs = append(jobs, func() error {
+				if mediaType.Schema.Schema() == nil {
+					return nil
+				}
+				cached := v.renderSchema(mediaType.Schema)
+				_, err := cached.compile(context.Background(), v.options)
+				if err == nil && helpers.IsJSONSequenceContentType(contentType) {
+					// each record of a stream is validated against the items of the schema.
+					if schema := cached.schema; slices.Contains(schema.Type, helpers.Array) &&
+						schema.Items != nil && schema.Items.IsA() {
+						_, err = v.renderSchema(schema.Items.A).compile(context.Background(), v.options)
+					}
+				}
+				if err != nil {
+					return &helpers.SchemaCompileError{
+						Path:     op.Path,
+						Method:   op.Method,
+						Location: fmt.Sprintf("request body '%s'", contentType),
+						Err:      err,
+					}
+				}
+				return nil
+			})
+		}
+	}
+	return helpers.RunParallel(jobs)
 }
@@ -4,6 +4,7 @@
 package requests
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strings"
@@ -13,20 +14,49 @@ import (
 
 	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
 
+	"github.com/pb33f/libopenapi-validator/config"
 	"github.com/pb33f/libopenapi-validator/errors"
 	"github.com/pb33f/libopenapi-validator/helpers"
 	"github.com/pb33f/libopenapi-validator/paths"
 )
 
 func (v *requestBodyValidator) ValidateRequestBody(request *http.Request) (bool, []*errors.ValidationError) {
-	pathItem, errs, foundPath := paths.FindPath(request, v.document)
-	if len(errs) > 0 {
-		return false, errs
-	}
-	return v.ValidateRequestBodyWithPathItem(request, pathItem, foundPath)
+	return v.ValidateRequestBodyCtx(context.Background(), request)
+}
+
+func (v *requestBodyValidator) ValidateRequestBodyCtx(ctx context.Context, request *http.Request) (bool, []*errors.ValidationError) {
+	return errors.ValidateWithContext(ctx, request, func() (bool, []*errors.ValidationError) {
+		pathItem, errs, foundPath := paths.FindPath(request, v.document, config.WithExistingOpts(v.options))
+		if len(errs) > 0 {
+			return false, errs
+		}
+		return v.validateRequestBody(ctx, request, pathItem, foundPath)
+	})
 }
 
 func (v *requestBodyValidator) ValidateRequestBodyWithPathItem(request *http.Request, pathItem *v3.PathItem, pathValue string) (bool, []*errors.ValidationError) {
+	return v.ValidateRequestBodyWithPathItemCtx(context.Background(), request, pathItem, pathValue)
+}
+
+func (v *requestBodyValidator) ValidateRequestBodyWithPathItemCtx(
+	ctx context.Context,
+	request *http.Request,
+	pathItem *v3.PathItem,
+	pathValue string,
+) (bool, []*errors.ValidationError) {
+	return errors.ValidateWithContext(ctx, request, func() (bool, []*errors.ValidationError) {
+		return v.validateRequestBody(ctx, request, pathItem, pathValue)
+	})
+}
+
+// validateRequestBody validates the request body for the operation of the path item. The context is checked by
+// the caller, it is passed down to the decoders and schema loaders.
+func (v *requestBodyValidator) validateRequestBody(
+	ctx context.Context,
+	request *http.Request,
+	pathItem *v3.PathItem,
+	pathValue string,
+) (bool, []*errors.ValidationError) {
 	if pathItem == nil {
 		return false, []*errors.ValidationError{{
 			ValidationType:    helpers.ParameterValidationPath,
@@ -62,16 +92,29 @@ func (v *requestBodyValidator) ValidateRequestBodyWithPathItem(request *http.Req
 		return false, []*errors.ValidationError{errors.RequestContentTypeNotFound(operation, request, pathValue)}
 	}
 
-	// extract the media type from the content type header.
-	ct, _, _ := helpers.ExtractContentType(contentType)
-	mediaType, ok := operation.RequestBody.Content.Get(ct)
+	// extract the media type from the content type header, and find the best matching media range in the spec.
+	ct, _, boundary := helpers.ExtractContentType(contentType)
+	_, mediaType, ok := helpers.FindMediaType(operation.RequestBody.Content, contentType)
 	if !ok {
 		return false, []*errors.ValidationError{errors.RequestContentTypeNotFound(operation, request, pathValue)}
 	}
 
-	// we currently only support JSON validation for request bodies
-	// this will capture *everything* that contains some form of 'json' in the content type
-	if !strings.Contains(strings.ToLower(contentType), helpers.JSONType) {
+	// a registered decoder is used over the built-in decoding.
+	decoder := v.options.FindBodyDecoder(ct)
+
+	// we currently support JSON, JSON sequences, XML, form and multipart validation for request bodies, anything else
+	// needs a decoder.
+	// JSON includes any media type with a '+json' suffix, such as 'application/problem+json'.
+	isForm := decoder == nil && strings.EqualFold(ct, helpers.FormURLEncodedContentType)
+	isMultipart := decoder == nil && strings.EqualFold(ct, helpers.MultipartFormDataType)
+	isSequence := decoder == nil && helpers.IsJSONSequenceContentType(ct)
+	if decoder == nil && !isForm && !isMultipart && !isSequence && !helpers.IsXMLContentType(ct) &&
+		!helpers.IsJSONContentType(ct) {
+		if v.options.UnknownMediaTypes == config.FailUnknownMediaTypes {
+			return false, []*errors.ValidationError{
+				errors.RequestBodyMediaTypeUnsupported(request, mediaType, ct, pathValue),
+			}
+		}
 		return true, nil
 	}
 
@@ -81,37 +124,128 @@ func (v *requestBodyValidator) ValidateRequestBodyWithPathItem(request *http.Req
 	}
 
 	// extract schema from media type
-	var schema *base.Schema
-	var renderedInline, renderedJSON []byte
+	cached := v.renderSchema(mediaType.Schema)
+
+	if isSequence {
+		return v.validateJSONSequenceBody(ctx, request, cached, ct, pathValue)
+	}
+	if isForm {
+		return v.validateFormBody(ctx, request, mediaType, cached, pathValue)
+	}
+	if isMultipart {
+		return v.validateMultipartBody(ctx, request, mediaType, cached, boundary, pathValue)
+	}
 
-	// have we seen this schema before? let's hash it and check the cache.
-	hash := mediaType.GoLow().Schema.Value.Hash()
+	// the schema is only compiled the first time it is used.
+	validationSucceeded, validationErrors := validateRequestSchema(ctx, request, cached, v.options)
 
-	// perform work only once and cache the result in the validator.
-	if cacheHit, ch := v.schemaCache.Load(hash); ch {
-		// got a hit, use cached values
-		schema = cacheHit.(*schemaCache).schema
-		renderedInline = cacheHit.(*schemaCache).renderedInline
-		renderedJSON = cacheHit.(*schemaCache).renderedJSON
+	errors.PopulateValidationErrors(validationErrors, request, pathValue)
 
-	} else {
+	return validationSucceeded, validationErrors
+}
+
+// renderSchema renders a schema inline, and converts the rendered schema to JSON. The conversion is only performed
+// once per rendered schema, the result is held in the schema cache of the validator, along with the compiled schema
+// once it has been compiled. The key of a schema is remembered by the validator, so a schema is not rendered again
+// while it remains in the cache.
+func (v *requestBodyValidator) renderSchema(proxy *base.SchemaProxy) *schemaCache {
+	schema := proxy.Schema()
+
+	// have we seen this schema before? let's check the cache.
+	var renderedInline []byte
+	key := sharedSchemaKey(v.schemaKeys.Key(schema, func() []byte {
+		renderedInline, _ = schema.RenderInline()
+		return renderedInline
+	}, v.options))
+	if cacheHit, ch := v.options.SchemaCache.Load(key); ch {
+		// got a hit, use cached values
+		return &schemaCache{schema: schema, sharedSchema: cacheHit.(*sharedSchema)}
+	}
 
-		// render the schema inline and perform the intensive work of rendering and converting
-		// this is only performed once per schema and cached in the validator.
-		schema = mediaType.Schema.Schema()
+	// perform the intensive work of converting the rendered schema
+	if renderedInline == nil {
 		renderedInline, _ = schema.RenderInline()
-		renderedJSON, _ = utils.ConvertYAMLtoJSON(renderedInline)
-		v.schemaCache.Store(hash, &schemaCache{
-			schema:         schema,
-			renderedInline: renderedInline,
-			renderedJSON:   renderedJSON,
-		})
+	}
+	renderedJSON, _ := utils.ConvertYAMLtoJSON(renderedInline)
+	rendered, _ := v.options.SchemaCache.LoadOrStore(key, &sharedSchema{
+		renderedInline: renderedInline,
+		renderedJSON:   renderedJSON,
+	})
+	return &schemaCache{schema: schema, sharedSchema: rendered.(*sharedSchema)}
+}
+
+// validateFormBody decodes a form request body using the encoding of the media type, and validates the decoded
+// object against the schema. Each name and value is transcoded from the charset of the request once it has been
+// unescaped.
+func (v *requestBodyValidator) validateFormBody(
+	ctx context.Context,
+	request *http.Request,
+	mediaType *v3.MediaType,
+	cached *schemaCache,
+	pathValue string,
+) (bool, []*errors.ValidationError) {
+	requestBody, readErr := readRequestBody(ctx, request, v.options, pathValue)
+	if readErr != nil {
+		return false, []*errors.ValidationError{readErr}
 	}
 
-	// render the schema, to be used for validation
-	validationSucceeded, validationErrors := ValidateRequestSchema(request, schema, renderedInline, renderedJSON)
+	_, charset, _ := helpers.ExtractContentType(request.Header.Get(helpers.ContentTypeHeader))
+	decoded, err := DecodeFormBodyCharset(requestBody, charset, cached.schema, mediaType.Encoding)
+	if err != nil {
+		return false, []*errors.ValidationError{
+			errors.RequestBodyCannotBeDecoded(request, helpers.FormURLEncodedContentType, err.Error(), pathValue),
+		}
+	}
 
+	valid, validationErrors := validateRequestObject(ctx, request, cached, decoded, requestBody, nil, nil, v.options)
 	errors.PopulateValidationErrors(validationErrors, request, pathValue)
+	return valid, validationErrors
+}
 
-	return validationSucceeded, validationErrors
+// validateMultipartBody decodes a multipart request body, checks the content type of each part against the
+// encoding of the media type, and validates the decoded object against the schema. No more than the multipart
+// memory limit, or the maximum body size when it is smaller, is read from the body.
+func (v *requestBodyValidator) validateMultipartBody(
+	ctx context.Context,
+	request *http.Request,
+	mediaType *v3.MediaType,
+	cached *schemaCache,
+	boundary string,
+	pathValue string,
+) (bool, []*errors.ValidationError) {
+	requestBody, readErr := readMultipartBody(ctx, request, v.options, pathValue)
+	if readErr != nil {
+		return false, []*errors.ValidationError{readErr}
+	}
+
+	decoded, parts, err := decodeMultipartBody(requestBody, boundary, cached.schema, mediaType.Encoding)
+	if err != nil {
+		return false, []*errors.ValidationError{
+			errors.RequestBodyCannotBeDecoded(request, helpers.MultipartFormDataType, err.Error(), pathValue),
+		}
+	}
+
+	var validationErrors []*errors.ValidationError
+	for _, part := range parts {
+		if part.binary != nil && !binaryPartSizeValid(part.size, part.binary) {
+			validationErrors = append(validationErrors,
+				errors.RequestPartSizeInvalid(request, part.name, part.size, part.binary, pathValue))
+		}
+		if mediaType.Encoding == nil {
+			continue
+		}
+		enc := mediaType.Encoding.GetOrZero(part.name)
+		if enc == nil || enc.ContentType == "" || helpers.MatchesMediaRanges(part.contentType, enc.ContentType) {
+			continue
+		}
+		validationErrors = append(validationErrors,
+			errors.RequestPartContentTypeInvalid(request, part.name, part.contentType, enc, pathValue))
+	}
+
+	// the values of binary parts are not text, so only their size is checked.
+	_, schemaErrors := validateRequestObject(ctx, request, cached, decoded, requestBody, nil,
+		binaryPartLocations(parts, cached.schema), v.options)
+	validationErrors = append(validationErrors, schemaErrors...)
+	errors.PopulateValidationErrors(validationErrors, request, pathValue)
+	return len(validationErrors) == 0, validationErrors
 }
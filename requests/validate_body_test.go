@@ -5,14 +5,22 @@ package requests
 
 import (
 	"bytes"
+	"compress/gzip"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/pb33f/libopenapi"
+	"github.com/pb33f/libopenapi/datamodel/high/base"
 	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
 
+	"github.com/pb33f/libopenapi-validator/config"
+	"github.com/pb33f/libopenapi-validator/errors"
 	"github.com/pb33f/libopenapi-validator/paths"
 )
 
@@ -1309,3 +1317,652 @@ func TestValidateBody_SchemaNoType_Issue75(t *testing.T) {
 	assert.Len(t, valErrs, 1)
 	assert.Equal(t, "PUT request body is empty for '/path1'", valErrs[0].Message)
 }
+
+func TestValidateBody_XML(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /burgers/createBurger:
+    post:
+      requestBody:
+        content:
+          application/xml:
+            schema:
+              type: object
+              required: [name]
+              additionalProperties: false
+              xml:
+                name: burger
+              properties:
+                id:
+                  type: integer
+                  xml:
+                    attribute: true
+                name:
+                  type: string
+                patties:
+                  type: integer
+                  maximum: 3
+                toppings:
+                  type: array
+                  xml:
+                    wrapped: true
+                  items:
+                    type: string
+                    enum: [cheese, pickles]
+                    xml:
+                      name: topping`
+
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+	m, _ := doc.BuildV3Model()
+	v := NewRequestBodyValidator(&m.Model)
+
+	request, _ := http.NewRequest(http.MethodPost, "https://things.com/burgers/createBurger",
+		bytes.NewBufferString(`<burger id="1"><name>Big Mac</name><patties>2</patties>`+
+			`<toppings><topping>cheese</topping></toppings></burger>`))
+	request.Header.Set("Content-Type", "application/xml")
+	valid, errs := v.ValidateRequestBody(request)
+	assert.True(t, valid)
+	assert.Len(t, errs, 0)
+
+	request, _ = http.NewRequest(http.MethodPost, "https://things.com/burgers/createBurger",
+		bytes.NewBufferString(`<burger id="one"><patties>4</patties>`+
+			`<toppings><topping>cheese</topping><topping>onions</topping></toppings><side/></burger>`))
+	request.Header.Set("Content-Type", "application/xml; charset=utf-8")
+	valid, errs = v.ValidateRequestBody(request)
+	assert.False(t, valid)
+	assert.Len(t, errs, 1)
+
+	elements := make(map[string]string)
+	for _, e := range errs[0].SchemaValidationErrors {
+		elements[e.Location] = e.ElementPath
+	}
+	assert.Equal(t, map[string]string{
+		"/required":                       "/burger",
+		"/additionalProperties":           "/burger",
+		"/properties/id/type":             "/burger/@id",
+		"/properties/patties/maximum":     "/burger/patties",
+		"/properties/toppings/items/enum": "/burger/toppings/topping[2]",
+	}, elements)
+
+	request, _ = http.NewRequest(http.MethodPost, "https://things.com/burgers/createBurger",
+		bytes.NewBufferString(`<pizza/>`))
+	request.Header.Set("Content-Type", "application/xml")
+	valid, errs = v.ValidateRequestBody(request)
+	assert.False(t, valid)
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "The request body cannot be decoded: the root element is 'pizza', however 'burger' is required",
+		errs[0].Reason)
+}
+
+func TestValidateBody_XML_Charset(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /burgers/createBurger:
+    post:
+      requestBody:
+        content:
+          application/xml:
+            schema:
+              type: object
+              xml:
+                name: burger
+              properties:
+                name:
+                  enum: [Café Burger]`
+
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+	m, _ := doc.BuildV3Model()
+	v := NewRequestBodyValidator(&m.Model)
+
+	buildRequest := func(body string, contentType string) *http.Request {
+		request, _ := http.NewRequest(http.MethodPost, "https://things.com/burgers/createBurger",
+			strings.NewReader(body))
+		request.Header.Set("Content-Type", contentType)
+		return request
+	}
+
+	// without a charset, the body is transcoded from the encoding declaration of the document.
+	valid, errs := v.ValidateRequestBody(buildRequest("<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?>"+
+		"<burger><name>Caf\xe9 Burger</name></burger>", "application/xml"))
+	assert.True(t, valid)
+	assert.Len(t, errs, 0)
+
+	// the charset of the content type is used over the encoding declaration, so the body is transcoded once.
+	valid, errs = v.ValidateRequestBody(buildRequest("<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?>"+
+		"<burger><name>Caf\xe9 Burger</name></burger>", "application/xml; charset=iso-8859-1"))
+	assert.True(t, valid)
+	assert.Len(t, errs, 0)
+
+	// a document without a declaration must be UTF-8.
+	valid, errs = v.ValidateRequestBody(buildRequest("<burger><name>Caf\xe9 Burger</name></burger>",
+		"application/xml"))
+	assert.False(t, valid)
+	assert.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Reason, "invalid UTF-8")
+}
+
+func TestValidateBody_BodyDecoder(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /burgers/createBurger:
+    post:
+      requestBody:
+        content:
+          application/yaml:
+            schema:
+              type: object
+              required: [name]
+              properties:
+                name:
+                  type: string
+                patties:
+                  type: integer
+          text/csv:
+            schema:
+              type: string`
+
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+	m, _ := doc.BuildV3Model()
+
+	yamlDecoder := config.BodyDecoderFunc(func(body []byte, _ *base.Schema) (any, error) {
+		var decoded any
+		err := yaml.Unmarshal(body, &decoded)
+		return decoded, err
+	})
+	v := NewRequestBodyValidator(&m.Model, config.WithBodyDecoder("application/*yaml", yamlDecoder))
+
+	request, _ := http.NewRequest(http.MethodPost, "https://things.com/burgers/createBurger",
+		bytes.NewBufferString("name: Big Mac\npatties: 2\n"))
+	request.Header.Set("Content-Type", "application/yaml")
+	valid, errs := v.ValidateRequestBody(request)
+	assert.True(t, valid)
+	assert.Len(t, errs, 0)
+
+	request, _ = http.NewRequest(http.MethodPost, "https://things.com/burgers/createBurger",
+		bytes.NewBufferString("patties: two\n"))
+	request.Header.Set("Content-Type", "application/yaml")
+	valid, errs = v.ValidateRequestBody(request)
+	assert.False(t, valid)
+	assert.Len(t, errs, 1)
+	assert.Len(t, errs[0].SchemaValidationErrors, 2)
+
+	request, _ = http.NewRequest(http.MethodPost, "https://things.com/burgers/createBurger",
+		bytes.NewBufferString("name: [Big Mac\n"))
+	request.Header.Set("Content-Type", "application/yaml")
+	valid, errs = v.ValidateRequestBody(request)
+	assert.False(t, valid)
+	assert.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Reason, "The request body cannot be decoded: yaml:")
+
+	// there is no decoder for CSV, so it's skipped by default.
+	request, _ = http.NewRequest(http.MethodPost, "https://things.com/burgers/createBurger",
+		bytes.NewBufferString("name,patties\nBig Mac,2\n"))
+	request.Header.Set("Content-Type", "text/csv")
+	valid, errs = v.ValidateRequestBody(request)
+	assert.True(t, valid)
+	assert.Len(t, errs, 0)
+
+	v = NewRequestBodyValidator(&m.Model, config.WithUnknownMediaTypePolicy(config.FailUnknownMediaTypes))
+	valid, errs = v.ValidateRequestBody(request)
+	assert.False(t, valid)
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "POST request body for '/burgers/createBurger' cannot be validated as 'text/csv'", errs[0].Message)
+	assert.Equal(t, "Register a body decoder for the 'text/csv' media type using config.WithBodyDecoder",
+		errs[0].HowToFix)
+	assert.Equal(t, 16, errs[0].SpecLine)
+}
+
+func TestValidateBody_MediaRanges(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /burgers/createBurger:
+    post:
+      requestBody:
+        content:
+          '*/*':
+            schema:
+              type: string
+          application/*+json:
+            schema:
+              type: object
+              required: [name]
+          application/json; version=2:
+            schema:
+              type: object
+              required: [name, patties]`
+
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+	m, _ := doc.BuildV3Model()
+	v := NewRequestBodyValidator(&m.Model)
+
+	validate := func(contentType, body string) (bool, int) {
+		request, _ := http.NewRequest(http.MethodPost, "https://things.com/burgers/createBurger",
+			bytes.NewBufferString(body))
+		request.Header.Set("Content-Type", contentType)
+		valid, errs := v.ValidateRequestBody(request)
+		return valid, len(errs)
+	}
+
+	// the suffix range is more specific than the wildcard range.
+	valid, errs := validate("application/vnd.burger+json", `{"name": "Big Mac"}`)
+	assert.True(t, valid)
+	assert.Equal(t, 0, errs)
+	valid, _ = validate("application/vnd.burger+json", `{}`)
+	assert.False(t, valid)
+
+	// the version parameter selects the media type.
+	valid, _ = validate("application/json; version=2", `{"name": "Big Mac"}`)
+	assert.False(t, valid)
+	valid, _ = validate("application/json; version=2", `{"name": "Big Mac", "patties": 2}`)
+	assert.True(t, valid)
+
+	// JSON lines are not JSON, they fall through to the wildcard range, and each record is validated against it.
+	valid, errs = validate("application/jsonl", "{\"name\": \"Big Mac\"}\n{}\n")
+	assert.False(t, valid)
+	assert.Equal(t, 2, errs)
+}
+
+func TestValidateBody_MaxBodySize(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /burgers/createBurger:
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [name]`
+
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+	m, _ := doc.BuildV3Model()
+	v := NewRequestBodyValidator(&m.Model, config.WithMaxBodySize(16))
+
+	body := `{"name": "Big Mac", "patties": 2}`
+	request, _ := http.NewRequest(http.MethodPost, "https://things.com/burgers/createBurger",
+		bytes.NewBufferString(body))
+	request.Header.Set("Content-Type", "application/json")
+
+	// the declared content length is over the limit, so nothing is read.
+	valid, errors := v.ValidateRequestBody(request)
+	assert.False(t, valid)
+	assert.Len(t, errors, 1)
+	assert.True(t, errors[0].IsBodyTooLargeError())
+	assert.Equal(t, "POST request body for '/burgers/createBurger' is too large", errors[0].Message)
+	assert.Equal(t, "/burgers/createBurger", errors[0].SpecPath)
+
+	// without a content length, the body is read up to the limit, and the rest is left in place.
+	request, _ = http.NewRequest(http.MethodPost, "https://things.com/burgers/createBurger",
+		bytes.NewBufferString(body))
+	request.Header.Set("Content-Type", "application/json")
+	request.ContentLength = -1
+
+	valid, errors = v.ValidateRequestBody(request)
+	assert.False(t, valid)
+	assert.Len(t, errors, 1)
+	assert.True(t, errors[0].IsBodyTooLargeError())
+	assert.Contains(t, errors[0].Reason, "the body is larger than the limit of 16 bytes")
+
+	remaining, _ := io.ReadAll(request.Body)
+	assert.Equal(t, body, string(remaining))
+}
+
+func TestValidateBody_ContentLengthMismatch(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /burgers/createBurger:
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object`
+
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+	m, _ := doc.BuildV3Model()
+	v := NewRequestBodyValidator(&m.Model)
+
+	request, _ := http.NewRequest(http.MethodPost, "https://things.com/burgers/createBurger",
+		bytes.NewBufferString(`{"name": "Big Mac"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.ContentLength = 64
+
+	valid, errors := v.ValidateRequestBody(request)
+	assert.False(t, valid)
+	assert.Len(t, errors, 1)
+	assert.Equal(t, "contentLength", errors[0].ValidationSubType)
+	assert.Equal(t, "The request body is incomplete or has been altered: the content length is 64 bytes, "+
+		"however 19 bytes were read", errors[0].Reason)
+}
+
+func TestValidateBody_ArrayItems(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /burgers/streamed:
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: array
+              items:
+                type: object
+                required: [name]
+                properties:
+                  name:
+                    type: string
+  /burgers/materialized:
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: array
+              maxItems: 10
+              items:
+                type: object
+                required: [name]
+                properties:
+                  name:
+                    type: string`
+
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+	m, _ := doc.BuildV3Model()
+	v := NewRequestBodyValidator(&m.Model)
+
+	validate := func(path, body string) []*errors.ValidationError {
+		request, _ := http.NewRequest(http.MethodPost, "https://things.com"+path, bytes.NewBufferString(body))
+		request.Header.Set("Content-Type", "application/json")
+		_, errs := v.ValidateRequestBody(request)
+		return errs
+	}
+
+	body := `[{"name": "Big Mac"}, {"patties": 2}, {"name": 3}]`
+
+	// items are validated one at a time, the failures are the same as validating the whole array.
+	streamed := validate("/burgers/streamed", body)
+	materialized := validate("/burgers/materialized", body)
+	assert.Len(t, streamed, 1)
+	assert.Len(t, materialized, 1)
+	assert.Len(t, streamed[0].SchemaValidationErrors, 2)
+
+	for i, failure := range streamed[0].SchemaValidationErrors {
+		assert.Equal(t, materialized[0].SchemaValidationErrors[i].Location, failure.Location)
+		assert.Equal(t, materialized[0].SchemaValidationErrors[i].Reason, failure.Reason)
+		assert.Equal(t, materialized[0].SchemaValidationErrors[i].ReferenceObject, failure.ReferenceObject)
+	}
+	assert.Equal(t, "/items/required", streamed[0].SchemaValidationErrors[0].Location)
+	assert.Equal(t, "{\n  \"patties\": 2\n}", streamed[0].SchemaValidationErrors[0].ReferenceObject)
+	assert.Equal(t, 4, streamed[0].SchemaValidationErrors[0].Line)
+
+	assert.Empty(t, validate("/burgers/streamed", `[{"name": "Big Mac"}]`))
+
+	errs := validate("/burgers/streamed", `[{"name": "Big Mac"}, {"name": `)
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "The request body cannot be decoded: unexpected end of JSON input", errs[0].Reason)
+}
+
+func TestValidateBody_ContentEncoding(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /burgers/createBurger:
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [name]
+              properties:
+                name:
+                  type: string`
+
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+	m, _ := doc.BuildV3Model()
+	v := NewRequestBodyValidator(&m.Model)
+
+	compress := func(body string) []byte {
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		_, _ = w.Write([]byte(body))
+		_ = w.Close()
+		return buf.Bytes()
+	}
+	buildRequest := func(body []byte, encoding string) *http.Request {
+		request, _ := http.NewRequest(http.MethodPost, "https://things.com/burgers/createBurger",
+			bytes.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("Content-Encoding", encoding)
+		return request
+	}
+
+	body := compress(`{"name": "Big Mac"}`)
+	request := buildRequest(body, "gzip")
+	valid, errs := v.ValidateRequestBody(request)
+	assert.True(t, valid)
+	assert.Len(t, errs, 0)
+
+	// the original compressed bytes are left on the request.
+	restored, _ := io.ReadAll(request.Body)
+	assert.Equal(t, body, restored)
+
+	valid, errs = v.ValidateRequestBody(buildRequest(compress(`{"name": 2}`), "gzip"))
+	assert.False(t, valid)
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "got number, want string", errs[0].SchemaValidationErrors[0].Reason)
+
+	// codings without a decoder cannot be validated.
+	valid, errs = v.ValidateRequestBody(buildRequest(body, "br"))
+	assert.False(t, valid)
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "POST request body for '/burgers/createBurger' cannot be decoded as 'application/json'",
+		errs[0].Message)
+	assert.Contains(t, errs[0].Reason, "the content encoding 'br' is not supported")
+	assert.Equal(t, "/burgers/createBurger", errs[0].SpecPath)
+
+	// a body that decompresses to more than the limit is rejected.
+	v = NewRequestBodyValidator(&m.Model, config.WithMaxDecompressedSize(64))
+	valid, errs = v.ValidateRequestBody(buildRequest(compress(`{"name": "`+strings.Repeat("0", 1024)+`"}`), "gzip"))
+	assert.False(t, valid)
+	assert.Len(t, errs, 1)
+	assert.True(t, errs[0].IsBodyTooLargeError())
+	assert.Contains(t, errs[0].Reason, "the decompressed body is larger than the limit of 64 bytes")
+}
+
+func TestValidateBody_Charset(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /burgers/createBurger:
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                name:
+                  enum: [Café Burger]
+          application/x-www-form-urlencoded:
+            schema:
+              type: object
+              properties:
+                name:
+                  type: string`
+
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+	m, _ := doc.BuildV3Model()
+	v := NewRequestBodyValidator(&m.Model)
+
+	buildRequest := func(body string, contentType string) *http.Request {
+		request, _ := http.NewRequest(http.MethodPost, "https://things.com/burgers/createBurger",
+			strings.NewReader(body))
+		request.Header.Set("Content-Type", contentType)
+		return request
+	}
+
+	// the body is transcoded to UTF-8 before it is decoded.
+	valid, errs := v.ValidateRequestBody(buildRequest("{\"name\": \"Caf\xe9 Burger\"}",
+		"application/json; charset=iso-8859-1"))
+	assert.True(t, valid)
+	assert.Len(t, errs, 0)
+
+	valid, errs = v.ValidateRequestBody(buildRequest("{\"name\": \"Caf\xe9 Burger\"}", "application/json"))
+	assert.False(t, valid)
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "POST request body for '/burgers/createBurger' cannot be decoded as 'application/json'",
+		errs[0].Message)
+	assert.Equal(t, "The request body cannot be decoded: the body is not valid UTF-8, the byte at offset 13 is invalid",
+		errs[0].Reason)
+	assert.Equal(t, "/burgers/createBurger", errs[0].SpecPath)
+
+	valid, errs = v.ValidateRequestBody(buildRequest(`{"name": "Café Burger"}`, "application/json; charset=x-burger"))
+	assert.False(t, valid)
+	assert.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Reason, "the charset 'x-burger' is not supported")
+
+	valid, errs = v.ValidateRequestBody(buildRequest("name=Big+Mac",
+		"application/x-www-form-urlencoded; charset=x-burger"))
+	assert.False(t, valid)
+	assert.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Reason, "the charset 'x-burger' is not supported")
+
+	// form values are transcoded once they have been unescaped.
+	valid, errs = v.ValidateRequestBody(buildRequest("name=Caf%E9+Burger",
+		"application/x-www-form-urlencoded; charset=iso-8859-1"))
+	assert.True(t, valid)
+	assert.Len(t, errs, 0)
+
+	valid, errs = v.ValidateRequestBody(buildRequest("name=Caf%E9+Burger", "application/x-www-form-urlencoded"))
+	assert.False(t, valid)
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "The request body cannot be decoded: the value of the form field 'name' cannot be decoded: "+
+		"the body is not valid UTF-8, the byte at offset 3 is invalid", errs[0].Reason)
+}
+
+func TestValidateBody_CompiledSchemaCache(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /burgers/createBurger:
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [name]`
+
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+	m, _ := doc.BuildV3Model()
+	v := NewRequestBodyValidator(&m.Model).(*requestBodyValidator)
+
+	validate := func(body string) bool {
+		request, _ := http.NewRequest(http.MethodPost, "https://things.com/burgers/createBurger",
+			bytes.NewBufferString(body))
+		request.Header.Set("Content-Type", "application/json")
+		valid, _ := v.ValidateRequestBody(request)
+		return valid
+	}
+
+	assert.True(t, validate(`{"name": "Big Mac"}`))
+
+	mediaType := m.Model.Paths.PathItems.GetOrZero("/burgers/createBurger").Post.RequestBody.Content.GetOrZero("application/json")
+	cached := v.renderSchema(mediaType.Schema)
+	jsch, err := cached.compile(context.Background(), v.options)
+	assert.NoError(t, err)
+	assert.NotNil(t, jsch)
+
+	// the compiled schema is reused by every request after the first.
+	assert.False(t, validate(`{"patties": 2}`))
+	assert.Same(t, cached.sharedSchema, v.renderSchema(mediaType.Schema).sharedSchema)
+	compiled, _ := cached.compile(context.Background(), v.options)
+	assert.Same(t, jsch, compiled)
+}
+
+type contextBodyDecoder struct {
+	ctx context.Context
+}
+
+func (d *contextBodyDecoder) Decode(body []byte, schema *base.Schema) (any, error) {
+	return d.DecodeContext(context.Background(), body, schema)
+}
+
+func (d *contextBodyDecoder) DecodeContext(ctx context.Context, body []byte, _ *base.Schema) (any, error) {
+	d.ctx = ctx
+	var decoded any
+	err := yaml.Unmarshal(body, &decoded)
+	return decoded, err
+}
+
+// cancelReader cancels the context of the validation when the body is first read.
+type cancelReader struct {
+	io.Reader
+	cancel context.CancelFunc
+}
+
+func (r *cancelReader) Read(p []byte) (int, error) {
+	r.cancel()
+	return r.Reader.Read(p)
+}
+
+func TestValidateBody_Context(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /burgers/createBurger:
+    post:
+      requestBody:
+        content:
+          application/yaml:
+            schema:
+              type: object
+              required: [name]
+              properties:
+                name:
+                  type: string
+          application/json:
+            schema:
+              type: object
+              required: [name]
+              properties:
+                name:
+                  type: string`
+
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+	m, _ := doc.BuildV3Model()
+
+	decoder := &contextBodyDecoder{}
+	v := NewRequestBodyValidator(&m.Model, config.WithBodyDecoder("application/yaml", decoder))
+
+	type key struct{}
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), key{}, "burger"))
+
+	request, _ := http.NewRequest(http.MethodPost, "https://things.com/burgers/createBurger",
+		bytes.NewBufferString("name: Big Mac\n"))
+	request.Header.Set("Content-Type", "application/yaml")
+	valid, errs := v.ValidateRequestBodyCtx(ctx, request)
+	assert.True(t, valid)
+	assert.Len(t, errs, 0)
+	assert.Equal(t, "burger", decoder.ctx.Value(key{}))
+
+	// the validation is cancelled while the body is read.
+	request, _ = http.NewRequest(http.MethodPost, "https://things.com/burgers/createBurger",
+		&cancelReader{Reader: strings.NewReader(`{"name": "Big Mac"}`), cancel: cancel})
+	request.Header.Set("Content-Type", "application/json")
+	valid, errs = v.ValidateRequestBodyCtx(ctx, request)
+	assert.False(t, valid)
+	assert.Len(t, errs, 1)
+	assert.True(t, errs[0].IsContextError())
+	assert.ErrorIs(t, errs[0], context.Canceled)
+	assert.Equal(t, "/burgers/createBurger", errs[0].RequestPath)
+
+	// the context is already done, nothing is validated.
+	request, _ = http.NewRequest(http.MethodPost, "https://things.com/burgers/createBurger",
+		bytes.NewBufferString("name: Big Mac\n"))
+	request.Header.Set("Content-Type", "application/yaml")
+	decoder.ctx = nil
+	valid, errs = v.ValidateRequestBodyWithPathItemCtx(ctx, request,
+		m.Model.Paths.PathItems.GetOrZero("/burgers/createBurger"), "/burgers/createBurger")
+	assert.False(t, valid)
+	assert.Len(t, errs, 1)
+	assert.True(t, errs[0].IsContextError())
+	assert.Nil(t, decoder.ctx)
+}
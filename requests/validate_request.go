@@ -5,6 +5,7 @@ package requests
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -12,7 +13,6 @@ import (
 	"reflect"
 	"regexp"
 	"strconv"
-	"strings"
 
 	"github.com/pb33f/libopenapi/datamodel/high/base"
 	"github.com/santhosh-tekuri/jsonschema/v6"
@@ -20,6 +20,7 @@ import (
 	"golang.org/x/text/message"
 	"gopkg.in/yaml.v3"
 
+	"github.com/pb33f/libopenapi-validator/config"
 	"github.com/pb33f/libopenapi-validator/errors"
 	"github.com/pb33f/libopenapi-validator/helpers"
 	"github.com/pb33f/libopenapi-validator/schema_validation"
@@ -29,49 +30,85 @@ var instanceLocationRegex = regexp.MustCompile(`^/(\d+)`)
 
 // ValidateRequestSchema will validate a http.Request pointer against a schema.
 // If validation fails, it will return a list of validation errors as the second return value.
+// Options can be supplied to configure the schema compiler.
 func ValidateRequestSchema(
 	request *http.Request,
 	schema *base.Schema,
 	renderedSchema,
 	jsonSchema []byte,
+	opts ...config.Option,
 ) (bool, []*errors.ValidationError) {
+	options := config.NewValidationOptions(opts...)
+	return validateRequestSchema(context.Background(), request, &schemaCache{
+		schema:       schema,
+		sharedSchema: &sharedSchema{renderedInline: renderedSchema, renderedJSON: jsonSchema},
+	}, options)
+}
+
+// validateRequestSchema validates a http.Request pointer against a schema, which is compiled the first time it is
+// needed. Validators supply a schema from their cache, so it is only compiled once.
+func validateRequestSchema(
+	ctx context.Context,
+	request *http.Request,
+	cached *schemaCache,
+	options *config.ValidationOptions,
+) (bool, []*errors.ValidationError) {
+	schema, renderedSchema, jsonSchema := cached.schema, cached.renderedInline, cached.renderedJSON
 	var validationErrors []*errors.ValidationError
 
 	var requestBody []byte
-	if request != nil && request.Body != nil {
-		requestBody, _ = io.ReadAll(request.Body)
-
-		// close the request body, so it can be re-read later by another player in the chain
-		_ = request.Body.Close()
-		request.Body = io.NopCloser(bytes.NewBuffer(requestBody))
-
+	if request != nil {
+		var readErr *errors.ValidationError
+		if requestBody, readErr = readRequestBody(ctx, request, options, ""); readErr != nil {
+			return false, []*errors.ValidationError{readErr}
+		}
 	}
 
 	var decodedObj interface{}
+	var elementPaths helpers.XMLPaths
 
 	if len(requestBody) > 0 {
-		err := json.Unmarshal(requestBody, &decodedObj)
-		if err != nil {
-			// cannot decode the request body, so it's not valid
-			violation := &errors.SchemaValidationFailure{
-				Reason:          err.Error(),
-				Location:        "unavailable",
-				ReferenceSchema: string(renderedSchema),
-				ReferenceObject: string(requestBody),
+		var err error
+		contentType := request.Header.Get(helpers.ContentTypeHeader)
+		_, charset, _ := helpers.ExtractContentType(contentType)
+		isXML := helpers.IsXMLContentType(contentType)
+		decoder := options.FindBodyDecoder(contentType)
+		if decoder == nil && (charset != "" || !isXML) {
+			// the built-in decoding works on UTF-8, so the body is transcoded from its charset first. An XML body
+			// without a charset is transcoded from the encoding declaration of the document instead.
+			var charsetErr *errors.ValidationError
+			if requestBody, charsetErr = decodeRequestCharset(request, requestBody, ""); charsetErr != nil {
+				return false, []*errors.ValidationError{charsetErr}
+			}
+		}
+		if decoder != nil {
+			// a registered decoder is used over the built-in decoding.
+			decodedObj, err = config.DecodeBody(ctx, decoder, requestBody, schema)
+		} else if isXML && charset == "" {
+			// XML bodies are decoded using the 'xml' objects of the schema.
+			decodedObj, elementPaths, err = helpers.DecodeXMLDocument(requestBody, schema)
+		} else if isXML {
+			decodedObj, elementPaths, err = helpers.DecodeXML(requestBody, schema)
+		} else if helpers.IsJSONArray(requestBody) && len(jsonSchema) > 0 {
+			// arrays are validated one item at a time when the schema allows it, so they are never materialized.
+			jsch, compileErr := cached.compile(ctx, options)
+			if compileErr == nil {
+				if items := helpers.StreamableItems(jsch); items != nil {
+					return validateRequestItems(request, schema, renderedSchema, items, requestBody)
+				}
+				err = json.Unmarshal(requestBody, &decodedObj)
+				if err == nil {
+					return validateRequestInstance(request, schema, renderedSchema, jsch, decodedObj, requestBody, nil,
+						nil)
+				}
+			} else {
+				err = json.Unmarshal(requestBody, &decodedObj)
 			}
-			validationErrors = append(validationErrors, &errors.ValidationError{
-				ValidationType:    helpers.RequestBodyValidation,
-				ValidationSubType: helpers.Schema,
-				Message: fmt.Sprintf("%s request body for '%s' failed to validate schema",
-					request.Method, request.URL.Path),
-				Reason:                 fmt.Sprintf("The request body cannot be decoded: %s", err.Error()),
-				SpecLine:               1,
-				SpecCol:                0,
-				SchemaValidationErrors: []*errors.SchemaValidationFailure{violation},
-				HowToFix:               errors.HowToFixInvalidSchema,
-				Context:                string(renderedSchema), // attach the rendered schema to the error
-			})
-			return false, validationErrors
+		} else {
+			err = json.Unmarshal(requestBody, &decodedObj)
+		}
+		if err != nil {
+			return false, []*errors.ValidationError{requestBodyDecodingError(request, renderedSchema, requestBody, err)}
 		}
 	}
 
@@ -106,117 +143,314 @@ func ValidateRequestSchema(
 		return false, validationErrors
 	}
 
-	compiler := jsonschema.NewCompiler()
-	compiler.UseLoader(helpers.NewCompilerLoader())
-	decodedSchema, _ := jsonschema.UnmarshalJSON(strings.NewReader(string(jsonSchema)))
-	_ = compiler.AddResource("requestBody.json", decodedSchema)
-	jsch, err := compiler.Compile("requestBody.json")
+	return validateRequestObject(ctx, request, cached, decodedObj, requestBody, elementPaths, nil, options)
+}
+
+// requestBodyDecodingError reports a request body that cannot be decoded, so it's not valid.
+func requestBodyDecodingError(request *http.Request, renderedSchema, requestBody []byte, err error) *errors.ValidationError {
+	violation := &errors.SchemaValidationFailure{
+		Reason:          err.Error(),
+		Location:        "unavailable",
+		ReferenceSchema: string(renderedSchema),
+		ReferenceObject: string(requestBody),
+	}
+	return &errors.ValidationError{
+		ValidationType:    helpers.RequestBodyValidation,
+		ValidationSubType: helpers.Schema,
+		Message: fmt.Sprintf("%s request body for '%s' failed to validate schema",
+			request.Method, request.URL.Path),
+		Reason:                 fmt.Sprintf("The request body cannot be decoded: %s", err.Error()),
+		SpecLine:               1,
+		SpecCol:                0,
+		SchemaValidationErrors: []*errors.SchemaValidationFailure{violation},
+		HowToFix:               errors.HowToFixInvalidSchema,
+		Context:                string(renderedSchema), // attach the rendered schema to the error
+	}
+}
+
+// validateRequestObject validates a request body that has already been decoded into an object against a schema.
+// The raw body is used as the reference object of any schema violations. If the body was decoded from XML, the
+// element paths are used to locate the element of each violation.
+func validateRequestObject(
+	ctx context.Context,
+	request *http.Request,
+	cached *schemaCache,
+	decodedObj any,
+	requestBody []byte,
+	elementPaths helpers.XMLPaths,
+	skip func(instanceLocation string) bool,
+	options *config.ValidationOptions,
+) (bool, []*errors.ValidationError) {
+	var validationErrors []*errors.ValidationError
+
+	jsch, err := cached.compile(ctx, options)
 	if err != nil {
 		validationErrors = append(validationErrors, &errors.ValidationError{
 			ValidationType:    helpers.RequestBodyValidation,
 			ValidationSubType: helpers.Schema,
 			Message:           err.Error(),
 			Reason:            "Failed to compile the request body schema.",
-			Context:           string(jsonSchema),
+			Context:           string(cached.renderedJSON),
 		})
 		return false, validationErrors
 	}
+	return validateRequestInstance(request, cached.schema, cached.renderedInline, jsch, decodedObj, requestBody,
+		elementPaths, skip)
+}
 
+// validateRequestInstance validates a decoded request body against a schema that has already been compiled. Failures
+// at an instance location that skip returns true for are not reported, skip may be nil.
+func validateRequestInstance(
+	request *http.Request,
+	schema *base.Schema,
+	renderedSchema []byte,
+	jsch *jsonschema.Schema,
+	decodedObj any,
+	requestBody []byte,
+	elementPaths helpers.XMLPaths,
+	skip func(instanceLocation string) bool,
+) (bool, []*errors.ValidationError) {
 	// validate the object against the schema
 	scErrs := jsch.Validate(decodedObj)
-	if scErrs != nil {
+	if scErrs == nil {
+		return true, nil
+	}
 
-		jk := scErrs.(*jsonschema.ValidationError)
+	// extract the element specified by the instance
+	reference := func(instanceLocation string) string {
+		val := instanceLocationRegex.FindStringSubmatch(instanceLocation)
+		if len(val) > 0 {
+			referenceIndex, _ := strconv.Atoi(val[1])
+			if reflect.ValueOf(decodedObj).Type().Kind() == reflect.Slice {
+				found := decodedObj.([]any)[referenceIndex]
+				recoded, _ := json.MarshalIndent(found, "", "  ")
+				return string(recoded)
+			}
+		}
+		return string(requestBody)
+	}
+	violations := schemaValidationFailures(scErrs.(*jsonschema.ValidationError), renderedSchema, "", reference,
+		elementPaths, skip)
+	if len(violations) == 0 && skip != nil {
+		return true, nil
+	}
+	return false, []*errors.ValidationError{requestSchemaError(request, schema, renderedSchema, violations)}
+}
 
-		// flatten the validationErrors
-		schFlatErrs := jk.BasicOutput().Errors
-		var schemaValidationErrors []*errors.SchemaValidationFailure
-		for q := range schFlatErrs {
-			er := schFlatErrs[q]
+// validateRequestItems validates the items of a JSON array request body one at a time, against the compiled items
+// schema, as they are decoded. The array is never fully materialized, only a single item is held in memory.
+func validateRequestItems(
+	request *http.Request,
+	schema *base.Schema,
+	renderedSchema []byte,
+	items *jsonschema.Schema,
+	requestBody []byte,
+) (bool, []*errors.ValidationError) {
+	var violations []*errors.SchemaValidationFailure
+	var failed bool
+	err := helpers.DecodeJSONArray(bytes.NewReader(requestBody), func(index int, item any) {
+		scErrs := items.Validate(item)
+		if scErrs == nil {
+			return
+		}
+		failed = true
+		reference := func(string) string {
+			recoded, _ := json.MarshalIndent(item, "", "  ")
+			return string(recoded)
+		}
+		// the item was validated on its own, so the locations are relative to the items schema.
+		violations = append(violations, schemaValidationFailures(scErrs.(*jsonschema.ValidationError),
+			renderedSchema, "/items", reference, nil, nil)...)
+	})
+	if err != nil {
+		return false, []*errors.ValidationError{requestBodyDecodingError(request, renderedSchema, requestBody, err)}
+	}
+	if !failed {
+		return true, nil
+	}
+	return false, []*errors.ValidationError{requestSchemaError(request, schema, renderedSchema, violations)}
+}
 
-			errMsg := er.Error.Kind.LocalizedString(message.NewPrinter(language.Tag{}))
+// schemaValidationFailures flattens a schema validation error into schema validation failures. The keyword
+// locations are prefixed with the keyword prefix, so a sub-schema that was validated on its own is located within
+// the rendered schema. The reference object of each failure is looked up using the instance location.
+func schemaValidationFailures(
+	jk *jsonschema.ValidationError,
+	renderedSchema []byte,
+	keywordPrefix string,
+	reference func(instanceLocation string) string,
+	elementPaths helpers.XMLPaths,
+	skip func(instanceLocation string) bool,
+) []*errors.SchemaValidationFailure {
+	// flatten the validationErrors
+	schFlatErrs := jk.BasicOutput().Errors
+	var schemaValidationErrors []*errors.SchemaValidationFailure
+	for q := range schFlatErrs {
+		er := schFlatErrs[q]
 
-			if er.KeywordLocation == "" || helpers.IgnoreRegex.MatchString(errMsg) {
-				continue // ignore this error, it's useless tbh, utter noise.
-			}
-			if er.Error != nil {
+		errMsg := er.Error.Kind.LocalizedString(message.NewPrinter(language.Tag{}))
 
-				// re-encode the schema.
-				var renderedNode yaml.Node
-				_ = yaml.Unmarshal(renderedSchema, &renderedNode)
+		if er.KeywordLocation == "" || helpers.IgnoreRegex.MatchString(errMsg) {
+			continue // ignore this error, it's useless tbh, utter noise.
+		}
+		if skip != nil && skip(er.InstanceLocation) {
+			continue
+		}
+		if er.Error != nil {
+			keywordLocation := keywordPrefix + er.KeywordLocation
 
-				// locate the violated property in the schema
-				located := schema_validation.LocateSchemaPropertyNodeByJSONPath(renderedNode.Content[0], er.KeywordLocation)
+			// re-encode the schema.
+			var renderedNode yaml.Node
+			_ = yaml.Unmarshal(renderedSchema, &renderedNode)
 
-				// extract the element specified by the instance
-				val := instanceLocationRegex.FindStringSubmatch(er.InstanceLocation)
-				var referenceObject string
+			// locate the violated property in the schema
+			located := schema_validation.LocateSchemaPropertyNodeByJSONPath(renderedNode.Content[0], keywordLocation)
 
-				if len(val) > 0 {
-					referenceIndex, _ := strconv.Atoi(val[1])
-					if reflect.ValueOf(decodedObj).Type().Kind() == reflect.Slice {
-						found := decodedObj.([]any)[referenceIndex]
-						recoded, _ := json.MarshalIndent(found, "", "  ")
-						referenceObject = string(recoded)
-					}
-				}
-				if referenceObject == "" {
-					referenceObject = string(requestBody)
-				}
-
-				errMsg := er.Error.Kind.LocalizedString(message.NewPrinter(language.Tag{}))
+			violation := &errors.SchemaValidationFailure{
+				Reason:          errMsg,
+				Location:        keywordLocation,
+				ReferenceSchema: string(renderedSchema),
+				ReferenceObject: reference(er.InstanceLocation),
+				OriginalError:   jk,
+			}
+			if elementPaths != nil {
+				violation.ElementPath = elementPaths.Find(er.InstanceLocation)
+			}
+			// if we have a location within the schema, add it to the error
+			if located != nil {
 
-				violation := &errors.SchemaValidationFailure{
-					Reason:          errMsg,
-					Location:        er.KeywordLocation,
-					ReferenceSchema: string(renderedSchema),
-					ReferenceObject: referenceObject,
-					OriginalError:   jk,
-				}
-				// if we have a location within the schema, add it to the error
-				if located != nil {
-
-					line := located.Line
-					// if the located node is a map or an array, then the actual human interpretable
-					// line on which the violation occurred is the line of the key, not the value.
-					if located.Kind == yaml.MappingNode || located.Kind == yaml.SequenceNode {
-						if line > 0 {
-							line--
-						}
+				line := located.Line
+				// if the located node is a map or an array, then the actual human interpretable
+				// line on which the violation occurred is the line of the key, not the value.
+				if located.Kind == yaml.MappingNode || located.Kind == yaml.SequenceNode {
+					if line > 0 {
+						line--
 					}
-
-					// location of the violation within the rendered schema.
-					violation.Line = line
-					violation.Column = located.Column
 				}
-				schemaValidationErrors = append(schemaValidationErrors, violation)
+
+				// location of the violation within the rendered schema.
+				violation.Line = line
+				violation.Column = located.Column
 			}
+			schemaValidationErrors = append(schemaValidationErrors, violation)
 		}
+	}
+	return schemaValidationErrors
+}
 
-		line := 1
-		col := 0
-		if schema.GoLow().Type.KeyNode != nil {
-			line = schema.GoLow().Type.KeyNode.Line
-			col = schema.GoLow().Type.KeyNode.Column
-		}
+// requestSchemaError wraps the schema validation failures of a request body.
+func requestSchemaError(
+	request *http.Request,
+	schema *base.Schema,
+	renderedSchema []byte,
+	violations []*errors.SchemaValidationFailure,
+) *errors.ValidationError {
+	line := 1
+	col := 0
+	if schema.GoLow().Type.KeyNode != nil {
+		line = schema.GoLow().Type.KeyNode.Line
+		col = schema.GoLow().Type.KeyNode.Column
+	}
 
-		// add the error to the list
-		validationErrors = append(validationErrors, &errors.ValidationError{
-			ValidationType:    helpers.RequestBodyValidation,
-			ValidationSubType: helpers.Schema,
-			Message: fmt.Sprintf("%s request body for '%s' failed to validate schema",
-				request.Method, request.URL.Path),
-			Reason: "The request body is defined as an object. " +
-				"However, it does not meet the schema requirements of the specification",
-			SpecLine:               line,
-			SpecCol:                col,
-			SchemaValidationErrors: schemaValidationErrors,
-			HowToFix:               errors.HowToFixInvalidSchema,
-			Context:                string(renderedSchema), // attach the rendered schema to the error
-		})
+	return &errors.ValidationError{
+		ValidationType:    helpers.RequestBodyValidation,
+		ValidationSubType: helpers.Schema,
+		Message: fmt.Sprintf("%s request body for '%s' failed to validate schema",
+			request.Method, request.URL.Path),
+		Reason: "The request body is defined as an object. " +
+			"However, it does not meet the schema requirements of the specification",
+		SpecLine:               line,
+		SpecCol:                col,
+		SchemaValidationErrors: violations,
+		HowToFix:               errors.HowToFixInvalidSchema,
+		Context:                string(renderedSchema), // attach the rendered schema to the error
 	}
-	if len(validationErrors) > 0 {
-		return false, validationErrors
+}
+
+// readRequestBody reads the whole request body, as long as it is no larger than the maximum body size, and replaces
+// the body, so it can be re-read later by another player in the chain. A body that is too large is left unread past
+// the limit, and a body that does not match its content length is reported. The returned bytes have been
+// decompressed according to the Content-Encoding header. Reading stops once the context is done.
+func readRequestBody(
+	ctx context.Context,
+	request *http.Request,
+	options *config.ValidationOptions,
+	specPath string,
+) ([]byte, *errors.ValidationError) {
+	return readRequestBodyWithLimit(ctx, request, options, options.MaxBodySize, false, specPath)
+}
+
+// readMultipartBody reads the whole request body in the same way as readRequestBody, the multipart memory limit
+// applies when it is smaller than the maximum body size.
+func readMultipartBody(
+	ctx context.Context,
+	request *http.Request,
+	options *config.ValidationOptions,
+	specPath string,
+) ([]byte, *errors.ValidationError) {
+	limit := options.MultipartMemoryLimit
+	if limit <= 0 || (options.MaxBodySize > 0 && options.MaxBodySize <= limit) {
+		return readRequestBody(ctx, request, options, specPath)
+	}
+	return readRequestBodyWithLimit(ctx, request, options, limit, true, specPath)
+}
+
+// readRequestBodyWithLimit reads the whole request body, as long as it is no larger than the limit. The limit is the
+// multipart memory limit, rather than the maximum body size, when multipart is true.
+func readRequestBodyWithLimit(
+	ctx context.Context,
+	request *http.Request,
+	options *config.ValidationOptions,
+	limit int64,
+	multipart bool,
+	specPath string,
+) ([]byte, *errors.ValidationError) {
+	if request.Body == nil {
+		return nil, nil
+	}
+	requestBody, err := helpers.ReadBody(helpers.NewContextReader(ctx, request.Body), limit, request.ContentLength)
+	if tooLarge, ok := err.(*helpers.BodyTooLargeError); ok {
+		tooLarge.Multipart = multipart
+		// leave the unread remainder of the body in place, so it can still be consumed.
+		request.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(requestBody), request.Body), request.Body}
+		return requestBody, errors.RequestBodyTooLarge(request, tooLarge, specPath)
+	}
+
+	// close the request body, so it can be re-read later by another player in the chain
+	_ = request.Body.Close()
+	request.Body = io.NopCloser(bytes.NewBuffer(requestBody))
+
+	if mismatch, ok := err.(*helpers.ContentLengthError); ok {
+		return requestBody, errors.RequestContentLengthMismatch(request, mismatch, specPath)
+	}
+
+	// the original bytes are left on the request, only the copy that is validated is decompressed.
+	decoded, err := helpers.DecodeContent(ctx, requestBody, request.Header.Get(helpers.ContentEncodingHeader), options)
+	if err != nil {
+		return nil, requestContentEncodingError(request, err, specPath)
+	}
+	return decoded, nil
+}
+
+// decodeRequestCharset transcodes a request body from the charset of its content type to UTF-8, and checks that
+// a UTF-8 body is valid.
+func decodeRequestCharset(request *http.Request, requestBody []byte, specPath string) ([]byte, *errors.ValidationError) {
+	contentType := request.Header.Get(helpers.ContentTypeHeader)
+	_, charset, _ := helpers.ExtractContentType(contentType)
+	decoded, err := helpers.DecodeCharset(requestBody, charset)
+	if err != nil {
+		return nil, errors.RequestBodyCannotBeDecoded(request, contentType, err.Error(), specPath)
+	}
+	return decoded, nil
+}
+
+// requestContentEncodingError converts an error from decompressing a request body into a validation error.
+func requestContentEncodingError(request *http.Request, err error, specPath string) *errors.ValidationError {
+	if tooLarge, ok := err.(*helpers.BodyTooLargeError); ok {
+		return errors.RequestBodyTooLarge(request, tooLarge, specPath)
 	}
-	return true, nil
+	return errors.RequestBodyCannotBeDecoded(request, request.Header.Get(helpers.ContentTypeHeader), err.Error(), specPath)
 }
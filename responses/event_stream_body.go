@@ -0,0 +1,190 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package responses
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+
+	"github.com/pb33f/libopenapi-validator/errors"
+	"github.com/pb33f/libopenapi-validator/helpers"
+)
+
+// validateEventStreamBody reads a 'text/event-stream' response body one event at a time, and validates each event.
+// The data of each event is decoded as JSON and validated against the schema, or against the items schema when the
+// schema is an array. When the schema describes the event itself, with a 'data' property, the 'event', 'id' and
+// 'retry' fields are validated along with the data. Each event is passed to the callback (if there is one) as soon
+// as it has been validated. With a callback, or when config.WithStreamingBodies is used, the body is consumed by
+// validation and never held in memory. Otherwise, the bytes that are read are kept, and the body is restored once it
+// has been validated.
+func (v *responseBodyValidator) validateEventStreamBody(
+	ctx context.Context,
+	request *http.Request,
+	response *http.Response,
+	cached *schemaCache,
+	pathFound string,
+	callback EventStreamCallback,
+) []*errors.ValidationError {
+	if response.Body == nil || response.Body == http.NoBody {
+		return nil
+	}
+	// a callback consumes the events as they arrive, otherwise the body can be read again once validated.
+	source := io.Reader(response.Body)
+	if v.options.StreamBodies || callback != nil {
+		defer func() {
+			_ = response.Body.Close()
+			response.Body = http.NoBody
+		}()
+	} else {
+		var read bytes.Buffer
+		source = io.TeeReader(response.Body, &read)
+		defer func() {
+			response.Body = helpers.ReplayBody(read.Bytes(), response.Body)
+		}()
+	}
+
+	var schema *base.Schema
+	var renderedInline []byte
+	var jsch *jsonschema.Schema
+	var envelope, rawData bool
+	if cached != nil {
+		// each event is an item of the array the stream represents.
+		if s := cached.schema; slices.Contains(s.Type, helpers.Array) && s.Items != nil && s.Items.IsA() {
+			cached = v.renderSchema(s.Items.A)
+		}
+		schema, renderedInline = cached.schema, cached.renderedInline
+
+		// a schema with a 'data' property describes the whole event, not just the data. string data is not decoded.
+		if schema.Properties != nil {
+			if data := schema.Properties.GetOrZero("data"); data != nil {
+				envelope = true
+				dataSchema := data.Schema()
+				rawData = dataSchema != nil && len(dataSchema.Type) == 1 && dataSchema.Type[0] == helpers.String
+			}
+		}
+
+		// the schema is compiled once for the whole stream.
+		var err error
+		jsch, err = cached.compile(ctx, v.options)
+		if err != nil {
+			return []*errors.ValidationError{{
+				ValidationType:    helpers.ResponseBodyValidation,
+				ValidationSubType: helpers.Schema,
+				Message:           err.Error(),
+				Reason:            "Failed to compile the response body schema.",
+				Context:           string(cached.renderedJSON),
+			}}
+		}
+	}
+
+	var validationErrors []*errors.ValidationError
+	// the maximum body size and the content length apply, even when the body is not held in memory.
+	body := helpers.NewLimitedReader(helpers.NewContextReader(ctx, source), v.options.MaxBodySize)
+	content, err := helpers.NewContentReader(ctx, body, response.Header.Get(helpers.ContentEncodingHeader), v.options)
+	if err != nil {
+		return []*errors.ValidationError{responseContentEncodingError(request, response, err)}
+	}
+	events := helpers.NewEventStreamReader(content, v.options.MaxRecordSize)
+	for {
+		event, readErr := events.Next()
+		if readErr == io.EOF {
+			contentLength := responseContentLength(request, response)
+			if mismatch, ok := body.CheckContentLength(contentLength).(*helpers.ContentLengthError); ok {
+				validationErrors = append(validationErrors,
+					errors.ResponseContentLengthMismatch(request, response, mismatch))
+			}
+			break
+		}
+		if tooLarge, ok := readErr.(*helpers.BodyTooLargeError); ok {
+			validationErrors = append(validationErrors, errors.ResponseBodyTooLarge(request, response, tooLarge))
+			break
+		}
+		index := events.Index()
+		if _, skipped := readErr.(*helpers.RecordTooLargeError); skipped {
+			decodeErr := errors.ResponseBodyCannotBeDecoded(request, response, helpers.EventStreamContentType,
+				readErr.Error())
+			decodeErr.RecordIndex = &index
+			validationErrors = append(validationErrors, decodeErr)
+			continue
+		}
+		if readErr != nil {
+			// the body itself could not be read, there is nothing more to validate.
+			validationErrors = append(validationErrors, errors.ResponseBodyCannotBeDecoded(request, response,
+				helpers.EventStreamContentType, readErr.Error()))
+			break
+		}
+
+		var eventErrors []*errors.ValidationError
+		if jsch != nil {
+			eventErrors = validateEvent(request, response, schema, renderedInline, jsch, event, index, envelope,
+				rawData)
+			errors.PopulateValidationErrors(eventErrors, request, pathFound)
+			validationErrors = append(validationErrors, eventErrors...)
+		}
+		if callback != nil && !callback(event, eventErrors) {
+			break
+		}
+	}
+	return validationErrors
+}
+
+// validateEvent validates a single event of an event stream against a compiled schema. The index of the event is
+// added to each validation error.
+func validateEvent(
+	request *http.Request,
+	response *http.Response,
+	schema *base.Schema,
+	renderedInline []byte,
+	jsch *jsonschema.Schema,
+	event *helpers.ServerSentEvent,
+	index int,
+	envelope,
+	rawData bool,
+) []*errors.ValidationError {
+	var data any = event.Data
+	if !rawData {
+		if err := json.Unmarshal([]byte(event.Data), &data); err != nil {
+			if !envelope {
+				decodeErr := errors.ResponseBodyCannotBeDecoded(request, response, helpers.EventStreamContentType,
+					fmt.Sprintf("the data of event %d is not valid JSON: %s", index, err.Error()))
+				decodeErr.RecordIndex = &index
+				return []*errors.ValidationError{decodeErr}
+			}
+			// the schema of the 'data' property decides if plain text is acceptable.
+			data = event.Data
+		}
+	}
+
+	instance := data
+	if envelope {
+		fields := map[string]any{"data": data}
+		if event.Event != "" {
+			fields["event"] = event.Event
+		}
+		if event.ID != "" {
+			fields["id"] = event.ID
+		}
+		if event.Retry >= 0 {
+			fields["retry"] = float64(event.Retry)
+		}
+		instance = fields
+	}
+
+	_, eventErrors := validateResponseInstance(request, response, schema, renderedInline, jsch, instance,
+		[]byte(event.Data), nil)
+	for _, eventErr := range eventErrors {
+		eventErr.Message = fmt.Sprintf("%d response body event %d for '%s' failed to validate schema",
+			response.StatusCode, index, request.URL.Path)
+		eventErr.RecordIndex = &index
+	}
+	return eventErrors
+}
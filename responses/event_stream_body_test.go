@@ -0,0 +1,262 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package responses
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pb33f/libopenapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pb33f/libopenapi-validator/config"
+	"github.com/pb33f/libopenapi-validator/errors"
+	"github.com/pb33f/libopenapi-validator/helpers"
+)
+
+const eventStreamSpec = `openapi: 3.1.0
+paths:
+  /burgers/orders:
+    get:
+      responses:
+        '200':
+          content:
+            text/event-stream:
+              schema:
+                type: object
+                required: [name]
+                properties:
+                  name:
+                    type: string
+  /burgers/kitchen:
+    get:
+      responses:
+        '200':
+          content:
+            text/event-stream:
+              schema:
+                type: object
+                required: [event, data]
+                properties:
+                  event:
+                    enum: [cooked, served]
+                  id:
+                    type: string
+                  retry:
+                    type: integer
+                    maximum: 5000
+                  data:
+                    type: string`
+
+func eventStreamValidator(t *testing.T) ResponseBodyValidator {
+	doc, err := libopenapi.NewDocument([]byte(eventStreamSpec))
+	require.NoError(t, err)
+	m, errs := doc.BuildV3Model()
+	require.Empty(t, errs)
+	return NewResponseBodyValidator(&m.Model)
+}
+
+func eventStreamResponse(body string) *http.Response {
+	res := httptest.NewRecorder()
+	res.Header().Set(helpers.ContentTypeHeader, helpers.EventStreamContentType)
+	res.WriteHeader(http.StatusOK)
+	_, _ = res.Write([]byte(body))
+	return res.Result()
+}
+
+func TestValidateBody_EventStream(t *testing.T) {
+	v := eventStreamValidator(t)
+	request, _ := http.NewRequest(http.MethodGet, "https://things.com/burgers/orders", nil)
+
+	// a recorded stream is validated after the fact.
+	body := "data: {\"name\": \"Big Mac\"}\n\n: ping\n\ndata: {\"name\": \"Whopper\"}\n\n"
+	response := eventStreamResponse(body)
+	valid, errs := v.ValidateResponseBody(request, response)
+	assert.True(t, valid)
+	assert.Len(t, errs, 0)
+
+	// the body is restored, so it can be read again.
+	restored, _ := io.ReadAll(response.Body)
+	assert.Equal(t, body, string(restored))
+
+	valid, errs = v.ValidateResponseBody(request, eventStreamResponse(
+		"data: {\"name\": \"Big Mac\"}\n\ndata: {\"patties\": 2}\n\ndata: not json\n\n"))
+	assert.False(t, valid)
+	require.Len(t, errs, 2)
+
+	require.NotNil(t, errs[0].RecordIndex)
+	assert.Equal(t, 1, *errs[0].RecordIndex)
+	assert.Equal(t, "200 response body event 1 for '/burgers/orders' failed to validate schema", errs[0].Message)
+	assert.Equal(t, "missing property 'name'", errs[0].SchemaValidationErrors[0].Reason)
+	assert.Equal(t, "/burgers/orders", errs[0].SpecPath)
+
+	require.NotNil(t, errs[1].RecordIndex)
+	assert.Equal(t, 2, *errs[1].RecordIndex)
+	assert.Equal(t, "200 response body for '/burgers/orders' cannot be decoded as 'text/event-stream'", errs[1].Message)
+	assert.Contains(t, errs[1].Reason, "the data of event 2 is not valid JSON")
+}
+
+func TestValidateBody_EventStream_ContentEncoding(t *testing.T) {
+	v := eventStreamValidator(t)
+	request, _ := http.NewRequest(http.MethodGet, "https://things.com/burgers/orders", nil)
+
+	var body bytes.Buffer
+	w := gzip.NewWriter(&body)
+	_, _ = w.Write([]byte("data: {\"name\": \"Big Mac\"}\n\ndata: {\"patties\": 2}\n\n"))
+	_ = w.Close()
+
+	response := eventStreamResponse(body.String())
+	response.Header.Set(helpers.ContentEncodingHeader, "gzip")
+	valid, errs := v.ValidateResponseBody(request, response)
+	assert.False(t, valid)
+	require.Len(t, errs, 1)
+	require.NotNil(t, errs[0].RecordIndex)
+	assert.Equal(t, 1, *errs[0].RecordIndex)
+
+	// a coding without a decoder stops validation before the stream is read.
+	response = eventStreamResponse(body.String())
+	response.Header.Set(helpers.ContentEncodingHeader, "br")
+	valid, errs = v.ValidateResponseBody(request, response)
+	assert.False(t, valid)
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Reason, "the content encoding 'br' is not supported")
+}
+
+func TestValidateBody_EventStream_Fields(t *testing.T) {
+	v := eventStreamValidator(t)
+	request, _ := http.NewRequest(http.MethodGet, "https://things.com/burgers/kitchen", nil)
+
+	// the schema describes the whole event, so the fields are validated, and the data is plain text.
+	valid, errs := v.ValidateResponseBody(request, eventStreamResponse(
+		"event: cooked\nid: 1\ndata: Big Mac\n\nevent: burnt\nretry: 9000\ndata: Whopper\n\ndata: Zinger\n\n"))
+	assert.False(t, valid)
+	require.Len(t, errs, 2)
+
+	assert.Equal(t, 1, *errs[0].RecordIndex)
+	require.Len(t, errs[0].SchemaValidationErrors, 2)
+	assert.ElementsMatch(t, []string{"/properties/event/enum", "/properties/retry/maximum"},
+		[]string{errs[0].SchemaValidationErrors[0].Location, errs[0].SchemaValidationErrors[1].Location})
+
+	assert.Equal(t, 2, *errs[1].RecordIndex)
+	assert.Equal(t, "missing property 'event'", errs[1].SchemaValidationErrors[0].Reason)
+}
+
+func TestValidateBody_EventStream_StreamBodies(t *testing.T) {
+	doc, _ := libopenapi.NewDocument([]byte(eventStreamSpec))
+	m, _ := doc.BuildV3Model()
+	v := NewResponseBodyValidator(&m.Model, config.WithStreamingBodies())
+	request, _ := http.NewRequest(http.MethodGet, "https://things.com/burgers/orders", nil)
+
+	response := eventStreamResponse("data: {\"name\": \"Big Mac\"}\n\n")
+	valid, errs := v.ValidateResponseBody(request, response)
+	assert.True(t, valid)
+	assert.Len(t, errs, 0)
+
+	// the body is streamed through validation, it is not restored.
+	assert.Equal(t, http.NoBody, response.Body)
+}
+
+func TestValidateBody_EventStream_EventTooLarge(t *testing.T) {
+	doc, _ := libopenapi.NewDocument([]byte(eventStreamSpec))
+	m, _ := doc.BuildV3Model()
+	v := NewResponseBodyValidator(&m.Model, config.WithMaxRecordSize(32))
+	request, _ := http.NewRequest(http.MethodGet, "https://things.com/burgers/orders", nil)
+
+	// the event that is too large is skipped, the events that follow it are still validated.
+	valid, errs := v.ValidateResponseBody(request, eventStreamResponse(
+		"data: {\"name\": \"Big Mac\"}\n\ndata: {\"name\": \""+strings.Repeat("x", 64)+"\"}\n\ndata: {}\n\n"))
+	assert.False(t, valid)
+	require.Len(t, errs, 2)
+	require.NotNil(t, errs[0].RecordIndex)
+	assert.Equal(t, 1, *errs[0].RecordIndex)
+	assert.Contains(t, errs[0].Reason, "record 1 is larger than the limit of 32 bytes")
+	require.NotNil(t, errs[1].RecordIndex)
+	assert.Equal(t, 2, *errs[1].RecordIndex)
+	assert.Equal(t, "missing property 'name'", errs[1].SchemaValidationErrors[0].Reason)
+}
+
+func TestValidateResponseEventStream(t *testing.T) {
+	v := eventStreamValidator(t)
+	request, _ := http.NewRequest(http.MethodGet, "https://things.com/burgers/orders", nil)
+
+	// events are validated as they arrive, the writer waits for each one to be read.
+	reader, writer := io.Pipe()
+	response := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{helpers.ContentTypeHeader: []string{helpers.EventStreamContentType}},
+		Body:       reader,
+	}
+	go func() {
+		_, _ = writer.Write([]byte("data: {\"name\": \"Big Mac\"}\n\n"))
+		_, _ = writer.Write([]byte("data: {\"patties\": 2}\n\n"))
+		_, _ = writer.Write([]byte("data: {\"name\": \"Whopper\"}\n\n"))
+		_ = writer.Close()
+	}()
+
+	var received []string
+	var receivedErrs [][]*errors.ValidationError
+	valid, errs := v.ValidateResponseEventStream(request, response,
+		func(event *helpers.ServerSentEvent, eventErrs []*errors.ValidationError) bool {
+			received = append(received, event.Data)
+			receivedErrs = append(receivedErrs, eventErrs)
+			return true
+		})
+	assert.False(t, valid)
+	require.Len(t, errs, 1)
+	assert.Equal(t, []string{`{"name": "Big Mac"}`, `{"patties": 2}`, `{"name": "Whopper"}`}, received)
+	assert.Empty(t, receivedErrs[0])
+	require.Len(t, receivedErrs[1], 1)
+	assert.Same(t, errs[0], receivedErrs[1][0])
+	assert.Equal(t, "/burgers/orders", receivedErrs[1][0].SpecPath)
+	assert.Empty(t, receivedErrs[2])
+
+	// the events have been consumed by the callback.
+	assert.Equal(t, http.NoBody, response.Body)
+
+	// returning false from the callback stops reading the stream.
+	received = nil
+	valid, errs = v.ValidateResponseEventStream(request,
+		eventStreamResponse("data: {\"name\": \"Big Mac\"}\n\ndata: {}\n\n"),
+		func(event *helpers.ServerSentEvent, _ []*errors.ValidationError) bool {
+			received = append(received, event.Data)
+			return false
+		})
+	assert.True(t, valid)
+	assert.Len(t, errs, 0)
+	assert.Len(t, received, 1)
+}
+
+func TestValidateBody_EventStreamCtx(t *testing.T) {
+	v := eventStreamValidator(t)
+	request, _ := http.NewRequest(http.MethodGet, "https://things.com/burgers/orders", nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	valid, errs := v.ValidateResponseEventStreamCtx(ctx, request, eventStreamResponse(
+		"data: {\"name\": \"Big Mac\"}\n\n"), nil)
+	assert.True(t, valid)
+	assert.Len(t, errs, 0)
+
+	// the stream is cancelled by the first event, the invalid event that follows is not reported.
+	var events int
+	valid, errs = v.ValidateResponseEventStreamCtx(ctx, request, eventStreamResponse(
+		"data: {\"name\": \"Big Mac\"}\n\ndata: {\"nom\": \"Whopper\"}\n\n"),
+		func(*helpers.ServerSentEvent, []*errors.ValidationError) bool {
+			events++
+			cancel()
+			return true
+		})
+	assert.False(t, valid)
+	require.Len(t, errs, 1)
+	assert.True(t, errs[0].IsContextError())
+	assert.ErrorIs(t, errs[0], context.Canceled)
+	assert.GreaterOrEqual(t, events, 1)
+}
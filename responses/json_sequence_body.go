@@ -0,0 +1,116 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package responses
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+
+	"github.com/pb33f/libopenapi-validator/errors"
+	"github.com/pb33f/libopenapi-validator/helpers"
+)
+
+// validateJSONSequenceBody validates a newline delimited JSON, JSON Lines or JSON text sequence response body one
+// record at a time. When the schema is an array, each record is validated against the items schema, otherwise each
+// record is validated against the schema itself. Records are streamed from the body, one at a time. The bytes that
+// are read are kept, and the body is restored once it has been validated, unless config.WithStreamingBodies is
+// used, in which case the body is never held in memory, and is consumed by validation.
+func (v *responseBodyValidator) validateJSONSequenceBody(
+	ctx context.Context,
+	request *http.Request,
+	response *http.Response,
+	cached *schemaCache,
+	contentType string,
+) []*errors.ValidationError {
+	if response.Body == nil || response.Body == http.NoBody {
+		return nil
+	}
+
+	// unless bodies are streamed, the bytes that are read are kept, so the body can be read again once validated.
+	source := io.Reader(response.Body)
+	if v.options.StreamBodies {
+		defer func() {
+			_ = response.Body.Close()
+			response.Body = http.NoBody
+		}()
+	} else {
+		var read bytes.Buffer
+		source = io.TeeReader(response.Body, &read)
+		defer func() {
+			response.Body = helpers.ReplayBody(read.Bytes(), response.Body)
+		}()
+	}
+
+	// each record is an item of the array the stream represents.
+	if schema := cached.schema; slices.Contains(schema.Type, helpers.Array) && schema.Items != nil && schema.Items.IsA() {
+		cached = v.renderSchema(schema.Items.A)
+	}
+	schema, renderedInline := cached.schema, cached.renderedInline
+
+	// the schema is compiled once for the whole stream.
+	jsch, err := cached.compile(ctx, v.options)
+	if err != nil {
+		return []*errors.ValidationError{{
+			ValidationType:    helpers.ResponseBodyValidation,
+			ValidationSubType: helpers.Schema,
+			Message:           err.Error(),
+			Reason:            "Failed to compile the response body schema.",
+			Context:           string(cached.renderedJSON),
+		}}
+	}
+
+	var validationErrors []*errors.ValidationError
+	// the maximum body size and the content length apply, even when the body is not held in memory.
+	body := helpers.NewLimitedReader(helpers.NewContextReader(ctx, source), v.options.MaxBodySize)
+	content, err := helpers.NewContentReader(ctx, body, response.Header.Get(helpers.ContentEncodingHeader), v.options)
+	if err != nil {
+		return []*errors.ValidationError{responseContentEncodingError(request, response, err)}
+	}
+	_, charset, _ := helpers.ExtractContentType(response.Header.Get(helpers.ContentTypeHeader))
+	if content, err = helpers.NewCharsetReader(content, charset); err != nil {
+		return []*errors.ValidationError{errors.ResponseBodyCannotBeDecoded(request, response, contentType, err.Error())}
+	}
+	records := helpers.NewJSONSequenceReader(content, contentType, v.options.MaxRecordSize)
+	for {
+		decoded, record, readErr := records.Next()
+		if readErr == io.EOF {
+			contentLength := responseContentLength(request, response)
+			if mismatch, ok := body.CheckContentLength(contentLength).(*helpers.ContentLengthError); ok {
+				validationErrors = append(validationErrors,
+					errors.ResponseContentLengthMismatch(request, response, mismatch))
+			}
+			break
+		}
+		index := records.Index()
+		if tooLarge, ok := readErr.(*helpers.BodyTooLargeError); ok {
+			validationErrors = append(validationErrors, errors.ResponseBodyTooLarge(request, response, tooLarge))
+			break
+		}
+		if readErr != nil {
+			decodeErr := errors.ResponseBodyCannotBeDecoded(request, response, contentType, readErr.Error())
+			if _, skipped := readErr.(*helpers.RecordTooLargeError); record == nil && !skipped {
+				// the body itself could not be read, there is nothing more to validate.
+				validationErrors = append(validationErrors, decodeErr)
+				break
+			}
+			decodeErr.RecordIndex = &index
+			validationErrors = append(validationErrors, decodeErr)
+			continue
+		}
+
+		_, recordErrors := validateResponseInstance(request, response, schema, renderedInline, jsch, decoded,
+			record, nil)
+		for _, recordErr := range recordErrors {
+			recordErr.Message = fmt.Sprintf("%d response body record %d for '%s' failed to validate schema",
+				response.StatusCode, index, request.URL.Path)
+			recordErr.RecordIndex = &index
+		}
+		validationErrors = append(validationErrors, recordErrors...)
+	}
+	return validationErrors
+}
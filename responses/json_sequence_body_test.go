@@ -0,0 +1,68 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package responses
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pb33f/libopenapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pb33f/libopenapi-validator/helpers"
+)
+
+func TestValidateBody_JSONSequence(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /burgers/export:
+    get:
+      responses:
+        '200':
+          content:
+            application/jsonl:
+              schema:
+                type: array
+                items:
+                  type: object
+                  required: [name]`
+
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+	m, _ := doc.BuildV3Model()
+	v := NewResponseBodyValidator(&m.Model)
+
+	request, _ := http.NewRequest(http.MethodGet, "https://things.com/burgers/export", nil)
+	buildResponse := func(body string) *http.Response {
+		res := httptest.NewRecorder()
+		res.Header().Set(helpers.ContentTypeHeader, "application/jsonl")
+		res.WriteHeader(http.StatusOK)
+		_, _ = res.Write([]byte(body))
+		return res.Result()
+	}
+
+	response := buildResponse("{\"name\":\"Big Mac\"}\n{\"name\":\"Whopper\"}\n")
+	valid, errs := v.ValidateResponseBody(request, response)
+	assert.True(t, valid)
+	assert.Len(t, errs, 0)
+
+	// the body is restored, so it can be read again.
+	restored, _ := io.ReadAll(response.Body)
+	assert.Equal(t, "{\"name\":\"Big Mac\"}\n{\"name\":\"Whopper\"}\n", string(restored))
+
+	valid, errs = v.ValidateResponseBody(request, buildResponse("{\"name\":\"Big Mac\"}\n{\"patties\":2}\n[\n"))
+	assert.False(t, valid)
+	require.Len(t, errs, 2)
+
+	require.NotNil(t, errs[0].RecordIndex)
+	assert.Equal(t, 1, *errs[0].RecordIndex)
+	assert.Equal(t, "200 response body record 1 for '/burgers/export' failed to validate schema", errs[0].Message)
+	assert.Equal(t, "missing property 'name'", errs[0].SchemaValidationErrors[0].Reason)
+
+	require.NotNil(t, errs[1].RecordIndex)
+	assert.Equal(t, 2, *errs[1].RecordIndex)
+	assert.Equal(t, "200 response body for '/burgers/export' cannot be decoded as 'application/jsonl'", errs[1].Message)
+}
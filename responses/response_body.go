@@ -4,14 +4,22 @@
 package responses
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"slices"
+	"strings"
 	"sync"
 
 	"github.com/pb33f/libopenapi/datamodel/high/base"
+	"github.com/santhosh-tekuri/jsonschema/v6"
 
 	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
 
+	"github.com/pb33f/libopenapi-validator/config"
 	"github.com/pb33f/libopenapi-validator/errors"
+	"github.com/pb33f/libopenapi-validator/helpers"
+	"github.com/pb33f/libopenapi-validator/radix"
 )
 
 // ResponseBodyValidator is an interface that defines the methods for validating response bodies for Operations.
@@ -28,20 +36,153 @@ type ResponseBodyValidator interface {
 	// locate the operation in the specification, the response is used to ensure the response code, media type and the
 	// schema of the response body are valid.
 	ValidateResponseBodyWithPathItem(request *http.Request, response *http.Response, pathItem *v3.PathItem, pathFound string) (bool, []*errors.ValidationError)
+
+	// ValidateResponseEventStream will validate a response in the same way as ValidateResponseBody, however when the
+	// response is a 'text/event-stream', each event is read from the body and passed to the callback as it arrives,
+	// along with the validation errors of that event. Validation stops early if the callback returns false. All the
+	// validation errors are also returned once the stream has been read. When there is a callback, the body is
+	// consumed by validation and cannot be read again.
+	ValidateResponseEventStream(request *http.Request, response *http.Response, callback EventStreamCallback) (bool, []*errors.ValidationError)
+
+	// ValidateResponseBodyCtx will validate the response body in the same way as ValidateResponseBody. Validation
+	// stops early once the context is done, and a single context error is returned. The context is supplied to body
+	// decoders, content decoders and schema loaders.
+	ValidateResponseBodyCtx(ctx context.Context, request *http.Request, response *http.Response) (bool, []*errors.ValidationError)
+
+	// ValidateResponseBodyWithPathItemCtx will validate the response body in the same way as
+	// ValidateResponseBodyWithPathItem, using the context in the same way as ValidateResponseBodyCtx.
+	ValidateResponseBodyWithPathItemCtx(ctx context.Context, request *http.Request, response *http.Response, pathItem *v3.PathItem, pathFound string) (bool, []*errors.ValidationError)
+
+	// ValidateResponseEventStreamCtx will validate a response in the same way as ValidateResponseEventStream, using
+	// the context in the same way as ValidateResponseBodyCtx. No more events are read once the context is done.
+	ValidateResponseEventStreamCtx(ctx context.Context, request *http.Request, response *http.Response, callback EventStreamCallback) (bool, []*errors.ValidationError)
 }
 
-// NewResponseBodyValidator will create a new ResponseBodyValidator from an OpenAPI 3+ document
-func NewResponseBodyValidator(document *v3.Document) ResponseBodyValidator {
-	return &responseBodyValidator{document: document, schemaCache: &sync.Map{}}
+// EventStreamCallback is called with each event of a 'text/event-stream' response, and the validation errors of
+// the event, which are empty if the event is valid. Returning false stops reading the stream.
+type EventStreamCallback func(event *helpers.ServerSentEvent, errs []*errors.ValidationError) bool
+
+// NewResponseBodyValidator will create a new ResponseBodyValidator from an OpenAPI 3+ document. Options can be
+// supplied to change the default behavior of the validator.
+func NewResponseBodyValidator(document *v3.Document, opts ...config.Option) ResponseBodyValidator {
+	options := config.NewValidationOptions(opts...)
+	if options.PathTree == nil {
+		options.PathTree = radix.NewPathTree(document)
+	}
+	if options.SchemaCache == nil {
+		options.SchemaCache = config.NewLRUSchemaCache(config.DefaultSchemaCacheSize)
+	}
+	return &responseBodyValidator{
+		document: document,
+		options:  options,
+	}
 }
 
+// schemaCache is a schema of the document, along with its rendered and compiled forms. The rendered and compiled
+// forms are held in the schema cache of the validator, and shared by every schema with the same hash.
 type schemaCache struct {
-	schema         *base.Schema
+	schema *base.Schema
+	*sharedSchema
+}
+
+// sharedSchema holds the rendered and compiled forms of a schema, so the work is only performed once per schema.
+type sharedSchema struct {
 	renderedInline []byte
 	renderedJSON   []byte
+
+	once       sync.Once
+	compiled   *jsonschema.Schema
+	compileErr error
+}
+
+// sharedSchemaKey identifies the rendered form of a schema in the schema cache, see helpers.CompiledSchemaKey.
+type sharedSchemaKey helpers.CompiledSchemaKey
+
+// compile compiles the rendered schema the first time it is needed, every call after that returns the same result.
+// The compiled schema is shared, so the compilation is not stopped when the context is cancelled.
+func (c *sharedSchema) compile(ctx context.Context, options *config.ValidationOptions) (*jsonschema.Schema, error) {
+	c.once.Do(func() {
+		c.compiled, c.compileErr = helpers.NewCompiledSchemaContext(context.WithoutCancel(ctx),
+			helpers.ResponseBodyValidation, c.renderedJSON, options)
+	})
+	return c.compiled, c.compileErr
 }
 
 type responseBodyValidator struct {
-	document    *v3.Document
-	schemaCache *sync.Map
+	document   *v3.Document
+	options    *config.ValidationOptions
+	schemaKeys helpers.SchemaKeys
+}
+
+// CompileSchemas compiles the schema of every response body and response header of every operation in the
+// document, so the compiled schemas are cached before the first response is validated. The item schemas of
+// streamed media types are compiled as well. A schema that cannot be compiled is returned as a
+// *helpers.SchemaCompileError. It is used by the validator to warm up eagerly, see
+// config.WithEagerSchemaCompilation.
+func (v *responseBodyValidator) CompileSchemas() []error {
+	var jobs []func() error
+	compile := func(op helpers.DocumentOperation, location string, proxy *base.SchemaProxy, stream bool) {
+		jobs = append(jobs, func() error {
+			if proxy.Schema() == nil {
+				return nil
+			}
+			cached := v.renderSchema(proxy)
+			_, err := cached.compile(context.Background(), v.options)
+			if err == nil && stream {
+				// each record or event of a stream is validated against the items of the schema.
+				if schema := cached.schema; slices.Contains(schema.Type, helpers.Array) &&
+					schema.Items != nil && schema.Items.IsA() {
+					_, err = v.renderSchema(schema.Items.A).compile(context.Background(), v.options)
+				}
+			}
+			if err != nil {
+				return &helpers.SchemaCompileError{Path: op.Path, Method: op.Method, Location: location, Err: err}
+			}
+			return nil
+		})
+	}
+
+	for _, op := range helpers.ExtractDocumentOperations(v.document) {
+		if op.Operation.Responses == nil {
+			continue
+		}
+		// the default response is compiled after the responses for status codes.
+		codes := []string{}
+		responses := []*v3.Response{}
+		for pair := op.Operation.Responses.Codes.First(); pair != nil; pair = pair.Next() {
+			codes, responses = append(codes, pair.Key()), append(responses, pair.Value())
+		}
+		if op.Operation.Responses.Default != nil {
+			codes, responses = append(codes, "default"), append(responses, op.Operation.Responses.Default)
+		}
+
+		for i, response := range responses {
+			if response == nil {
+				continue
+			}
+			code := codes[i]
+			for pair := response.Content.First(); pair != nil; pair = pair.Next() {
+				contentType, mediaType := pair.Key(), pair.Value()
+				if mediaType == nil || mediaType.Schema == nil {
+					continue
+				}
+				stream := helpers.IsJSONSequenceContentType(contentType) || helpers.IsEventStreamContentType(contentType)
+				compile(op, fmt.Sprintf("'%s' response body '%s'", code, contentType), mediaType.Schema, stream)
+			}
+			for pair := response.Headers.First(); pair != nil; pair = pair.Next() {
+				name, header := pair.Key(), pair.Value()
+				if header == nil || strings.EqualFold(name, helpers.ContentTypeHeader) {
+					continue
+				}
+				proxy := header.Schema
+				if header.Content != nil && header.Content.Len() > 0 {
+					proxy = header.Content.First().Value().Schema
+				}
+				if proxy != nil {
+					compile(op, fmt.Sprintf("'%s' response header '%s'", code, name), proxy, false)
+				}
+			}
+		}
+	}
+	return helpers.RunParallel(jobs)
 }
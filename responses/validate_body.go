@@ -4,10 +4,10 @@
 package responses
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strconv"
-	"strings"
 
 	"github.com/pb33f/libopenapi/datamodel/high/base"
 	"github.com/pb33f/libopenapi/orderedmap"
@@ -15,6 +15,7 @@ import (
 
 	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
 
+	"github.com/pb33f/libopenapi-validator/config"
 	"github.com/pb33f/libopenapi-validator/errors"
 	"github.com/pb33f/libopenapi-validator/helpers"
 	"github.com/pb33f/libopenapi-validator/paths"
@@ -24,14 +25,80 @@ func (v *responseBodyValidator) ValidateResponseBody(
 	request *http.Request,
 	response *http.Response,
 ) (bool, []*errors.ValidationError) {
-	pathItem, errs, foundPath := paths.FindPath(request, v.document)
+	return v.ValidateResponseBodyCtx(context.Background(), request, response)
+}
+
+func (v *responseBodyValidator) ValidateResponseBodyCtx(
+	ctx context.Context,
+	request *http.Request,
+	response *http.Response,
+) (bool, []*errors.ValidationError) {
+	return errors.ValidateWithContext(ctx, request, func() (bool, []*errors.ValidationError) {
+		return v.findAndValidateResponseBody(ctx, request, response, nil)
+	})
+}
+
+func (v *responseBodyValidator) ValidateResponseBodyWithPathItem(request *http.Request, response *http.Response, pathItem *v3.PathItem, pathFound string) (bool, []*errors.ValidationError) {
+	return v.ValidateResponseBodyWithPathItemCtx(context.Background(), request, response, pathItem, pathFound)
+}
+
+func (v *responseBodyValidator) ValidateResponseBodyWithPathItemCtx(
+	ctx context.Context,
+	request *http.Request,
+	response *http.Response,
+	pathItem *v3.PathItem,
+	pathFound string,
+) (bool, []*errors.ValidationError) {
+	return errors.ValidateWithContext(ctx, request, func() (bool, []*errors.ValidationError) {
+		return v.validateResponseBody(ctx, request, response, pathItem, pathFound, nil)
+	})
+}
+
+func (v *responseBodyValidator) ValidateResponseEventStream(
+	request *http.Request,
+	response *http.Response,
+	callback EventStreamCallback,
+) (bool, []*errors.ValidationError) {
+	return v.ValidateResponseEventStreamCtx(context.Background(), request, response, callback)
+}
+
+func (v *responseBodyValidator) ValidateResponseEventStreamCtx(
+	ctx context.Context,
+	request *http.Request,
+	response *http.Response,
+	callback EventStreamCallback,
+) (bool, []*errors.ValidationError) {
+	return errors.ValidateWithContext(ctx, request, func() (bool, []*errors.ValidationError) {
+		return v.findAndValidateResponseBody(ctx, request, response, callback)
+	})
+}
+
+// findAndValidateResponseBody finds the path item of the request in the document, and validates the response
+// against it, see validateResponseBody.
+func (v *responseBodyValidator) findAndValidateResponseBody(
+	ctx context.Context,
+	request *http.Request,
+	response *http.Response,
+	callback EventStreamCallback,
+) (bool, []*errors.ValidationError) {
+	pathItem, errs, foundPath := paths.FindPath(request, v.document, config.WithExistingOpts(v.options))
 	if len(errs) > 0 {
 		return false, errs
 	}
-	return v.ValidateResponseBodyWithPathItem(request, response, pathItem, foundPath)
+	return v.validateResponseBody(ctx, request, response, pathItem, foundPath, callback)
 }
 
-func (v *responseBodyValidator) ValidateResponseBodyWithPathItem(request *http.Request, response *http.Response, pathItem *v3.PathItem, pathFound string) (bool, []*errors.ValidationError) {
+// validateResponseBody validates the response code, body and headers of a response. When the response is an event
+// stream, each event is passed to the callback as it is validated, the callback may be nil. The context is checked
+// by the caller, it is passed down to the decoders and schema loaders.
+func (v *responseBodyValidator) validateResponseBody(
+	ctx context.Context,
+	request *http.Request,
+	response *http.Response,
+	pathItem *v3.PathItem,
+	pathFound string,
+	callback EventStreamCallback,
+) (bool, []*errors.ValidationError) {
 	if pathItem == nil {
 		return false, []*errors.ValidationError{{
 			ValidationType:    helpers.ParameterValidationPath,
@@ -71,9 +138,9 @@ func (v *responseBodyValidator) ValidateResponseBodyWithPathItem(request *http.R
 	if foundResponse != nil {
 		if foundResponse.Content != nil { // only validate if we have content types.
 			// check content type has been defined in the contract
-			if mediaType, ok := foundResponse.Content.Get(mediaTypeSting); ok {
+			if _, mediaType, ok := helpers.FindMediaType(foundResponse.Content, contentType); ok {
 				validationErrors = append(validationErrors,
-					v.checkResponseSchema(request, response, mediaTypeSting, mediaType)...)
+					v.checkResponseSchema(ctx, request, response, mediaTypeSting, mediaType, pathFound, callback)...)
 			} else {
 				// check that the operation *actually* returns a body. (i.e. a 204 response)
 				if foundResponse.Content != nil && orderedmap.Len(foundResponse.Content) > 0 {
@@ -87,9 +154,9 @@ func (v *responseBodyValidator) ValidateResponseBodyWithPathItem(request *http.R
 		// no code match, check for default response
 		if operation.Responses.Default != nil && operation.Responses.Default.Content != nil {
 			// check content type has been defined in the contract
-			if mediaType, ok := operation.Responses.Default.Content.Get(mediaTypeSting); ok {
+			if _, mediaType, ok := helpers.FindMediaType(operation.Responses.Default.Content, contentType); ok {
 				validationErrors = append(validationErrors,
-					v.checkResponseSchema(request, response, contentType, mediaType)...)
+					v.checkResponseSchema(ctx, request, response, contentType, mediaType, pathFound, callback)...)
 			} else {
 				// check that the operation *actually* returns a body. (i.e. a 204 response)
 				if operation.Responses.Default.Content != nil && orderedmap.Len(operation.Responses.Default.Content) > 0 {
@@ -106,6 +173,14 @@ func (v *responseBodyValidator) ValidateResponseBodyWithPathItem(request *http.R
 		}
 	}
 
+	// check the response headers, using the matched response or the default response.
+	if foundResponse != nil {
+		validationErrors = append(validationErrors, v.checkResponseHeaders(ctx, response, foundResponse.Headers)...)
+	} else if operation.Responses.Default != nil {
+		validationErrors = append(validationErrors,
+			v.checkResponseHeaders(ctx, response, operation.Responses.Default.Headers)...)
+	}
+
 	errors.PopulateValidationErrors(validationErrors, request, pathFound)
 
 	if len(validationErrors) > 0 {
@@ -115,52 +190,87 @@ func (v *responseBodyValidator) ValidateResponseBodyWithPathItem(request *http.R
 }
 
 func (v *responseBodyValidator) checkResponseSchema(
+	ctx context.Context,
 	request *http.Request,
 	response *http.Response,
 	contentType string,
 	mediaType *v3.MediaType,
+	pathFound string,
+	callback EventStreamCallback,
 ) []*errors.ValidationError {
 	var validationErrors []*errors.ValidationError
 
-	// currently, we can only validate JSON based responses, so check for the presence
-	// of 'json' in the content type (what ever it may be) so we can perform a schema check on it.
-	// anything other than JSON, will be ignored.
-	if strings.Contains(strings.ToLower(contentType), helpers.JSONType) {
-		// extract schema from media type
+	// JSON, JSON sequences, event streams and XML responses (including '+json' and '+xml' media types) are decoded
+	// by the validator. anything else needs a registered decoder, or it will be handled by the unknown media type
+	// policy.
+	decoder := v.options.FindBodyDecoder(contentType)
+	isSequence := decoder == nil && helpers.IsJSONSequenceContentType(contentType)
+	isEventStream := decoder == nil && helpers.IsEventStreamContentType(contentType)
+	if decoder == nil && !isSequence && !isEventStream && !helpers.IsXMLContentType(contentType) &&
+		!helpers.IsJSONContentType(contentType) {
+		if v.options.UnknownMediaTypes == config.FailUnknownMediaTypes {
+			mediaTypeString, _, _ := helpers.ExtractContentType(contentType)
+			validationErrors = append(validationErrors,
+				errors.ResponseBodyMediaTypeUnsupported(request, response, mediaType, mediaTypeString))
+		}
+		return validationErrors
+	}
+	// events are streamed to the callback even when there is no schema to validate them against.
+	if isEventStream {
+		var cached *schemaCache
 		if mediaType.Schema != nil {
+			cached = v.renderSchema(mediaType.Schema)
+		}
+		return append(validationErrors, v.validateEventStreamBody(ctx, request, response, cached, pathFound,
+			callback)...)
+	}
 
-			var schema *base.Schema
-			var renderedInline, renderedJSON []byte
+	// extract schema from media type
+	if mediaType.Schema == nil {
+		return validationErrors
+	}
 
-			// have we seen this schema before? let's hash it and check the cache.
-			hash := mediaType.GoLow().Schema.Value.Hash()
+	cached := v.renderSchema(mediaType.Schema)
 
-			if cacheHit, ch := v.schemaCache.Load(hash); ch {
-				// got a hit, use cached values
-				schema = cacheHit.(*schemaCache).schema
-				renderedInline = cacheHit.(*schemaCache).renderedInline
-				renderedJSON = cacheHit.(*schemaCache).renderedJSON
+	if isSequence {
+		mediaTypeString, _, _ := helpers.ExtractContentType(contentType)
+		return append(validationErrors, v.validateJSONSequenceBody(ctx, request, response, cached, mediaTypeString)...)
+	}
 
-			} else {
+	// the schema is only compiled the first time it is used.
+	valid, vErrs := validateResponseSchema(ctx, request, response, cached, v.options)
+	if !valid {
+		validationErrors = append(validationErrors, vErrs...)
+	}
+	return validationErrors
+}
 
-				// render the schema inline and perform the intensive work of rendering and converting
-				// this is only performed once per schema and cached in the validator.
-				schema = mediaType.Schema.Schema()
-				renderedInline, _ = schema.RenderInline()
-				renderedJSON, _ = utils.ConvertYAMLtoJSON(renderedInline)
-				v.schemaCache.Store(hash, &schemaCache{
-					schema:         schema,
-					renderedInline: renderedInline,
-					renderedJSON:   renderedJSON,
-				})
-			}
+// renderSchema renders a schema inline, and converts the rendered schema to JSON. The conversion is only performed
+// once per rendered schema, the result is held in the schema cache of the validator, along with the compiled schema
+// once it has been compiled. The key of a schema is remembered by the validator, so a schema is not rendered again
+// while it remains in the cache.
+func (v *responseBodyValidator) renderSchema(proxy *base.SchemaProxy) *schemaCache {
+	schema := proxy.Schema()
 
-			// render the schema, to be used for validation
-			valid, vErrs := ValidateResponseSchema(request, response, schema, renderedInline, renderedJSON)
-			if !valid {
-				validationErrors = append(validationErrors, vErrs...)
-			}
-		}
+	// have we seen this schema before? let's check the cache.
+	var renderedInline []byte
+	key := sharedSchemaKey(v.schemaKeys.Key(schema, func() []byte {
+		renderedInline, _ = schema.RenderInline()
+		return renderedInline
+	}, v.options))
+	if cacheHit, ch := v.options.SchemaCache.Load(key); ch {
+		// got a hit, use cached values
+		return &schemaCache{schema: schema, sharedSchema: cacheHit.(*sharedSchema)}
 	}
-	return validationErrors
+
+	// perform the intensive work of converting the rendered schema
+	if renderedInline == nil {
+		renderedInline, _ = schema.RenderInline()
+	}
+	renderedJSON, _ := utils.ConvertYAMLtoJSON(renderedInline)
+	rendered, _ := v.options.SchemaCache.LoadOrStore(key, &sharedSchema{
+		renderedInline: renderedInline,
+		renderedJSON:   renderedJSON,
+	})
+	return &schemaCache{schema: schema, sharedSchema: rendered.(*sharedSchema)}
 }
@@ -5,16 +5,22 @@ package responses
 
 import (
 	"bytes"
+	"compress/zlib"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/pb33f/libopenapi"
+	"github.com/pb33f/libopenapi/datamodel/high/base"
 	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
 
+	"github.com/pb33f/libopenapi-validator/config"
 	"github.com/pb33f/libopenapi-validator/helpers"
 	"github.com/pb33f/libopenapi-validator/paths"
 )
@@ -1310,3 +1316,538 @@ func (er *errorReader) Read(p []byte) (n int, err error) {
 func (er *errorReader) Close() error {
 	return nil
 }
+
+func TestValidateBody_WithFormatAssertions(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /burgers/createBurger:
+    post:
+      responses:
+        '200':
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  createdAt:
+                    type: string
+                    format: date-time`
+
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+	m, _ := doc.BuildV3Model()
+
+	request, _ := http.NewRequest(http.MethodPost, "https://things.com/burgers/createBurger", nil)
+
+	buildResponse := func() *http.Response {
+		res := httptest.NewRecorder()
+		res.Header().Set(helpers.ContentTypeHeader, helpers.JSONContentType)
+		res.WriteHeader(http.StatusOK)
+		_, _ = res.Write([]byte(`{"createdAt": "yesterday"}`))
+		return res.Result()
+	}
+
+	v := NewResponseBodyValidator(&m.Model)
+	valid, errs := v.ValidateResponseBody(request, buildResponse())
+	assert.True(t, valid)
+	assert.Len(t, errs, 0)
+
+	v = NewResponseBodyValidator(&m.Model, config.WithFormatAssertions())
+	valid, errs = v.ValidateResponseBody(request, buildResponse())
+	assert.False(t, valid)
+	assert.Len(t, errs, 1)
+	assert.Contains(t, errs[0].SchemaValidationErrors[0].Reason, "'yesterday' is not valid date-time")
+}
+
+func TestValidateBody_XML(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /burgers:
+    get:
+      responses:
+        '200':
+          content:
+            application/xml:
+              schema:
+                type: array
+                xml:
+                  name: burgers
+                items:
+                  type: object
+                  required: [name]
+                  xml:
+                    name: burger
+                  properties:
+                    name:
+                      type: string
+                    patties:
+                      type: integer`
+
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+	m, _ := doc.BuildV3Model()
+	v := NewResponseBodyValidator(&m.Model)
+
+	request, _ := http.NewRequest(http.MethodGet, "https://things.com/burgers", nil)
+	buildResponse := func(body string) *http.Response {
+		res := httptest.NewRecorder()
+		res.Header().Set(helpers.ContentTypeHeader, "application/xml")
+		res.WriteHeader(http.StatusOK)
+		_, _ = res.Write([]byte(body))
+		return res.Result()
+	}
+
+	valid, errs := v.ValidateResponseBody(request,
+		buildResponse(`<burgers><burger><name>Big Mac</name><patties>2</patties></burger></burgers>`))
+	assert.True(t, valid)
+	assert.Len(t, errs, 0)
+
+	valid, errs = v.ValidateResponseBody(request,
+		buildResponse(`<burgers><burger><name>Big Mac</name></burger><burger><patties>two</patties></burger></burgers>`))
+	assert.False(t, valid)
+	assert.Len(t, errs, 1)
+	assert.Len(t, errs[0].SchemaValidationErrors, 2)
+	for _, e := range errs[0].SchemaValidationErrors {
+		switch e.Location {
+		case "/items/required":
+			assert.Equal(t, "/burgers/burger[2]", e.ElementPath)
+		case "/items/properties/patties/type":
+			assert.Equal(t, "/burgers/burger[2]/patties", e.ElementPath)
+		default:
+			t.Errorf("unexpected violation: %s", e.Location)
+		}
+	}
+
+	valid, errs = v.ValidateResponseBody(request, buildResponse(`<burgers><burger>`))
+	assert.False(t, valid)
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "The response body cannot be decoded: XML syntax error on line 1: unexpected EOF", errs[0].Reason)
+}
+
+func TestValidateBody_XML_Charset(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /burgers:
+    get:
+      responses:
+        '200':
+          content:
+            application/xml:
+              schema:
+                type: object
+                xml:
+                  name: burger
+                properties:
+                  name:
+                    enum: [Café Burger]`
+
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+	m, _ := doc.BuildV3Model()
+	v := NewResponseBodyValidator(&m.Model)
+
+	request, _ := http.NewRequest(http.MethodGet, "https://things.com/burgers", nil)
+	buildResponse := func(contentType, body string) *http.Response {
+		res := httptest.NewRecorder()
+		res.Header().Set(helpers.ContentTypeHeader, contentType)
+		res.WriteHeader(http.StatusOK)
+		_, _ = res.Write([]byte(body))
+		return res.Result()
+	}
+
+	// without a charset, the body is transcoded from the encoding declaration of the document.
+	valid, errs := v.ValidateResponseBody(request, buildResponse("application/xml",
+		"<?xml version=\"1.0\" encoding=\"windows-1252\"?><burger><name>Caf\xe9 Burger</name></burger>"))
+	assert.True(t, valid)
+	assert.Len(t, errs, 0)
+
+	valid, errs = v.ValidateResponseBody(request, buildResponse("application/xml; charset=windows-1252",
+		"<?xml version=\"1.0\" encoding=\"windows-1252\"?><burger><name>Caf\xe9 Burger</name></burger>"))
+	assert.True(t, valid)
+	assert.Len(t, errs, 0)
+
+	valid, errs = v.ValidateResponseBody(request, buildResponse("application/xml",
+		"<burger><name>Caf\xe9 Burger</name></burger>"))
+	assert.False(t, valid)
+	assert.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Reason, "invalid UTF-8")
+}
+
+func TestValidateBody_BodyDecoder(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /burgers:
+    get:
+      responses:
+        '200':
+          content:
+            application/yaml:
+              schema:
+                type: object
+                required: [name]
+            text/csv:
+              schema:
+                type: string`
+
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+	m, _ := doc.BuildV3Model()
+
+	request, _ := http.NewRequest(http.MethodGet, "https://things.com/burgers", nil)
+	buildResponse := func(contentType, body string) *http.Response {
+		res := httptest.NewRecorder()
+		res.Header().Set(helpers.ContentTypeHeader, contentType)
+		res.WriteHeader(http.StatusOK)
+		_, _ = res.Write([]byte(body))
+		return res.Result()
+	}
+
+	yamlDecoder := config.BodyDecoderFunc(func(body []byte, _ *base.Schema) (any, error) {
+		var decoded any
+		err := yaml.Unmarshal(body, &decoded)
+		return decoded, err
+	})
+	v := NewResponseBodyValidator(&m.Model, config.WithBodyDecoder("application/yaml", yamlDecoder))
+
+	valid, errs := v.ValidateResponseBody(request, buildResponse("application/yaml", "name: Big Mac\n"))
+	assert.True(t, valid)
+	assert.Len(t, errs, 0)
+
+	valid, errs = v.ValidateResponseBody(request, buildResponse("application/yaml", "patties: 2\n"))
+	assert.False(t, valid)
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "missing property 'name'", errs[0].SchemaValidationErrors[0].Reason)
+
+	// there is no decoder for CSV, so it's skipped by default.
+	valid, errs = v.ValidateResponseBody(request, buildResponse("text/csv", "name\nBig Mac\n"))
+	assert.True(t, valid)
+	assert.Len(t, errs, 0)
+
+	v = NewResponseBodyValidator(&m.Model, config.WithUnknownMediaTypePolicy(config.FailUnknownMediaTypes))
+	valid, errs = v.ValidateResponseBody(request, buildResponse("text/csv", "name\nBig Mac\n"))
+	assert.False(t, valid)
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "200 response body for '/burgers' cannot be validated as 'text/csv'", errs[0].Message)
+	assert.Equal(t, "/burgers", errs[0].SpecPath)
+}
+
+func TestValidateBody_MediaRanges(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /burgers:
+    get:
+      responses:
+        '200':
+          content:
+            application/*:
+              schema:
+                type: object
+                required: [name]
+        default:
+          content:
+            application/*+json:
+              schema:
+                type: object
+                required: [title]`
+
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+	m, _ := doc.BuildV3Model()
+	v := NewResponseBodyValidator(&m.Model)
+
+	request, _ := http.NewRequest(http.MethodGet, "https://things.com/burgers", nil)
+	buildResponse := func(code int, contentType, body string) *http.Response {
+		res := httptest.NewRecorder()
+		res.Header().Set(helpers.ContentTypeHeader, contentType)
+		res.WriteHeader(code)
+		_, _ = res.Write([]byte(body))
+		return res.Result()
+	}
+
+	valid, errs := v.ValidateResponseBody(request, buildResponse(http.StatusOK, "application/json", `{"name": "Big Mac"}`))
+	assert.True(t, valid)
+	assert.Len(t, errs, 0)
+
+	valid, errs = v.ValidateResponseBody(request, buildResponse(http.StatusOK, "application/json", `{}`))
+	assert.False(t, valid)
+	assert.Len(t, errs, 1)
+
+	valid, errs = v.ValidateResponseBody(request,
+		buildResponse(http.StatusInternalServerError, "application/problem+json", `{"status": 500}`))
+	assert.False(t, valid)
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "missing property 'title'", errs[0].SchemaValidationErrors[0].Reason)
+
+	valid, errs = v.ValidateResponseBody(request,
+		buildResponse(http.StatusInternalServerError, "text/plain", "oops"))
+	assert.False(t, valid)
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "GET / 500 operation response content type 'text/plain' does not exist", errs[0].Message)
+}
+
+func TestValidateBody_MaxBodySize(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /burgers:
+    get:
+      responses:
+        '200':
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  type: object
+                  required: [name]`
+
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+	m, _ := doc.BuildV3Model()
+
+	request, _ := http.NewRequest(http.MethodGet, "https://things.com/burgers", nil)
+	buildResponse := func(body string, contentLength int64) *http.Response {
+		res := httptest.NewRecorder()
+		res.Header().Set(helpers.ContentTypeHeader, helpers.JSONContentType)
+		res.WriteHeader(http.StatusOK)
+		_, _ = res.Write([]byte(body))
+		response := res.Result()
+		response.ContentLength = contentLength
+		return response
+	}
+	body := `[{"name": "Big Mac"}, {"patties": 2}]`
+
+	v := NewResponseBodyValidator(&m.Model, config.WithMaxBodySize(16))
+	response := buildResponse(body, -1)
+	valid, errs := v.ValidateResponseBody(request, response)
+	assert.False(t, valid)
+	assert.Len(t, errs, 1)
+	assert.True(t, errs[0].IsBodyTooLargeError())
+	assert.Equal(t, "200 response body for '/burgers' is too large", errs[0].Message)
+
+	// the body is left in place.
+	remaining, _ := io.ReadAll(response.Body)
+	assert.Equal(t, body, string(remaining))
+
+	// the content length must match the body.
+	v = NewResponseBodyValidator(&m.Model)
+	valid, errs = v.ValidateResponseBody(request, buildResponse(body, 12))
+	assert.False(t, valid)
+	assert.Len(t, errs, 1)
+	assert.Equal(t, helpers.BodyContentLength, errs[0].ValidationSubType)
+
+	// the items of the array are validated one at a time.
+	valid, errs = v.ValidateResponseBody(request, buildResponse(body, int64(len(body))))
+	assert.False(t, valid)
+	assert.Len(t, errs, 1)
+	assert.Len(t, errs[0].SchemaValidationErrors, 1)
+	assert.Equal(t, "/items/required", errs[0].SchemaValidationErrors[0].Location)
+	assert.Equal(t, "{\n  \"patties\": 2\n}", errs[0].SchemaValidationErrors[0].ReferenceObject)
+}
+
+func TestValidateBody_ContentEncoding(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /burgers:
+    get:
+      responses:
+        '200':
+          content:
+            application/json:
+              schema:
+                type: object
+                required: [name]`
+
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+	m, _ := doc.BuildV3Model()
+	v := NewResponseBodyValidator(&m.Model)
+
+	request, _ := http.NewRequest(http.MethodGet, "https://things.com/burgers", nil)
+	buildResponse := func(body string, encoding string) (*http.Response, []byte) {
+		var buf bytes.Buffer
+		w := zlib.NewWriter(&buf)
+		_, _ = w.Write([]byte(body))
+		_ = w.Close()
+
+		res := httptest.NewRecorder()
+		res.Header().Set(helpers.ContentTypeHeader, helpers.JSONContentType)
+		res.Header().Set(helpers.ContentEncodingHeader, encoding)
+		res.WriteHeader(http.StatusOK)
+		_, _ = res.Write(buf.Bytes())
+		return res.Result(), buf.Bytes()
+	}
+
+	response, compressed := buildResponse(`{"name": "Big Mac"}`, "deflate")
+	valid, errs := v.ValidateResponseBody(request, response)
+	assert.True(t, valid)
+	assert.Len(t, errs, 0)
+
+	// the original compressed bytes are left on the response.
+	restored, _ := io.ReadAll(response.Body)
+	assert.Equal(t, compressed, restored)
+
+	response, _ = buildResponse(`{"patties": 2}`, "deflate")
+	valid, errs = v.ValidateResponseBody(request, response)
+	assert.False(t, valid)
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "missing property 'name'", errs[0].SchemaValidationErrors[0].Reason)
+
+	// the body was not compressed using the declared coding.
+	response, _ = buildResponse(`{"name": "Big Mac"}`, "gzip")
+	valid, errs = v.ValidateResponseBody(request, response)
+	assert.False(t, valid)
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "200 response body for '/burgers' cannot be decoded as 'application/json'", errs[0].Message)
+	assert.Contains(t, errs[0].Reason, "the body cannot be decoded as 'gzip'")
+}
+
+func TestValidateBody_Charset(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /burgers:
+    get:
+      responses:
+        '200':
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  name:
+                    enum: [Café Burger]`
+
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+	m, _ := doc.BuildV3Model()
+	v := NewResponseBodyValidator(&m.Model)
+
+	request, _ := http.NewRequest(http.MethodGet, "https://things.com/burgers", nil)
+	buildResponse := func(body string, contentType string) *http.Response {
+		res := httptest.NewRecorder()
+		res.Header().Set(helpers.ContentTypeHeader, contentType)
+		res.WriteHeader(http.StatusOK)
+		_, _ = res.Write([]byte(body))
+		return res.Result()
+	}
+
+	// the body is transcoded to UTF-8 before it is decoded.
+	valid, errs := v.ValidateResponseBody(request, buildResponse("{\"name\": \"Caf\xe9 Burger\"}",
+		"application/json; charset=windows-1252"))
+	assert.True(t, valid)
+	assert.Len(t, errs, 0)
+
+	valid, errs = v.ValidateResponseBody(request, buildResponse("{\"name\": \"Caf\xe9 Burger\"}",
+		"application/json; charset=utf-8"))
+	assert.False(t, valid)
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "200 response body for '/burgers' cannot be decoded as 'application/json; charset=utf-8'",
+		errs[0].Message)
+	assert.Contains(t, errs[0].Reason, "the body is not valid UTF-8, the byte at offset 13 is invalid")
+
+	valid, errs = v.ValidateResponseBody(request, buildResponse(`{"name": "Café Burger"}`,
+		"application/json; charset=x-burger"))
+	assert.False(t, valid)
+	assert.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Reason, "the charset 'x-burger' is not supported")
+}
+
+func TestValidateBody_CompiledSchemaCache(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /burgers:
+    get:
+      responses:
+        '200':
+          content:
+            application/json:
+              schema:
+                type: object
+                required: [name]`
+
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+	m, _ := doc.BuildV3Model()
+	v := NewResponseBodyValidator(&m.Model).(*responseBodyValidator)
+
+	request, _ := http.NewRequest(http.MethodGet, "https://things.com/burgers", nil)
+	validate := func(body string) bool {
+		res := httptest.NewRecorder()
+		res.Header().Set(helpers.ContentTypeHeader, helpers.JSONContentType)
+		res.WriteHeader(http.StatusOK)
+		_, _ = res.Write([]byte(body))
+		valid, _ := v.ValidateResponseBody(request, res.Result())
+		return valid
+	}
+
+	assert.True(t, validate(`{"name": "Big Mac"}`))
+
+	mediaType := m.Model.Paths.PathItems.GetOrZero("/burgers").Get.Responses.Codes.GetOrZero("200").Content.GetOrZero("application/json")
+	cached := v.renderSchema(mediaType.Schema)
+	jsch, err := cached.compile(context.Background(), v.options)
+	assert.NoError(t, err)
+	assert.NotNil(t, jsch)
+
+	// the compiled schema is reused by every response after the first.
+	assert.False(t, validate(`{"patties": 2}`))
+	assert.Same(t, cached.sharedSchema, v.renderSchema(mediaType.Schema).sharedSchema)
+	compiled, _ := cached.compile(context.Background(), v.options)
+	assert.Same(t, jsch, compiled)
+}
+
+type contextContentDecoder struct {
+	ctx context.Context
+}
+
+func (d *contextContentDecoder) NewReader(body io.Reader) (io.Reader, error) {
+	return d.NewReaderContext(context.Background(), body)
+}
+
+func (d *contextContentDecoder) NewReaderContext(ctx context.Context, body io.Reader) (io.Reader, error) {
+	d.ctx = ctx
+	return zlib.NewReader(body)
+}
+
+func TestValidateBody_Context(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /burgers:
+    get:
+      responses:
+        '200':
+          content:
+            application/json:
+              schema:
+                type: object
+                required: [name]
+                properties:
+                  name:
+                    type: string`
+
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+	m, _ := doc.BuildV3Model()
+
+	decoder := &contextContentDecoder{}
+	v := NewResponseBodyValidator(&m.Model, config.WithContentDecoder("compress", decoder))
+
+	var compressed bytes.Buffer
+	writer := zlib.NewWriter(&compressed)
+	_, _ = writer.Write([]byte(`{"name": "Big Mac"}`))
+	_ = writer.Close()
+
+	type key struct{}
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), key{}, "burger"))
+
+	request, _ := http.NewRequest(http.MethodGet, "https://things.com/burgers", nil)
+	res := httptest.NewRecorder()
+	res.Header().Set(helpers.ContentTypeHeader, "application/json")
+	res.Header().Set(helpers.ContentEncodingHeader, "compress")
+	res.WriteHeader(http.StatusOK)
+	_, _ = res.Write(compressed.Bytes())
+
+	valid, errs := v.ValidateResponseBodyCtx(ctx, request, res.Result())
+	assert.True(t, valid)
+	assert.Len(t, errs, 0)
+	assert.Equal(t, "burger", decoder.ctx.Value(key{}))
+
+	// the context is already done, nothing is validated.
+	cancel()
+	decoder.ctx = nil
+	valid, errs = v.ValidateResponseBodyWithPathItemCtx(ctx, request, res.Result(),
+		m.Model.Paths.PathItems.GetOrZero("/burgers"), "/burgers")
+	assert.False(t, valid)
+	assert.Len(t, errs, 1)
+	assert.True(t, errs[0].IsContextError())
+	assert.ErrorIs(t, errs[0], context.Canceled)
+	assert.Nil(t, decoder.ctx)
+}
@@ -0,0 +1,143 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package responses
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	"github.com/pb33f/libopenapi/orderedmap"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+
+	"github.com/pb33f/libopenapi-validator/errors"
+	"github.com/pb33f/libopenapi-validator/helpers"
+)
+
+// checkResponseHeaders validates the headers of a response against the headers defined for the response in the
+// specification. Header values are decoded in the same way as header parameters, using the 'simple' style, unless
+// the header is defined using content, in which case the value is decoded using the media type, see
+// helpers.DecodeHeaderContent. A 'Content-Type' header definition is ignored, as required by the specification.
+func (v *responseBodyValidator) checkResponseHeaders(
+	ctx context.Context,
+	response *http.Response,
+	headers *orderedmap.Map[string, *v3.Header],
+) []*errors.ValidationError {
+	var validationErrors []*errors.ValidationError
+	for pair := orderedmap.First(headers); pair != nil; pair = pair.Next() {
+		name, header := pair.Key(), pair.Value()
+		if header == nil || strings.EqualFold(name, helpers.ContentTypeHeader) {
+			continue
+		}
+
+		values := response.Header.Values(name)
+		if len(values) == 0 {
+			if header.Required {
+				validationErrors = append(validationErrors,
+					errors.ResponseHeaderMissing(name, header, response.StatusCode))
+			}
+			continue
+		}
+		// multiple header lines are the same as a single, comma separated, line.
+		value := strings.Join(values, ", ")
+
+		var schemaProxy *base.SchemaProxy
+		var decoded any
+		var err error
+		if header.Content != nil && header.Content.Len() > 0 {
+			// a content based header has a single media type, a value that cannot be decoded is not validated.
+			content := orderedmap.First(header.Content)
+			schemaProxy = content.Value().Schema
+			if schemaProxy == nil {
+				continue
+			}
+			var ok bool
+			decoded, ok, err = helpers.DecodeHeaderContent(ctx, value, content.Key(), schemaProxy.Schema(), v.options)
+			if !ok {
+				continue
+			}
+		} else if header.Schema != nil {
+			schemaProxy = header.Schema
+			decoded, err = helpers.DecodeSimpleHeader(value, header.Schema.Schema(), header.Explode)
+		}
+		if err != nil {
+			validationErrors = append(validationErrors,
+				errors.ResponseHeaderCannotBeDecoded(name, header, value, err.Error()))
+			continue
+		}
+		if schemaProxy == nil {
+			continue
+		}
+		validationErrors = append(validationErrors,
+			v.validateHeaderSchema(ctx, name, header, schemaProxy, value, decoded, response.StatusCode)...)
+	}
+	return validationErrors
+}
+
+// validateHeaderSchema validates a decoded header value against the schema of the header.
+func (v *responseBodyValidator) validateHeaderSchema(
+	ctx context.Context,
+	name string,
+	header *v3.Header,
+	schemaProxy *base.SchemaProxy,
+	value string,
+	decoded any,
+	statusCode int,
+) []*errors.ValidationError {
+	if schemaProxy.Schema() == nil {
+		return nil
+	}
+	// headers are often shared using references, so the rendered and compiled schemas are cached.
+	cached := v.renderSchema(schemaProxy)
+	renderedInline := cached.renderedInline
+	jsch, err := cached.compile(ctx, v.options)
+	if err != nil {
+		v.options.Logger.Error("unable to compile response header schema", "header", name, "error", err.Error())
+		return nil
+	}
+
+	scErrs := jsch.Validate(decoded)
+	if scErrs == nil {
+		return nil
+	}
+	var schemaValidationErrors []*errors.SchemaValidationFailure
+	if jk, ok := scErrs.(*jsonschema.ValidationError); ok {
+		for _, er := range jk.BasicOutput().Errors {
+			errMsg := er.Error.Kind.LocalizedString(message.NewPrinter(language.Tag{}))
+			if er.KeywordLocation == "" || helpers.IgnoreRegex.MatchString(errMsg) {
+				continue
+			}
+			schemaValidationErrors = append(schemaValidationErrors, &errors.SchemaValidationFailure{
+				Reason:          errMsg,
+				Location:        er.KeywordLocation,
+				ReferenceSchema: string(renderedInline),
+				ReferenceObject: value,
+				OriginalError:   jk,
+			})
+		}
+	}
+
+	line, col := 1, 0
+	if low := schemaProxy.GoLow(); low != nil && low.GetValueNode() != nil {
+		line, col = low.GetValueNode().Line, low.GetValueNode().Column
+	}
+	return []*errors.ValidationError{{
+		ValidationType:    helpers.ResponseHeaderValidation,
+		ValidationSubType: helpers.Schema,
+		Message:           fmt.Sprintf("Response header '%s' failed to validate", name),
+		Reason: fmt.Sprintf("The response header '%s' for status code '%d' does not meet the schema "+
+			"requirements of the specification, the value is '%s'", name, statusCode, value),
+		SpecLine:               line,
+		SpecCol:                col,
+		SchemaValidationErrors: schemaValidationErrors,
+		HowToFix:               errors.HowToFixInvalidSchema,
+		Context:                header,
+	}}
+}
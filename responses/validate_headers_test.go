@@ -0,0 +1,278 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package responses
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/pb33f/libopenapi"
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pb33f/libopenapi-validator/config"
+	"github.com/pb33f/libopenapi-validator/helpers"
+)
+
+const headersSpec = `openapi: 3.1.0
+paths:
+  /burgers:
+    post:
+      responses:
+        '201':
+          description: created
+          headers:
+            Location:
+              required: true
+              schema:
+                type: string
+                format: uri-reference
+            X-RateLimit-Remaining:
+              schema:
+                type: integer
+                minimum: 0
+            X-Burger-Ids:
+              schema:
+                type: array
+                items:
+                  type: integer
+            X-Burger:
+              schema:
+                type: object
+                properties:
+                  name:
+                    type: string
+                  patties:
+                    type: integer
+            X-Burger-Exploded:
+              explode: true
+              schema:
+                type: object
+                properties:
+                  patties:
+                    type: integer
+                    maximum: 3
+            X-Burger-Json:
+              content:
+                application/json:
+                  schema:
+                    type: object
+                    required: [name]
+            X-Burger-Text:
+              content:
+                text/plain:
+                  schema:
+                    type: string
+                    maxLength: 8
+            X-Burger-Xml:
+              content:
+                application/xml:
+                  schema:
+                    type: object
+            ETag:
+              $ref: '#/components/headers/ETag'
+            Content-Type:
+              required: true
+              schema:
+                type: integer
+        default:
+          description: error
+          headers:
+            X-Request-Id:
+              required: true
+              schema:
+                type: string
+          content:
+            application/json:
+              schema:
+                type: object
+components:
+  headers:
+    ETag:
+      required: true
+      schema:
+        type: string
+        pattern: '^"[a-z0-9]+"$'`
+
+func headerResponse(code int, headers map[string]string) *http.Response {
+	response := &http.Response{StatusCode: code, Header: http.Header{}}
+	for k, v := range headers {
+		response.Header.Set(k, v)
+	}
+	return response
+}
+
+func TestValidateResponseHeaders_Valid(t *testing.T) {
+	doc, _ := libopenapi.NewDocument([]byte(headersSpec))
+	m, _ := doc.BuildV3Model()
+	v := NewResponseBodyValidator(&m.Model)
+
+	request, _ := http.NewRequest(http.MethodPost, "https://things.com/burgers", nil)
+	response := headerResponse(http.StatusCreated, map[string]string{
+		"Location":              "/burgers/1234",
+		"X-RateLimit-Remaining": "10",
+		"X-Burger-Ids":          "1, 2,3",
+		"X-Burger":              "name,Big Mac,patties,2",
+		"X-Burger-Exploded":     "patties=3",
+		"X-Burger-Json":         `{"name": "Big Mac"}`,
+		"ETag":                  `"abc123"`,
+	})
+	valid, errs := v.ValidateResponseBody(request, response)
+	assert.True(t, valid)
+	assert.Empty(t, errs)
+}
+
+func TestValidateResponseHeaders_Missing(t *testing.T) {
+	doc, _ := libopenapi.NewDocument([]byte(headersSpec))
+	m, _ := doc.BuildV3Model()
+	v := NewResponseBodyValidator(&m.Model)
+
+	// the Content-Type definition is ignored, and the $ref'd ETag header is required.
+	request, _ := http.NewRequest(http.MethodPost, "https://things.com/burgers", nil)
+	valid, errs := v.ValidateResponseBody(request, headerResponse(http.StatusCreated, nil))
+	assert.False(t, valid)
+	require.Len(t, errs, 2)
+	assert.Equal(t, helpers.ResponseHeaderValidation, errs[0].ValidationType)
+	assert.Equal(t, "missing", errs[0].ValidationSubType)
+	assert.Equal(t, "Response header 'Location' is missing", errs[0].Message)
+	assert.Equal(t, "The response header 'Location' is defined as being required for status code '201', "+
+		"however it's missing from the response", errs[0].Reason)
+	assert.Equal(t, 10, errs[0].SpecLine)
+	assert.Equal(t, "/burgers", errs[0].SpecPath)
+	assert.Equal(t, "Response header 'ETag' is missing", errs[1].Message)
+
+	// the default response has headers too.
+	response := headerResponse(http.StatusInternalServerError, map[string]string{
+		helpers.ContentTypeHeader: helpers.JSONContentType,
+	})
+	response.Body = io.NopCloser(strings.NewReader("{}"))
+	valid, errs = v.ValidateResponseBody(request, response)
+	assert.False(t, valid)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "Response header 'X-Request-Id' is missing", errs[0].Message)
+}
+
+func TestValidateResponseHeaders_Schema(t *testing.T) {
+	doc, _ := libopenapi.NewDocument([]byte(headersSpec))
+	m, _ := doc.BuildV3Model()
+	v := NewResponseBodyValidator(&m.Model)
+
+	request, _ := http.NewRequest(http.MethodPost, "https://things.com/burgers", nil)
+	response := headerResponse(http.StatusCreated, map[string]string{
+		"Location":              "/burgers/1234",
+		"X-RateLimit-Remaining": "-1",
+		"X-Burger-Ids":          "1,two",
+		"X-Burger":              "name,Big Mac,patties,lots",
+		"X-Burger-Exploded":     "patties=4",
+		"X-Burger-Json":         `{"patties": 2}`,
+		"ETag":                  "W/abc",
+	})
+	valid, errs := v.ValidateResponseBody(request, response)
+	assert.False(t, valid)
+	require.Len(t, errs, 6)
+
+	var failed []string
+	for _, e := range errs {
+		assert.Equal(t, helpers.ResponseHeaderValidation, e.ValidationType)
+		assert.Equal(t, helpers.Schema, e.ValidationSubType)
+		assert.NotEmpty(t, e.SchemaValidationErrors)
+		failed = append(failed, e.Message)
+	}
+	assert.Equal(t, []string{
+		"Response header 'X-RateLimit-Remaining' failed to validate",
+		"Response header 'X-Burger-Ids' failed to validate",
+		"Response header 'X-Burger' failed to validate",
+		"Response header 'X-Burger-Exploded' failed to validate",
+		"Response header 'X-Burger-Json' failed to validate",
+		"Response header 'ETag' failed to validate",
+	}, failed)
+	assert.Equal(t, "The response header 'X-RateLimit-Remaining' for status code '201' does not meet the schema "+
+		"requirements of the specification, the value is '-1'", errs[0].Reason)
+}
+
+func TestValidateResponseHeaders_CannotBeDecoded(t *testing.T) {
+	doc, _ := libopenapi.NewDocument([]byte(headersSpec))
+	m, _ := doc.BuildV3Model()
+	v := NewResponseBodyValidator(&m.Model)
+
+	request, _ := http.NewRequest(http.MethodPost, "https://things.com/burgers", nil)
+	response := headerResponse(http.StatusCreated, map[string]string{
+		"Location":          "/burgers/1234",
+		"ETag":              `"abc123"`,
+		"X-Burger":          "name",
+		"X-Burger-Exploded": "patties",
+		"X-Burger-Json":     `{"name":`,
+	})
+	valid, errs := v.ValidateResponseBody(request, response)
+	assert.False(t, valid)
+	require.Len(t, errs, 3)
+	assert.Equal(t, "Response header 'X-Burger' cannot be decoded", errs[0].Message)
+	assert.Equal(t, "The response header 'X-Burger' value 'name' cannot be decoded: "+
+		"the value cannot be extracted into an object", errs[0].Reason)
+	assert.Equal(t, "Response header 'X-Burger-Exploded' cannot be decoded", errs[1].Message)
+	assert.Equal(t, "Response header 'X-Burger-Json' cannot be decoded", errs[2].Message)
+}
+
+func TestDecodeSimpleHeader_MultipleLines(t *testing.T) {
+	doc, _ := libopenapi.NewDocument([]byte(headersSpec))
+	m, _ := doc.BuildV3Model()
+	v := NewResponseBodyValidator(&m.Model)
+
+	// repeated header lines are combined into a single array.
+	request, _ := http.NewRequest(http.MethodPost, "https://things.com/burgers", nil)
+	response := headerResponse(http.StatusCreated, map[string]string{
+		"Location": "/burgers/1234",
+		"ETag":     `"abc123"`,
+	})
+	response.Header.Add("X-Burger-Ids", "1")
+	response.Header.Add("X-Burger-Ids", "2")
+	valid, _ := v.ValidateResponseBody(request, response)
+	assert.True(t, valid)
+
+	response.Header.Add("X-Burger-Ids", "three")
+	valid, errs := v.ValidateResponseBody(request, response)
+	assert.False(t, valid)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "The response header 'X-Burger-Ids' for status code '201' does not meet the schema "+
+		"requirements of the specification, the value is '1, 2, three'", errs[0].Reason)
+}
+
+func TestValidateResponseHeaders_Content(t *testing.T) {
+	doc, _ := libopenapi.NewDocument([]byte(headersSpec))
+	m, _ := doc.BuildV3Model()
+	v := NewResponseBodyValidator(&m.Model)
+
+	request, _ := http.NewRequest(http.MethodPost, "https://things.com/burgers", nil)
+	response := headerResponse(http.StatusCreated, map[string]string{
+		"Location":      "/burgers/1234",
+		"ETag":          `"abc123"`,
+		"X-Burger-Text": "Big Mac",
+		"X-Burger-Xml":  "<burger/>",
+	})
+
+	// a text value is validated as it is, and a media type that cannot be decoded is not validated.
+	valid, errs := v.ValidateResponseBody(request, response)
+	assert.True(t, valid)
+	assert.Empty(t, errs)
+
+	response.Header.Set("X-Burger-Text", "Quarter Pounder")
+	valid, errs = v.ValidateResponseBody(request, response)
+	assert.False(t, valid)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "Response header 'X-Burger-Text' failed to validate", errs[0].Message)
+
+	// a registered decoder is used for the media type.
+	v = NewResponseBodyValidator(&m.Model, config.WithBodyDecoder("application/xml",
+		config.BodyDecoderFunc(func(body []byte, _ *base.Schema) (any, error) {
+			return string(body), nil
+		})))
+	response.Header.Set("X-Burger-Text", "Big Mac")
+	valid, errs = v.ValidateResponseBody(request, response)
+	assert.False(t, valid)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "Response header 'X-Burger-Xml' failed to validate", errs[0].Message)
+}
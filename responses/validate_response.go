@@ -5,6 +5,7 @@ package responses
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -12,7 +13,6 @@ import (
 	"reflect"
 	"regexp"
 	"strconv"
-	"strings"
 
 	"github.com/pb33f/libopenapi/datamodel/high/base"
 	"github.com/santhosh-tekuri/jsonschema/v6"
@@ -20,6 +20,7 @@ import (
 	"golang.org/x/text/message"
 	"gopkg.in/yaml.v3"
 
+	"github.com/pb33f/libopenapi-validator/config"
 	"github.com/pb33f/libopenapi-validator/errors"
 	"github.com/pb33f/libopenapi-validator/helpers"
 	"github.com/pb33f/libopenapi-validator/schema_validation"
@@ -32,13 +33,32 @@ var instanceLocationRegex = regexp.MustCompile(`^/(\d+)`)
 // schema of the response body are valid.
 //
 // This function is used by the ValidateResponseBody function, but can be used independently.
+// Options can be supplied to configure the schema compiler.
 func ValidateResponseSchema(
 	request *http.Request,
 	response *http.Response,
 	schema *base.Schema,
 	renderedSchema,
 	jsonSchema []byte,
+	opts ...config.Option,
 ) (bool, []*errors.ValidationError) {
+	options := config.NewValidationOptions(opts...)
+	return validateResponseSchema(context.Background(), request, response, &schemaCache{
+		schema:       schema,
+		sharedSchema: &sharedSchema{renderedInline: renderedSchema, renderedJSON: jsonSchema},
+	}, options)
+}
+
+// validateResponseSchema validates the response body for a http.Response pointer against a schema, which is
+// compiled the first time it is needed. Validators supply a schema from their cache, so it is only compiled once.
+func validateResponseSchema(
+	ctx context.Context,
+	request *http.Request,
+	response *http.Response,
+	cached *schemaCache,
+	options *config.ValidationOptions,
+) (bool, []*errors.ValidationError) {
+	schema, renderedSchema, jsonSchema := cached.schema, cached.renderedInline, cached.renderedJSON
 	var validationErrors []*errors.ValidationError
 
 	if response == nil || response.Body == nil {
@@ -63,8 +83,18 @@ func ValidateResponseSchema(
 		return false, validationErrors
 	}
 
-	responseBody, ioErr := io.ReadAll(response.Body)
-	if ioErr != nil {
+	responseBody, ioErr := helpers.ReadBody(helpers.NewContextReader(ctx, response.Body), options.MaxBodySize,
+		responseContentLength(request, response))
+	if tooLarge, ok := ioErr.(*helpers.BodyTooLargeError); ok {
+		// leave the unread remainder of the body in place, so it can still be consumed.
+		response.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(responseBody), response.Body), response.Body}
+		return false, []*errors.ValidationError{errors.ResponseBodyTooLarge(request, response, tooLarge)}
+	}
+	mismatch, _ := ioErr.(*helpers.ContentLengthError)
+	if ioErr != nil && mismatch == nil {
 		// cannot decode the response body, so it's not valid
 		violation := &errors.SchemaValidationFailure{
 			Reason:          ioErr.Error(),
@@ -91,138 +121,289 @@ func ValidateResponseSchema(
 	_ = response.Body.Close()
 	response.Body = io.NopCloser(bytes.NewBuffer(responseBody))
 
+	if mismatch != nil {
+		return false, []*errors.ValidationError{errors.ResponseContentLengthMismatch(request, response, mismatch)}
+	}
+
+	// the original bytes are left on the response, only the copy that is validated is decompressed.
+	var contentErr error
+	responseBody, contentErr = helpers.DecodeContent(ctx, responseBody,
+		response.Header.Get(helpers.ContentEncodingHeader), options)
+	if contentErr != nil {
+		return false, []*errors.ValidationError{responseContentEncodingError(request, response, contentErr)}
+	}
+
 	var decodedObj interface{}
+	var elementPaths helpers.XMLPaths
+	var jsch *jsonschema.Schema
 
 	if len(responseBody) > 0 {
-		err := json.Unmarshal(responseBody, &decodedObj)
+		var err error
+		contentType := response.Header.Get(helpers.ContentTypeHeader)
+		_, charset, _ := helpers.ExtractContentType(contentType)
+		isXML := helpers.IsXMLContentType(contentType)
+		decoder := options.FindBodyDecoder(contentType)
+		if decoder == nil && (charset != "" || !isXML) {
+			// the built-in decoding works on UTF-8, so the body is transcoded from its charset first. An XML body
+			// without a charset is transcoded from the encoding declaration of the document instead.
+			if responseBody, err = helpers.DecodeCharset(responseBody, charset); err != nil {
+				return false, []*errors.ValidationError{
+					errors.ResponseBodyCannotBeDecoded(request, response, contentType, err.Error()),
+				}
+			}
+		}
+		if decoder != nil {
+			// a registered decoder is used over the built-in decoding.
+			decodedObj, err = config.DecodeBody(ctx, decoder, responseBody, schema)
+		} else if isXML && charset == "" {
+			// XML bodies are decoded using the 'xml' objects of the schema.
+			decodedObj, elementPaths, err = helpers.DecodeXMLDocument(responseBody, schema)
+		} else if isXML {
+			decodedObj, elementPaths, err = helpers.DecodeXML(responseBody, schema)
+		} else if helpers.IsJSONArray(responseBody) {
+			// arrays are validated one item at a time when the schema allows it, so they are never materialized.
+			jsch, _ = cached.compile(ctx, options)
+			if jsch != nil {
+				if items := helpers.StreamableItems(jsch); items != nil {
+					return validateResponseItems(request, response, schema, renderedSchema, items, responseBody)
+				}
+			}
+			err = json.Unmarshal(responseBody, &decodedObj)
+		} else {
+			err = json.Unmarshal(responseBody, &decodedObj)
+		}
 		if err != nil {
-			// cannot decode the response body, so it's not valid
-			violation := &errors.SchemaValidationFailure{
-				Reason:          err.Error(),
-				Location:        "unavailable",
-				ReferenceSchema: string(renderedSchema),
-				ReferenceObject: string(responseBody),
+			return false, []*errors.ValidationError{
+				responseBodyDecodingError(request, renderedSchema, responseBody, err),
 			}
+		}
+	}
+
+	// no response body? failed to decode anything? nothing to do here.
+	if responseBody == nil || decodedObj == nil {
+		return true, nil
+	}
+
+	// compile the rendered JSON schema, unless it has already been compiled.
+	if jsch == nil {
+		var err error
+		jsch, err = cached.compile(ctx, options)
+		if err != nil {
 			validationErrors = append(validationErrors, &errors.ValidationError{
 				ValidationType:    helpers.ResponseBodyValidation,
 				ValidationSubType: helpers.Schema,
-				Message: fmt.Sprintf("%s response body for '%s' failed to validate schema",
-					request.Method, request.URL.Path),
-				Reason:                 fmt.Sprintf("The response body cannot be decoded: %s", err.Error()),
-				SpecLine:               1,
-				SpecCol:                0,
-				SchemaValidationErrors: []*errors.SchemaValidationFailure{violation},
-				HowToFix:               errors.HowToFixInvalidSchema,
-				Context:                string(renderedSchema), // attach the rendered schema to the error
+				Message:           err.Error(),
+				Reason:            "Failed to compile the response body schema.",
+				Context:           string(jsonSchema),
 			})
 			return false, validationErrors
 		}
 	}
+	return validateResponseInstance(request, response, schema, renderedSchema, jsch, decodedObj, responseBody,
+		elementPaths)
+}
 
-	// no response body? failed to decode anything? nothing to do here.
-	if responseBody == nil || decodedObj == nil {
-		return true, nil
+// responseBodyDecodingError reports a response body that cannot be decoded, so it's not valid.
+func responseBodyDecodingError(request *http.Request, renderedSchema, responseBody []byte, err error) *errors.ValidationError {
+	violation := &errors.SchemaValidationFailure{
+		Reason:          err.Error(),
+		Location:        "unavailable",
+		ReferenceSchema: string(renderedSchema),
+		ReferenceObject: string(responseBody),
 	}
+	return &errors.ValidationError{
+		ValidationType:    helpers.ResponseBodyValidation,
+		ValidationSubType: helpers.Schema,
+		Message: fmt.Sprintf("%s response body for '%s' failed to validate schema",
+			request.Method, request.URL.Path),
+		Reason:                 fmt.Sprintf("The response body cannot be decoded: %s", err.Error()),
+		SpecLine:               1,
+		SpecCol:                0,
+		SchemaValidationErrors: []*errors.SchemaValidationFailure{violation},
+		HowToFix:               errors.HowToFixInvalidSchema,
+		Context:                string(renderedSchema), // attach the rendered schema to the error
+	}
+}
 
-	// create a new jsonschema compiler and add in the rendered JSON schema.
-	compiler := jsonschema.NewCompiler()
-	compiler.UseLoader(helpers.NewCompilerLoader())
-	fName := fmt.Sprintf("%s.json", helpers.ResponseBodyValidation)
-	decodedSchema, _ := jsonschema.UnmarshalJSON(strings.NewReader(string(jsonSchema)))
-	_ = compiler.AddResource(fName, decodedSchema)
-	jsch, _ := compiler.Compile(fName)
-
+// validateResponseInstance validates a decoded response body against a schema that has already been compiled.
+func validateResponseInstance(
+	request *http.Request,
+	response *http.Response,
+	schema *base.Schema,
+	renderedSchema []byte,
+	jsch *jsonschema.Schema,
+	decodedObj any,
+	responseBody []byte,
+	elementPaths helpers.XMLPaths,
+) (bool, []*errors.ValidationError) {
 	// validate the object against the schema
 	scErrs := jsch.Validate(decodedObj)
-	if scErrs != nil {
-		jk := scErrs.(*jsonschema.ValidationError)
-
-		// flatten the validationErrors
-		schFlatErrs := jk.BasicOutput().Errors
-		var schemaValidationErrors []*errors.SchemaValidationFailure
-		for q := range schFlatErrs {
-			er := schFlatErrs[q]
-
-			errMsg := er.Error.Kind.LocalizedString(message.NewPrinter(language.Tag{}))
-			if er.KeywordLocation == "" || helpers.IgnoreRegex.MatchString(errMsg) {
-				continue // ignore this error, it's useless tbh, utter noise.
+	if scErrs == nil {
+		return true, nil
+	}
+
+	// extract the element specified by the instance
+	reference := func(instanceLocation string) string {
+		val := instanceLocationRegex.FindStringSubmatch(instanceLocation)
+		if len(val) > 0 {
+			referenceIndex, _ := strconv.Atoi(val[1])
+			if reflect.ValueOf(decodedObj).Type().Kind() == reflect.Slice {
+				found := decodedObj.([]any)[referenceIndex]
+				recoded, _ := json.MarshalIndent(found, "", "  ")
+				return string(recoded)
 			}
-			if er.Error != nil {
+		}
+		return string(responseBody)
+	}
+	violations := schemaValidationFailures(scErrs.(*jsonschema.ValidationError), renderedSchema, "", reference,
+		elementPaths)
+	return false, []*errors.ValidationError{responseSchemaError(request, response, schema, renderedSchema, violations)}
+}
 
-				// re-encode the schema.
-				var renderedNode yaml.Node
-				_ = yaml.Unmarshal(renderedSchema, &renderedNode)
+// validateResponseItems validates the items of a JSON array response body one at a time, against the compiled
+// items schema, as they are decoded. The array is never fully materialized, only a single item is held in memory.
+func validateResponseItems(
+	request *http.Request,
+	response *http.Response,
+	schema *base.Schema,
+	renderedSchema []byte,
+	items *jsonschema.Schema,
+	responseBody []byte,
+) (bool, []*errors.ValidationError) {
+	var violations []*errors.SchemaValidationFailure
+	var failed bool
+	err := helpers.DecodeJSONArray(bytes.NewReader(responseBody), func(index int, item any) {
+		scErrs := items.Validate(item)
+		if scErrs == nil {
+			return
+		}
+		failed = true
+		reference := func(string) string {
+			recoded, _ := json.MarshalIndent(item, "", "  ")
+			return string(recoded)
+		}
+		// the item was validated on its own, so the locations are relative to the items schema.
+		violations = append(violations, schemaValidationFailures(scErrs.(*jsonschema.ValidationError),
+			renderedSchema, "/items", reference, nil)...)
+	})
+	if err != nil {
+		return false, []*errors.ValidationError{
+			responseBodyDecodingError(request, renderedSchema, responseBody, err),
+		}
+	}
+	if !failed {
+		return true, nil
+	}
+	return false, []*errors.ValidationError{responseSchemaError(request, response, schema, renderedSchema, violations)}
+}
 
-				// locate the violated property in the schema
-				located := schema_validation.LocateSchemaPropertyNodeByJSONPath(renderedNode.Content[0], er.KeywordLocation)
+// schemaValidationFailures flattens a schema validation error into schema validation failures. The keyword
+// locations are prefixed with the keyword prefix, so a sub-schema that was validated on its own is located within
+// the rendered schema. The reference object of each failure is looked up using the instance location.
+func schemaValidationFailures(
+	jk *jsonschema.ValidationError,
+	renderedSchema []byte,
+	keywordPrefix string,
+	reference func(instanceLocation string) string,
+	elementPaths helpers.XMLPaths,
+) []*errors.SchemaValidationFailure {
+	// flatten the validationErrors
+	schFlatErrs := jk.BasicOutput().Errors
+	var schemaValidationErrors []*errors.SchemaValidationFailure
+	for q := range schFlatErrs {
+		er := schFlatErrs[q]
 
-				// extract the element specified by the instance
-				val := instanceLocationRegex.FindStringSubmatch(er.InstanceLocation)
-				var referenceObject string
+		errMsg := er.Error.Kind.LocalizedString(message.NewPrinter(language.Tag{}))
+		if er.KeywordLocation == "" || helpers.IgnoreRegex.MatchString(errMsg) {
+			continue // ignore this error, it's useless tbh, utter noise.
+		}
+		if er.Error != nil {
+			keywordLocation := keywordPrefix + er.KeywordLocation
 
-				if len(val) > 0 {
-					referenceIndex, _ := strconv.Atoi(val[1])
-					if reflect.ValueOf(decodedObj).Type().Kind() == reflect.Slice {
-						found := decodedObj.([]any)[referenceIndex]
-						recoded, _ := json.MarshalIndent(found, "", "  ")
-						referenceObject = string(recoded)
-					}
-				}
-				if referenceObject == "" {
-					referenceObject = string(responseBody)
-				}
+			// re-encode the schema.
+			var renderedNode yaml.Node
+			_ = yaml.Unmarshal(renderedSchema, &renderedNode)
 
-				violation := &errors.SchemaValidationFailure{
-					Reason:          errMsg,
-					Location:        er.KeywordLocation,
-					ReferenceSchema: string(renderedSchema),
-					ReferenceObject: referenceObject,
-					OriginalError:   jk,
-				}
-				// if we have a location within the schema, add it to the error
-				if located != nil {
-
-					line := located.Line
-					// if the located node is a map or an array, then the actual human interpretable
-					// line on which the violation occurred is the line of the key, not the value.
-					if located.Kind == yaml.MappingNode || located.Kind == yaml.SequenceNode {
-						if line > 0 {
-							line--
-						}
-					}
+			// locate the violated property in the schema
+			located := schema_validation.LocateSchemaPropertyNodeByJSONPath(renderedNode.Content[0], keywordLocation)
 
-					// location of the violation within the rendered schema.
-					violation.Line = line
-					violation.Column = located.Column
+			violation := &errors.SchemaValidationFailure{
+				Reason:          errMsg,
+				Location:        keywordLocation,
+				ReferenceSchema: string(renderedSchema),
+				ReferenceObject: reference(er.InstanceLocation),
+				OriginalError:   jk,
+			}
+			if elementPaths != nil {
+				violation.ElementPath = elementPaths.Find(er.InstanceLocation)
+			}
+			// if we have a location within the schema, add it to the error
+			if located != nil {
+
+				line := located.Line
+				// if the located node is a map or an array, then the actual human interpretable
+				// line on which the violation occurred is the line of the key, not the value.
+				if located.Kind == yaml.MappingNode || located.Kind == yaml.SequenceNode {
+					if line > 0 {
+						line--
+					}
 				}
-				schemaValidationErrors = append(schemaValidationErrors, violation)
+
+				// location of the violation within the rendered schema.
+				violation.Line = line
+				violation.Column = located.Column
 			}
+			schemaValidationErrors = append(schemaValidationErrors, violation)
 		}
+	}
+	return schemaValidationErrors
+}
 
-		line := 1
-		col := 0
-		if schema.GoLow().Type.KeyNode != nil {
-			line = schema.GoLow().Type.KeyNode.Line
-			col = schema.GoLow().Type.KeyNode.Column
-		}
+// responseSchemaError wraps the schema validation failures of a response body.
+func responseSchemaError(
+	request *http.Request,
+	response *http.Response,
+	schema *base.Schema,
+	renderedSchema []byte,
+	violations []*errors.SchemaValidationFailure,
+) *errors.ValidationError {
+	line := 1
+	col := 0
+	if schema.GoLow().Type.KeyNode != nil {
+		line = schema.GoLow().Type.KeyNode.Line
+		col = schema.GoLow().Type.KeyNode.Column
+	}
 
-		// add the error to the list
-		validationErrors = append(validationErrors, &errors.ValidationError{
-			ValidationType:    helpers.ResponseBodyValidation,
-			ValidationSubType: helpers.Schema,
-			Message: fmt.Sprintf("%d response body for '%s' failed to validate schema",
-				response.StatusCode, request.URL.Path),
-			Reason: fmt.Sprintf("The response body for status code '%d' is defined as an object. "+
-				"However, it does not meet the schema requirements of the specification", response.StatusCode),
-			SpecLine:               line,
-			SpecCol:                col,
-			SchemaValidationErrors: schemaValidationErrors,
-			HowToFix:               errors.HowToFixInvalidSchema,
-			Context:                string(renderedSchema), // attach the rendered schema to the error
-		})
+	return &errors.ValidationError{
+		ValidationType:    helpers.ResponseBodyValidation,
+		ValidationSubType: helpers.Schema,
+		Message: fmt.Sprintf("%d response body for '%s' failed to validate schema",
+			response.StatusCode, request.URL.Path),
+		Reason: fmt.Sprintf("The response body for status code '%d' is defined as an object. "+
+			"However, it does not meet the schema requirements of the specification", response.StatusCode),
+		SpecLine:               line,
+		SpecCol:                col,
+		SchemaValidationErrors: violations,
+		HowToFix:               errors.HowToFixInvalidSchema,
+		Context:                string(renderedSchema), // attach the rendered schema to the error
 	}
-	if len(validationErrors) > 0 {
-		return false, validationErrors
+}
+
+// responseContentLength returns the content length of a response body, or -1 when the Content-Length header does
+// not describe the body, such as the response to a HEAD request, or a response that cannot have a body.
+func responseContentLength(request *http.Request, response *http.Response) int64 {
+	if request.Method == http.MethodHead || response.StatusCode == http.StatusNoContent ||
+		response.StatusCode == http.StatusNotModified {
+		return -1
+	}
+	return response.ContentLength
+}
+
+// responseContentEncodingError converts an error from decompressing a response body into a validation error.
+func responseContentEncodingError(request *http.Request, response *http.Response, err error) *errors.ValidationError {
+	if tooLarge, ok := err.(*helpers.BodyTooLargeError); ok {
+		return errors.ResponseBodyTooLarge(request, response, tooLarge)
 	}
-	return true, nil
+	return errors.ResponseBodyCannotBeDecoded(request, response, response.Header.Get(helpers.ContentTypeHeader),
+		err.Error())
 }
@@ -8,11 +8,9 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
-	"os"
 	"reflect"
 	"regexp"
 	"strconv"
-	"strings"
 	"sync"
 
 	"github.com/pb33f/libopenapi/datamodel/high/base"
@@ -24,6 +22,7 @@ import (
 
 	_ "embed"
 
+	"github.com/pb33f/libopenapi-validator/config"
 	liberrors "github.com/pb33f/libopenapi-validator/errors"
 	"github.com/pb33f/libopenapi-validator/helpers"
 )
@@ -50,21 +49,22 @@ type SchemaValidator interface {
 var instanceLocationRegex = regexp.MustCompile(`^/(\d+)`)
 
 type schemaValidator struct {
-	logger *slog.Logger
-	lock   sync.Mutex
+	options *config.ValidationOptions
+	logger  *slog.Logger
+	lock    sync.Mutex
 }
 
 // NewSchemaValidatorWithLogger will create a new SchemaValidator instance, ready to accept schemas and payloads to validate.
-func NewSchemaValidatorWithLogger(logger *slog.Logger) SchemaValidator {
-	return &schemaValidator{logger: logger, lock: sync.Mutex{}}
+func NewSchemaValidatorWithLogger(logger *slog.Logger, opts ...config.Option) SchemaValidator {
+	options := config.NewValidationOptions(append(opts, config.WithLogger(logger))...)
+	return &schemaValidator{options: options, logger: options.Logger, lock: sync.Mutex{}}
 }
 
 // NewSchemaValidator will create a new SchemaValidator instance, ready to accept schemas and payloads to validate.
-func NewSchemaValidator() SchemaValidator {
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelError,
-	}))
-	return NewSchemaValidatorWithLogger(logger)
+// Options can be supplied to configure the logger and the schema compiler.
+func NewSchemaValidator(opts ...config.Option) SchemaValidator {
+	options := config.NewValidationOptions(opts...)
+	return &schemaValidator{options: options, logger: options.Logger, lock: sync.Mutex{}}
 }
 
 func (s *schemaValidator) ValidateSchemaString(schema *base.Schema, payload string) (bool, []*liberrors.ValidationError) {
@@ -124,12 +124,7 @@ func (s *schemaValidator) validateSchema(schema *base.Schema, payload []byte, de
 		}
 
 	}
-	compiler := jsonschema.NewCompiler()
-	compiler.UseLoader(helpers.NewCompilerLoader())
-
-	decodedSchema, _ := jsonschema.UnmarshalJSON(strings.NewReader(string(jsonSchema)))
-	_ = compiler.AddResource("schema.json", decodedSchema)
-	jsch, err := compiler.Compile("schema.json")
+	jsch, err := helpers.NewCompiledSchema(helpers.Schema, jsonSchema, s.options)
 
 	var schemaValidationErrors []*liberrors.SchemaValidationFailure
 
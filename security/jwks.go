@@ -0,0 +1,172 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package security
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+// Key is a single key used to verify the signature of a token.
+type Key struct {
+	// ID is the 'kid' of the key, it may be empty.
+	ID string
+
+	// Algorithm is the 'alg' the key is restricted to, an empty value allows any algorithm suited to the key.
+	Algorithm string
+
+	// Key is the key itself, an *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey or a []byte HMAC secret.
+	Key crypto.PublicKey
+}
+
+// KeySet is a set of keys used to verify tokens, usually loaded from a JSON Web Key Set (RFC 7517).
+type KeySet struct {
+	Keys []*Key
+}
+
+// NewKeySet creates a KeySet from the supplied keys.
+func NewKeySet(keys ...*Key) *KeySet {
+	return &KeySet{Keys: keys}
+}
+
+// LoadJWKS reads a JSON Web Key Set from a local file, so tokens can be verified without any network access.
+func LoadJWKS(path string) (*KeySet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseJWKS(data)
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	K   string `json:"k"`
+}
+
+// ParseJWKS parses a JSON Web Key Set. Keys that are not used for signatures, or that have a key type that is not
+// supported, are skipped. RSA, EC (P-256, P-384 and P-521), OKP (Ed25519) and oct keys are supported.
+func ParseJWKS(data []byte) (*KeySet, error) {
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &jwks); err != nil {
+		return nil, fmt.Errorf("unable to parse JSON Web Key Set: %w", err)
+	}
+	ks := &KeySet{}
+	for i := range jwks.Keys {
+		jwk := &jwks.Keys[i]
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse key %d ('%s') of JSON Web Key Set: %w", i, jwk.Kid, err)
+		}
+		if key == nil {
+			continue
+		}
+		ks.Keys = append(ks.Keys, &Key{ID: jwk.Kid, Algorithm: jwk.Alg, Key: key})
+	}
+	return ks, nil
+}
+
+func (jwk *jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decodeBigInt(jwk.N, "n")
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(jwk.E, "e")
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("exponent is too large")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("curve '%s' is not supported", jwk.Crv)
+		}
+		x, err := decodeBigInt(jwk.X, "x")
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(jwk.Y, "y")
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("point is not on curve '%s'", jwk.Crv)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+
+	case "OKP":
+		if jwk.Crv != "Ed25519" {
+			return nil, fmt.Errorf("curve '%s' is not supported", jwk.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("parameter 'x' is not a valid Ed25519 public key")
+		}
+		return ed25519.PublicKey(x), nil
+
+	case "oct":
+		k, err := base64.RawURLEncoding.DecodeString(jwk.K)
+		if err != nil || len(k) == 0 {
+			return nil, fmt.Errorf("parameter 'k' is not a valid secret")
+		}
+		return k, nil
+	}
+	return nil, nil
+}
+
+func decodeBigInt(value, name string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(b) == 0 {
+		return nil, fmt.Errorf("parameter '%s' is not valid base64url", name)
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// candidates returns the keys that may have signed a token, using the key ID and algorithm of the token.
+func (ks *KeySet) candidates(kid, alg string) []*Key {
+	var found []*Key
+	for _, key := range ks.Keys {
+		if kid != "" && key.ID != "" && key.ID != kid {
+			continue
+		}
+		if key.Algorithm != "" && key.Algorithm != alg {
+			continue
+		}
+		found = append(found, key)
+	}
+	return found
+}
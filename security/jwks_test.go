@@ -0,0 +1,77 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package security
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadJWKS(t *testing.T) {
+	ks, err := LoadJWKS("../test_specs/jwks.json")
+	require.NoError(t, err)
+
+	// the encryption key is skipped.
+	require.Len(t, ks.Keys, 2)
+	assert.Equal(t, "pb33f-hmac", ks.Keys[0].ID)
+	assert.Equal(t, "HS256", ks.Keys[0].Algorithm)
+	assert.Equal(t, []byte("pb33f-offline-jwks-test-secret!!"), ks.Keys[0].Key)
+
+	assert.Equal(t, "pb33f-ec", ks.Keys[1].ID)
+	ec, ok := ks.Keys[1].Key.(*ecdsa.PublicKey)
+	require.True(t, ok)
+	assert.Equal(t, elliptic.P256(), ec.Curve)
+
+	_, err = LoadJWKS("../test_specs/missing.json")
+	assert.Error(t, err)
+}
+
+func TestParseJWKS_KeyTypes(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	edKey, _, _ := ed25519.GenerateKey(rand.Reader)
+	b := base64.RawURLEncoding
+
+	jwks := fmt.Sprintf(`{"keys": [
+		{"kty": "RSA", "kid": "rsa", "n": "%s", "e": "%s"},
+		{"kty": "OKP", "kid": "ed", "crv": "Ed25519", "x": "%s"},
+		{"kty": "unknown", "kid": "skipped"}
+	]}`, b.EncodeToString(rsaKey.N.Bytes()), b.EncodeToString(big.NewInt(int64(rsaKey.E)).Bytes()),
+		b.EncodeToString(edKey))
+
+	ks, err := ParseJWKS([]byte(jwks))
+	require.NoError(t, err)
+	require.Len(t, ks.Keys, 2)
+	assert.True(t, rsaKey.PublicKey.Equal(ks.Keys[0].Key))
+	assert.Equal(t, edKey, ks.Keys[1].Key)
+}
+
+func TestParseJWKS_Invalid(t *testing.T) {
+	_, err := ParseJWKS([]byte(`not json`))
+	assert.ErrorContains(t, err, "unable to parse JSON Web Key Set")
+
+	_, err = ParseJWKS([]byte(`{"keys": [{"kty": "RSA", "kid": "rsa", "n": "", "e": "AQAB"}]}`))
+	assert.EqualError(t, err, "unable to parse key 0 ('rsa') of JSON Web Key Set: parameter 'n' is not valid base64url")
+
+	_, err = ParseJWKS([]byte(`{"keys": [{"kty": "EC", "crv": "P-192", "x": "AA", "y": "AA"}]}`))
+	assert.EqualError(t, err, "unable to parse key 0 ('') of JSON Web Key Set: curve 'P-192' is not supported")
+
+	_, err = ParseJWKS([]byte(`{"keys": [{"kty": "EC", "crv": "P-256", "x": "AQ", "y": "AQ"}]}`))
+	assert.EqualError(t, err, "unable to parse key 0 ('') of JSON Web Key Set: point is not on curve 'P-256'")
+
+	_, err = ParseJWKS([]byte(`{"keys": [{"kty": "OKP", "crv": "Ed25519", "x": "AQ"}]}`))
+	assert.ErrorContains(t, err, "parameter 'x' is not a valid Ed25519 public key")
+
+	_, err = ParseJWKS([]byte(`{"keys": [{"kty": "oct", "k": ""}]}`))
+	assert.ErrorContains(t, err, "parameter 'k' is not a valid secret")
+}
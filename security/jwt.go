@@ -0,0 +1,203 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package security
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	// register the hash functions used by the supported algorithms.
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+)
+
+// Claims are the claims of a verified token. Claims are returned as the principal by JWTAuthenticator.
+type Claims map[string]any
+
+// Subject returns the 'sub' claim of the token.
+func (c Claims) Subject() string {
+	s, _ := c["sub"].(string)
+	return s
+}
+
+// Scopes returns the scopes granted to the token. Scopes are read from the 'scope' claim as a space delimited
+// string (RFC 8693), or from the 'scp' claim as either a string or an array.
+func (c Claims) Scopes() []string {
+	var scopes []string
+	for _, name := range []string{"scope", "scp"} {
+		switch v := c[name].(type) {
+		case string:
+			scopes = append(scopes, strings.Fields(v)...)
+		case []any:
+			for _, s := range v {
+				if str, ok := s.(string); ok {
+					scopes = append(scopes, str)
+				}
+			}
+		}
+	}
+	return scopes
+}
+
+// Audience returns the 'aud' claim of the token, which may be a string or an array.
+func (c Claims) Audience() []string {
+	switch v := c["aud"].(type) {
+	case string:
+		return []string{v}
+	case []any:
+		var aud []string
+		for _, s := range v {
+			if str, ok := s.(string); ok {
+				aud = append(aud, str)
+			}
+		}
+		return aud
+	}
+	return nil
+}
+
+// time returns a NumericDate claim, such as 'exp' or 'nbf'.
+func (c Claims) time(name string) (time.Time, bool) {
+	v, ok := c[name].(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+	f, err := v.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+	sec := int64(f)
+	return time.Unix(sec, int64((f-float64(sec))*float64(time.Second))), true
+}
+
+type tokenHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// verifyToken checks the structure and signature of a compact serialized JWT, and returns its claims.
+// Expiry and other claims are not checked.
+func verifyToken(token string, keys *KeySet) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("token is not a JWT, it must have three parts separated by '.'")
+	}
+	var header tokenHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("token header is not valid: %w", err)
+	}
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("token claims are not valid: %w", err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("token signature is not valid base64url")
+	}
+
+	hash, ok := algorithmHash(header.Alg)
+	if !ok {
+		return nil, fmt.Errorf("token algorithm '%s' is not supported", header.Alg)
+	}
+	signed := []byte(parts[0] + "." + parts[1])
+	var digest []byte
+	if hash != 0 {
+		h := hash.New()
+		h.Write(signed)
+		digest = h.Sum(nil)
+	}
+
+	if keys != nil {
+		for _, key := range keys.candidates(header.Kid, header.Alg) {
+			if verifySignature(header.Alg, hash, key.Key, signed, digest, signature) {
+				return claims, nil
+			}
+		}
+	}
+	if header.Kid != "" {
+		return nil, fmt.Errorf("token signature could not be verified with key '%s'", header.Kid)
+	}
+	return nil, fmt.Errorf("token signature could not be verified")
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return fmt.Errorf("not valid base64url")
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// algorithmHash returns the hash used by a JWS algorithm. EdDSA signs the message itself, so it has no hash.
+func algorithmHash(alg string) (crypto.Hash, bool) {
+	switch alg {
+	case "RS256", "PS256", "ES256", "HS256":
+		return crypto.SHA256, true
+	case "RS384", "PS384", "ES384", "HS384":
+		return crypto.SHA384, true
+	case "RS512", "PS512", "ES512", "HS512":
+		return crypto.SHA512, true
+	case "EdDSA":
+		return 0, true
+	}
+	return 0, false
+}
+
+func verifySignature(alg string, hash crypto.Hash, key crypto.PublicKey, signed, digest, signature []byte) bool {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		switch alg[:2] {
+		case "RS":
+			return rsa.VerifyPKCS1v15(k, hash, digest, signature) == nil
+		case "PS":
+			return rsa.VerifyPSS(k, hash, digest, signature,
+				&rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}) == nil
+		}
+	case *ecdsa.PublicKey:
+		if alg[:2] != "ES" {
+			return false
+		}
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size || k.Curve.Params().BitSize != curveBits(alg) {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		return ecdsa.Verify(k, digest, r, s)
+	case ed25519.PublicKey:
+		return alg == "EdDSA" && ed25519.Verify(k, signed, signature)
+	case []byte:
+		if alg[:2] != "HS" {
+			return false
+		}
+		mac := hmac.New(hash.New, k)
+		mac.Write(signed)
+		return hmac.Equal(mac.Sum(nil), signature)
+	}
+	return false
+}
+
+func curveBits(alg string) int {
+	switch alg {
+	case "ES256":
+		return 256
+	case "ES384":
+		return 384
+	case "ES512":
+		return 521
+	}
+	return 0
+}
@@ -0,0 +1,147 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package security
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pb33f/libopenapi-validator/config"
+	"github.com/pb33f/libopenapi-validator/helpers"
+)
+
+// JWTAuthenticator verifies bearer tokens sent in the 'Authorization' header. Tokens are verified offline against
+// a KeySet, must not have expired, and must carry every scope listed by the security requirement of the operation.
+// The Claims of the token are returned as the principal.
+//
+// Failures are reported with a distinct validation sub-type:
+//
+//	missingToken      - there is no bearer token in the request
+//	expiredToken      - the token has expired
+//	invalidToken      - the token is malformed, its signature does not verify, or its issuer or audience is wrong
+//	insufficientScope - the token does not carry all the required scopes
+type JWTAuthenticator struct {
+	keys     *KeySet
+	issuer   string
+	audience string
+	leeway   time.Duration
+	now      func() time.Time
+}
+
+// JWTOption configures a JWTAuthenticator.
+type JWTOption func(*JWTAuthenticator)
+
+// WithIssuer requires the 'iss' claim of tokens to match the issuer.
+func WithIssuer(issuer string) JWTOption {
+	return func(a *JWTAuthenticator) {
+		a.issuer = issuer
+	}
+}
+
+// WithAudience requires the 'aud' claim of tokens to contain the audience.
+func WithAudience(audience string) JWTOption {
+	return func(a *JWTAuthenticator) {
+		a.audience = audience
+	}
+}
+
+// WithLeeway allows for clock skew when checking the 'exp' and 'nbf' claims of tokens.
+func WithLeeway(leeway time.Duration) JWTOption {
+	return func(a *JWTAuthenticator) {
+		a.leeway = leeway
+	}
+}
+
+// WithClock sets the function used to read the current time, which is useful for testing. A nil function is ignored.
+func WithClock(now func() time.Time) JWTOption {
+	return func(a *JWTAuthenticator) {
+		if now != nil {
+			a.now = now
+		}
+	}
+}
+
+// NewJWTAuthenticator creates a JWTAuthenticator that verifies tokens using the supplied keys.
+func NewJWTAuthenticator(keys *KeySet, opts ...JWTOption) *JWTAuthenticator {
+	a := &JWTAuthenticator{keys: keys, now: time.Now}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(a)
+		}
+	}
+	return a
+}
+
+// Authenticate verifies the bearer token of the request, and checks it carries the scopes of the requirement.
+func (a *JWTAuthenticator) Authenticate(input *config.AuthenticationInput) (any, error) {
+	token := bearerToken(input.Request.Header.Get(helpers.AuthorizationHeader))
+	if token == "" {
+		return nil, &config.AuthenticationError{
+			SubType:  helpers.SecurityMissingToken,
+			Reason:   fmt.Sprintf("A bearer token is required by security scheme '%s', but none was supplied", input.SchemeName),
+			HowToFix: "Add an 'Authorization' header with a bearer token, for example 'Authorization: Bearer <token>'",
+		}
+	}
+
+	claims, err := verifyToken(token, a.keys)
+	if err != nil {
+		return nil, invalidToken(err.Error())
+	}
+
+	now := a.now()
+	if exp, ok := claims.time("exp"); ok && !now.Before(exp.Add(a.leeway)) {
+		return nil, &config.AuthenticationError{
+			SubType:  helpers.SecurityExpiredToken,
+			Reason:   fmt.Sprintf("The bearer token expired at %s", exp.UTC().Format(time.RFC3339)),
+			HowToFix: "Obtain a new token and retry the request",
+		}
+	}
+	if nbf, ok := claims.time("nbf"); ok && now.Add(a.leeway).Before(nbf) {
+		return nil, invalidToken(fmt.Sprintf("The bearer token is not valid until %s", nbf.UTC().Format(time.RFC3339)))
+	}
+	if a.issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != a.issuer {
+			return nil, invalidToken(fmt.Sprintf("The bearer token was issued by '%s', not '%s'", iss, a.issuer))
+		}
+	}
+	if a.audience != "" && !slices.Contains(claims.Audience(), a.audience) {
+		return nil, invalidToken(fmt.Sprintf("The bearer token is not intended for audience '%s'", a.audience))
+	}
+
+	granted := claims.Scopes()
+	var missing []string
+	for _, scope := range input.Scopes {
+		if !slices.Contains(granted, scope) {
+			missing = append(missing, scope)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, &config.AuthenticationError{
+			SubType: helpers.SecurityInsufficientScope,
+			Reason: fmt.Sprintf("The bearer token does not grant the scopes required by security scheme '%s': %s",
+				input.SchemeName, strings.Join(missing, ", ")),
+			HowToFix: fmt.Sprintf("Request a token that grants the '%s' scopes", strings.Join(input.Scopes, " ")),
+		}
+	}
+	return claims, nil
+}
+
+func invalidToken(reason string) *config.AuthenticationError {
+	return &config.AuthenticationError{
+		SubType:  helpers.SecurityInvalidToken,
+		Reason:   reason,
+		HowToFix: "Supply a valid, signed bearer token",
+	}
+}
+
+// bearerToken returns the token from an 'Authorization' header that uses the 'Bearer' scheme.
+func bearerToken(header string) string {
+	scheme, token, found := strings.Cut(strings.TrimSpace(header), " ")
+	if !found || !strings.EqualFold(scheme, "bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
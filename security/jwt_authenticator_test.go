@@ -0,0 +1,286 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+package security
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/pb33f/libopenapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pb33f/libopenapi-validator/config"
+	"github.com/pb33f/libopenapi-validator/helpers"
+	"github.com/pb33f/libopenapi-validator/parameters"
+)
+
+var testSecret = []byte("pb33f-offline-jwks-test-secret!!")
+
+// signToken creates a compact serialized JWT, signed with the supplied private key or HMAC secret.
+func signToken(t *testing.T, alg, kid string, key any, claims map[string]any) string {
+	header := map[string]string{"alg": alg, "typ": "JWT"}
+	if kid != "" {
+		header["kid"] = kid
+	}
+	h, _ := json.Marshal(header)
+	c, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c)
+
+	hash, _ := algorithmHash(alg)
+	var digest []byte
+	if hash != 0 {
+		d := hash.New()
+		d.Write([]byte(signed))
+		digest = d.Sum(nil)
+	}
+
+	var signature []byte
+	var err error
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(hash.New, k)
+		mac.Write([]byte(signed))
+		signature = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		if alg[:2] == "PS" {
+			signature, err = rsa.SignPSS(rand.Reader, k, hash, digest,
+				&rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		} else {
+			signature, err = rsa.SignPKCS1v15(rand.Reader, k, hash, digest)
+		}
+	case *ecdsa.PrivateKey:
+		r, s, e := ecdsa.Sign(rand.Reader, k, digest)
+		err = e
+		size := (k.Curve.Params().BitSize + 7) / 8
+		signature = append(r.FillBytes(make([]byte, size)), s.FillBytes(make([]byte, size))...)
+	case ed25519.PrivateKey:
+		signature = ed25519.Sign(k, []byte(signed))
+	}
+	require.NoError(t, err)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func authInput(token string, scopes ...string) *config.AuthenticationInput {
+	request, _ := http.NewRequest(http.MethodGet, "https://things.com/products", nil)
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	return &config.AuthenticationInput{Request: request, SchemeName: "OAuth", Scopes: scopes}
+}
+
+func authSubType(t *testing.T, err error) string {
+	var authErr *config.AuthenticationError
+	require.ErrorAs(t, err, &authErr)
+	return authErr.SubType
+}
+
+func TestJWTAuthenticator_Algorithms(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	ks := NewKeySet(
+		&Key{ID: "rsa", Key: &rsaKey.PublicKey},
+		&Key{ID: "ec", Key: &ecKey.PublicKey},
+		&Key{ID: "ed", Key: edKey.Public()},
+		&Key{ID: "hmac", Algorithm: "HS512", Key: testSecret},
+	)
+	a := NewJWTAuthenticator(ks)
+	claims := map[string]any{"sub": "pb33f"}
+
+	for _, tc := range []struct {
+		alg, kid string
+		key      any
+	}{
+		{"RS256", "rsa", rsaKey},
+		{"PS512", "rsa", rsaKey},
+		{"ES384", "ec", ecKey},
+		{"EdDSA", "ed", edKey},
+		{"HS512", "hmac", testSecret},
+		{"RS384", "", rsaKey},
+	} {
+		t.Run(tc.alg, func(t *testing.T) {
+			principal, err := a.Authenticate(authInput(signToken(t, tc.alg, tc.kid, tc.key, claims)))
+			require.NoError(t, err)
+			assert.Equal(t, "pb33f", principal.(Claims).Subject())
+		})
+	}
+
+	// the key is restricted to HS512.
+	_, err := a.Authenticate(authInput(signToken(t, "HS256", "hmac", testSecret, claims)))
+	assert.EqualError(t, err, "token signature could not be verified with key 'hmac'")
+	assert.Equal(t, helpers.SecurityInvalidToken, authSubType(t, err))
+
+	// an ES256 signature cannot be made with a P-384 key.
+	_, err = a.Authenticate(authInput(signToken(t, "ES256", "ec", ecKey, claims)))
+	assert.Equal(t, helpers.SecurityInvalidToken, authSubType(t, err))
+}
+
+func TestJWTAuthenticator_InvalidTokens(t *testing.T) {
+	ks, err := LoadJWKS("../test_specs/jwks.json")
+	require.NoError(t, err)
+	a := NewJWTAuthenticator(ks)
+
+	for name, tc := range map[string]struct {
+		token, reason string
+	}{
+		"parts":     {"abc.def", "token is not a JWT, it must have three parts separated by '.'"},
+		"header":    {"!!.e30.", "token header is not valid: not valid base64url"},
+		"claims":    {"e30.!!.", "token claims are not valid: not valid base64url"},
+		"signature": {"e30.e30.!!", "token signature is not valid base64url"},
+		"none":      {"eyJhbGciOiJub25lIn0.e30.", "token algorithm 'none' is not supported"},
+		"forged":    {signToken(t, "HS256", "pb33f-hmac", []byte("wrong"), nil), "token signature could not be verified with key 'pb33f-hmac'"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := a.Authenticate(authInput(tc.token))
+			assert.EqualError(t, err, tc.reason)
+			assert.Equal(t, helpers.SecurityInvalidToken, authSubType(t, err))
+		})
+	}
+}
+
+func TestJWTAuthenticator_Claims(t *testing.T) {
+	ks, err := LoadJWKS("../test_specs/jwks.json")
+	require.NoError(t, err)
+
+	now := time.Unix(1700000000, 0)
+	a := NewJWTAuthenticator(ks, WithClock(func() time.Time { return now }),
+		WithIssuer("https://auth.pb33f.io"), WithAudience("burgers"), WithLeeway(time.Minute))
+	token := func(claims map[string]any) string {
+		return signToken(t, "HS256", "pb33f-hmac", testSecret, claims)
+	}
+	valid := map[string]any{"iss": "https://auth.pb33f.io", "aud": []string{"fries", "burgers"}}
+
+	_, err = a.Authenticate(authInput(""))
+	assert.Equal(t, helpers.SecurityMissingToken, authSubType(t, err))
+
+	input := authInput("")
+	input.Request.Header.Set("Authorization", "Basic cGIzM2Y6cGIzM2Y=")
+	_, err = a.Authenticate(input)
+	assert.Equal(t, helpers.SecurityMissingToken, authSubType(t, err))
+
+	_, err = a.Authenticate(authInput(token(valid)))
+	assert.NoError(t, err)
+
+	// expired, but within the leeway.
+	_, err = a.Authenticate(authInput(token(map[string]any{"iss": valid["iss"], "aud": "burgers", "exp": now.Unix() - 30})))
+	assert.NoError(t, err)
+
+	_, err = a.Authenticate(authInput(token(map[string]any{"iss": valid["iss"], "aud": "burgers", "exp": now.Unix() - 60})))
+	assert.EqualError(t, err, "The bearer token expired at 2023-11-14T22:12:20Z")
+	assert.Equal(t, helpers.SecurityExpiredToken, authSubType(t, err))
+
+	_, err = a.Authenticate(authInput(token(map[string]any{"iss": valid["iss"], "aud": "burgers", "nbf": now.Unix() + 120})))
+	assert.EqualError(t, err, "The bearer token is not valid until 2023-11-14T22:15:20Z")
+	assert.Equal(t, helpers.SecurityInvalidToken, authSubType(t, err))
+
+	_, err = a.Authenticate(authInput(token(map[string]any{"iss": "https://evil.pb33f.io", "aud": "burgers"})))
+	assert.EqualError(t, err, "The bearer token was issued by 'https://evil.pb33f.io', not 'https://auth.pb33f.io'")
+
+	_, err = a.Authenticate(authInput(token(map[string]any{"iss": valid["iss"], "aud": "fries"})))
+	assert.EqualError(t, err, "The bearer token is not intended for audience 'burgers'")
+}
+
+func TestJWTAuthenticator_Scopes(t *testing.T) {
+	a := NewJWTAuthenticator(NewKeySet(&Key{Key: testSecret}))
+	token := func(claims map[string]any) string {
+		return signToken(t, "HS256", "", testSecret, claims)
+	}
+
+	_, err := a.Authenticate(authInput(token(map[string]any{"scope": "read:products write:products"}),
+		"read:products", "write:products"))
+	assert.NoError(t, err)
+
+	_, err = a.Authenticate(authInput(token(map[string]any{"scp": []string{"read:products", "write:products"}}),
+		"write:products"))
+	assert.NoError(t, err)
+
+	_, err = a.Authenticate(authInput(token(map[string]any{"scp": "read:products"}),
+		"read:products", "write:products", "delete:products"))
+	assert.EqualError(t, err, "The bearer token does not grant the scopes required by security scheme "+
+		"'OAuth': write:products, delete:products")
+	assert.Equal(t, helpers.SecurityInsufficientScope, authSubType(t, err))
+}
+
+func TestJWTAuthenticator_ParameterValidator(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /products:
+    get:
+      security:
+        - OAuth:
+          - read:products
+    post:
+      security:
+        - OIDC:
+          - write:products
+components:
+  securitySchemes:
+    OAuth:
+      type: oauth2
+      flows:
+        clientCredentials:
+          tokenUrl: https://things.com/token
+          scopes:
+            read:products: read
+            write:products: write
+    OIDC:
+      type: openIdConnect
+      openIdConnectUrl: https://things.com/.well-known/openid-configuration
+`
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+	m, _ := doc.BuildV3Model()
+
+	ks, err := LoadJWKS("../test_specs/jwks.json")
+	require.NoError(t, err)
+	auth := NewJWTAuthenticator(ks)
+	v := parameters.NewParameterValidator(&m.Model,
+		config.WithAuthenticator("oauth2", auth),
+		config.WithAuthenticator("openIdConnect", auth))
+
+	// there is no token.
+	request, _ := http.NewRequest(http.MethodGet, "https://things.com/products", nil)
+	valid, errs := v.ValidateSecurity(request)
+	assert.False(t, valid)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "security", errs[0].ValidationType)
+	assert.Equal(t, helpers.SecurityMissingToken, errs[0].ValidationSubType)
+
+	token := signToken(t, "HS256", "pb33f-hmac", testSecret, map[string]any{
+		"sub": "pb33f", "scope": "read:products", "exp": time.Now().Add(time.Hour).Unix(),
+	})
+	request.Header.Set("Authorization", "Bearer "+token)
+	result := v.ValidateSecurityResult(request)
+	assert.True(t, result.Valid)
+	assert.Equal(t, "pb33f", result.Principals["OAuth"].(Claims).Subject())
+
+	// the token does not grant 'write:products'.
+	request, _ = http.NewRequest(http.MethodPost, "https://things.com/products", nil)
+	request.Header.Set("Authorization", "Bearer "+token)
+	valid, errs = v.ValidateSecurity(request)
+	assert.False(t, valid)
+	require.Len(t, errs, 1)
+	assert.Equal(t, helpers.SecurityInsufficientScope, errs[0].ValidationSubType)
+	assert.Equal(t, "Request a token that grants the 'write:products' scopes", errs[0].HowToFix)
+
+	expired := signToken(t, "HS256", "pb33f-hmac", testSecret, map[string]any{
+		"scope": "read:products", "exp": time.Now().Add(-time.Hour).Unix(),
+	})
+	request, _ = http.NewRequest(http.MethodGet, "https://things.com/products", nil)
+	request.Header.Set("Authorization", "Bearer "+expired)
+	valid, errs = v.ValidateSecurity(request)
+	assert.False(t, valid)
+	require.Len(t, errs, 1)
+	assert.Equal(t, helpers.SecurityExpiredToken, errs[0].ValidationSubType)
+}
@@ -0,0 +1,13 @@
+// Copyright 2023-2024 Princess Beef Heavy Industries, LLC / Dave Shanley
+// https://pb33f.io
+
+// Package security contains authenticators that can be registered with the validator to verify the credentials
+// supplied for security schemes. JWTAuthenticator verifies bearer tokens offline against a JSON Web Key Set,
+// and checks the token carries the scopes required by the operation, for example:
+//
+//	keys, err := security.LoadJWKS("jwks.json")
+//	auth := security.NewJWTAuthenticator(keys, security.WithIssuer("https://auth.pb33f.io"))
+//	v, errs := validator.NewValidator(document,
+//		config.WithAuthenticator("oauth2", auth),
+//		config.WithAuthenticator("openIdConnect", auth))
+package security
@@ -4,16 +4,21 @@
 package validator
 
 import (
+	"context"
+	"fmt"
 	"net/http"
-	"sync"
 
 	"github.com/pb33f/libopenapi"
+	"github.com/pb33f/libopenapi/orderedmap"
 
 	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
 
+	"github.com/pb33f/libopenapi-validator/config"
 	"github.com/pb33f/libopenapi-validator/errors"
+	"github.com/pb33f/libopenapi-validator/helpers"
 	"github.com/pb33f/libopenapi-validator/parameters"
 	"github.com/pb33f/libopenapi-validator/paths"
+	"github.com/pb33f/libopenapi-validator/radix"
 	"github.com/pb33f/libopenapi-validator/requests"
 	"github.com/pb33f/libopenapi-validator/responses"
 	"github.com/pb33f/libopenapi-validator/schema_validation"
@@ -27,7 +32,8 @@ import (
 // Validating an OpenAPI 3+ document against the OpenAPI 3+ specification
 type Validator interface {
 	// ValidateHttpRequest will validate an *http.Request object against an OpenAPI 3+ document.
-	// The path, query, cookie and header parameters and request body are validated.
+	// The path, query, cookie and header parameters and request body are validated in parallel, by the workers of
+	// the validator (see config.WithConcurrency). Errors are returned in the same order as ValidateHttpRequestSync.
 	ValidateHttpRequest(request *http.Request) (bool, []*errors.ValidationError)
 	// ValidateHttpRequestSync will validate an *http.Request object against an OpenAPI 3+ document syncronously and without spawning any goroutines.
 	// The path, query, cookie and header parameters and request body are validated.
@@ -49,7 +55,33 @@ type Validator interface {
 	// The path, query, cookie and header parameters and request and response body are validated.
 	ValidateHttpRequestResponse(request *http.Request, response *http.Response) (bool, []*errors.ValidationError)
 
-	// ValidateDocument will validate an OpenAPI 3+ document against the 3.0 or 3.1 OpenAPI 3+ specification
+	// ValidateHttpRequestCtx will validate an *http.Request object in the same way as ValidateHttpRequest. Validation
+	// stops early once the context is done, and a single context error is returned. The context is supplied to
+	// schema loaders, authenticators, body decoders and content decoders.
+	ValidateHttpRequestCtx(ctx context.Context, request *http.Request) (bool, []*errors.ValidationError)
+
+	// ValidateHttpRequestSyncCtx will validate an *http.Request object in the same way as ValidateHttpRequestSync,
+	// using the context in the same way as ValidateHttpRequestCtx.
+	ValidateHttpRequestSyncCtx(ctx context.Context, request *http.Request) (bool, []*errors.ValidationError)
+
+	// ValidateHttpRequestWithPathItemCtx will validate an *http.Request object in the same way as
+	// ValidateHttpRequestWithPathItem, using the context in the same way as ValidateHttpRequestCtx.
+	ValidateHttpRequestWithPathItemCtx(ctx context.Context, request *http.Request, pathItem *v3.PathItem, pathValue string) (bool, []*errors.ValidationError)
+
+	// ValidateHttpRequestSyncWithPathItemCtx will validate an *http.Request object in the same way as
+	// ValidateHttpRequestSyncWithPathItem, using the context in the same way as ValidateHttpRequestCtx.
+	ValidateHttpRequestSyncWithPathItemCtx(ctx context.Context, request *http.Request, pathItem *v3.PathItem, pathValue string) (bool, []*errors.ValidationError)
+
+	// ValidateHttpResponseCtx will validate an *http.Response object in the same way as ValidateHttpResponse, using
+	// the context in the same way as ValidateHttpRequestCtx.
+	ValidateHttpResponseCtx(ctx context.Context, request *http.Request, response *http.Response) (bool, []*errors.ValidationError)
+
+	// ValidateHttpRequestResponseCtx will validate both the *http.Request and *http.Response objects in the same way
+	// as ValidateHttpRequestResponse, using the context in the same way as ValidateHttpRequestCtx.
+	ValidateHttpRequestResponseCtx(ctx context.Context, request *http.Request, response *http.Response) (bool, []*errors.ValidationError)
+
+	// ValidateDocument will validate an OpenAPI 3+ document against the 3.0 or 3.1 OpenAPI 3+ specification.
+	// Paths that can match the same request are reported as well, see GetPathAmbiguities.
 	ValidateDocument() (bool, []*errors.ValidationError)
 
 	// GetParameterValidator will return a parameters.ParameterValidator instance used to validate parameters
@@ -60,35 +92,110 @@ type Validator interface {
 
 	// GetResponseBodyValidator will return a parameters.ResponseBodyValidator instance used to validate response bodies
 	GetResponseBodyValidator() responses.ResponseBodyValidator
+
+	// GetPathAmbiguities will return every pair of paths in the document that can match the same request, without
+	// one being more specific than the other. Ambiguous paths are not fatal, but the document should be fixed.
+	GetPathAmbiguities() []*radix.Ambiguity
 }
 
-// NewValidator will create a new Validator from an OpenAPI 3+ document
-func NewValidator(document libopenapi.Document) (Validator, []error) {
+// NewValidator will create a new Validator from an OpenAPI 3+ document. Options can be supplied to change the
+// default behavior of the validator, they are passed down to every sub-validator.
+//
+// When config.WithEagerSchemaCompilation is supplied, every schema of the document is compiled before the validator
+// is returned, and the schemas that cannot be compiled are returned as *helpers.SchemaCompileError errors, instead
+// of a validator.
+func NewValidator(document libopenapi.Document, opts ...config.Option) (Validator, []error) {
 	m, errs := document.BuildV3Model()
 	if errs != nil {
 		return nil, errs
 	}
-	v := NewValidatorFromV3Model(&m.Model)
-	v.(*validator).document = document
+	v := newValidator(&m.Model, opts...)
+	v.document = document
+	if v.options.EagerSchemaCompilation {
+		if errs = v.compileSchemas(); len(errs) > 0 {
+			return nil, errs
+		}
+	}
 	return v, nil
 }
 
-// NewValidatorFromV3Model will create a new Validator from an OpenAPI Model
-func NewValidatorFromV3Model(m *v3.Document) Validator {
+// NewValidatorFromV3Model will create a new Validator from an OpenAPI Model. Options can be supplied to change the
+// default behavior of the validator, they are passed down to every sub-validator.
+//
+// When config.WithEagerSchemaCompilation is supplied, every schema of the document is compiled before the validator
+// is returned, and the schemas that cannot be compiled are logged. Use NewValidator to have them returned.
+func NewValidatorFromV3Model(m *v3.Document, opts ...config.Option) Validator {
+	v := newValidator(m, opts...)
+	if v.options.EagerSchemaCompilation {
+		for _, err := range v.compileSchemas() {
+			v.options.Logger.Error("unable to compile schema", "error", err.Error())
+		}
+	}
+	return v
+}
+
+// schemaCompiler is implemented by the sub-validators that cache compiled schemas, so every schema of the document
+// can be compiled up front.
+type schemaCompiler interface {
+	CompileSchemas() []error
+}
+
+// compileSchemas compiles every schema of the document using the sub-validators, which compile in parallel. The
+// errors are returned in a stable order: parameters, then request bodies, then responses.
+func (v *validator) compileSchemas() []error {
+	var errs []error
+	for _, sub := range []any{v.paramValidator, v.requestValidator, v.responseValidator} {
+		if compiler, ok := sub.(schemaCompiler); ok {
+			errs = append(errs, compiler.CompileSchemas()...)
+		}
+	}
+	return errs
+}
+
+func newValidator(m *v3.Document, opts ...config.Option) *validator {
+	options := config.NewValidationOptions(opts...)
+
+	// compile the paths of the document once, the tree is shared by all the sub-validators.
+	if options.PathTree == nil {
+		options.PathTree = radix.NewPathTree(m)
+	}
+
+	// the schema cache is shared by all the sub-validators, so a schema is only compiled once.
+	if options.SchemaCache == nil {
+		options.SchemaCache = config.NewLRUSchemaCache(config.DefaultSchemaCacheSize)
+	}
+
+	// ambiguous paths are not fatal, the most specific path wins, but the document should be fixed. They are
+	// reported by ValidateDocument, and logged as warnings.
+	for _, ambiguity := range options.PathTree.Ambiguities() {
+		options.Logger.Warn("ambiguous path in document", "path", ambiguity.Path,
+			"conflictsWith", ambiguity.ConflictsWith, "reason", ambiguity.String())
+	}
+
 	// create a new parameter validator
-	paramValidator := parameters.NewParameterValidator(m)
+	paramValidator := parameters.NewParameterValidator(m, config.WithExistingOpts(options))
 
 	// create a new request body validator
-	reqBodyValidator := requests.NewRequestBodyValidator(m)
+	reqBodyValidator := requests.NewRequestBodyValidator(m, config.WithExistingOpts(options))
 
 	// create a response body validator
-	respBodyValidator := responses.NewResponseBodyValidator(m)
+	respBodyValidator := responses.NewResponseBodyValidator(m, config.WithExistingOpts(options))
 
 	return &validator{
+		options:           options,
 		v3Model:           m,
 		requestValidator:  reqBodyValidator,
 		responseValidator: respBodyValidator,
 		paramValidator:    paramValidator,
+		pool:              helpers.NewWorkerPool(options.Concurrency),
+		requestValidations: []validationFunction{
+			paramValidator.ValidatePathParamsWithPathItemCtx,
+			paramValidator.ValidateCookieParamsWithPathItemCtx,
+			paramValidator.ValidateHeaderParamsWithPathItemCtx,
+			paramValidator.ValidateQueryParamsWithPathItemCtx,
+			paramValidator.ValidateSecurityWithPathItemCtx,
+			reqBodyValidator.ValidateRequestBodyWithPathItemCtx,
+		},
 	}
 }
 
@@ -104,238 +211,234 @@ func (v *validator) GetResponseBodyValidator() responses.ResponseBodyValidator {
 	return v.responseValidator
 }
 
+func (v *validator) GetPathAmbiguities() []*radix.Ambiguity {
+	return v.options.PathTree.Ambiguities()
+}
+
 func (v *validator) ValidateDocument() (bool, []*errors.ValidationError) {
-	return schema_validation.ValidateOpenAPIDocument(v.document)
+	valid, validationErrors := schema_validation.ValidateOpenAPIDocument(v.document, config.WithExistingOpts(v.options))
+	// ambiguous paths pass the specification schema, but can match the same request.
+	for _, ambiguity := range v.options.PathTree.Ambiguities() {
+		validationErrors = append(validationErrors, v.pathAmbiguityError(ambiguity))
+		valid = false
+	}
+	return valid, validationErrors
+}
+
+// pathAmbiguityError converts an ambiguity between two paths of the document into a validation error, located at
+// the later of the two paths.
+func (v *validator) pathAmbiguityError(ambiguity *radix.Ambiguity) *errors.ValidationError {
+	validationError := &errors.ValidationError{
+		ValidationType:    helpers.Schema,
+		ValidationSubType: helpers.PathAmbiguity,
+		Message:           fmt.Sprintf("Path '%s' is ambiguous with '%s'", ambiguity.Path, ambiguity.ConflictsWith),
+		Reason:            fmt.Sprintf("The %s", ambiguity.String()),
+		HowToFix:          errors.HowToFixPathAmbiguity,
+		SpecPath:          ambiguity.Path,
+	}
+	if v.v3Model != nil && v.v3Model.Paths != nil && v.v3Model.Paths.GoLow() != nil {
+		for pair := orderedmap.First(v.v3Model.Paths.GoLow().PathItems); pair != nil; pair = pair.Next() {
+			if pair.Key().Value == ambiguity.Path && pair.Key().KeyNode != nil {
+				validationError.SpecLine = pair.Key().KeyNode.Line
+				validationError.SpecCol = pair.Key().KeyNode.Column
+				break
+			}
+		}
+	}
+	return validationError
 }
 
 func (v *validator) ValidateHttpResponse(
 	request *http.Request,
 	response *http.Response,
 ) (bool, []*errors.ValidationError) {
-	var pathItem *v3.PathItem
-	var pathValue string
-	var errs []*errors.ValidationError
+	return v.ValidateHttpResponseCtx(context.Background(), request, response)
+}
 
-	pathItem, errs, pathValue = paths.FindPath(request, v.v3Model)
-	if pathItem == nil || errs != nil {
-		return false, errs
-	}
+func (v *validator) ValidateHttpResponseCtx(
+	ctx context.Context,
+	request *http.Request,
+	response *http.Response,
+) (bool, []*errors.ValidationError) {
+	return errors.ValidateWithContext(ctx, request, func() (bool, []*errors.ValidationError) {
+		var pathItem *v3.PathItem
+		var pathValue string
+		var errs []*errors.ValidationError
+
+		pathItem, errs, pathValue = paths.FindPath(request, v.v3Model, config.WithExistingOpts(v.options))
+		if pathItem == nil || errs != nil {
+			return false, errs
+		}
 
-	responseBodyValidator := v.responseValidator
+		responseBodyValidator := v.responseValidator
 
-	// validate response
-	_, responseErrors := responseBodyValidator.ValidateResponseBodyWithPathItem(request, response, pathItem, pathValue)
+		// validate response
+		_, responseErrors := responseBodyValidator.ValidateResponseBodyWithPathItemCtx(ctx, request, response,
+			pathItem, pathValue)
 
-	if len(responseErrors) > 0 {
-		return false, responseErrors
-	}
-	return true, nil
+		if len(responseErrors) > 0 {
+			return false, responseErrors
+		}
+		return true, nil
+	})
 }
 
 func (v *validator) ValidateHttpRequestResponse(
 	request *http.Request,
 	response *http.Response,
 ) (bool, []*errors.ValidationError) {
-	var pathItem *v3.PathItem
-	var pathValue string
-	var errs []*errors.ValidationError
+	return v.ValidateHttpRequestResponseCtx(context.Background(), request, response)
+}
 
-	pathItem, errs, pathValue = paths.FindPath(request, v.v3Model)
-	if pathItem == nil || errs != nil {
-		return false, errs
-	}
+func (v *validator) ValidateHttpRequestResponseCtx(
+	ctx context.Context,
+	request *http.Request,
+	response *http.Response,
+) (bool, []*errors.ValidationError) {
+	return errors.ValidateWithContext(ctx, request, func() (bool, []*errors.ValidationError) {
+		var pathItem *v3.PathItem
+		var pathValue string
+		var errs []*errors.ValidationError
+
+		pathItem, errs, pathValue = paths.FindPath(request, v.v3Model, config.WithExistingOpts(v.options))
+		if pathItem == nil || errs != nil {
+			return false, errs
+		}
 
-	responseBodyValidator := v.responseValidator
+		responseBodyValidator := v.responseValidator
 
-	// validate request and response
-	_, requestErrors := v.ValidateHttpRequestWithPathItem(request, pathItem, pathValue)
-	_, responseErrors := responseBodyValidator.ValidateResponseBodyWithPathItem(request, response, pathItem, pathValue)
+		// validate request and response
+		_, requestErrors := v.validateHttpRequest(ctx, request, pathItem, pathValue)
+		_, responseErrors := responseBodyValidator.ValidateResponseBodyWithPathItemCtx(ctx, request, response,
+			pathItem, pathValue)
 
-	if len(requestErrors) > 0 || len(responseErrors) > 0 {
-		return false, append(requestErrors, responseErrors...)
-	}
-	return true, nil
+		if len(requestErrors) > 0 || len(responseErrors) > 0 {
+			return false, append(requestErrors, responseErrors...)
+		}
+		return true, nil
+	})
 }
 
 func (v *validator) ValidateHttpRequest(request *http.Request) (bool, []*errors.ValidationError) {
-	pathItem, errs, foundPath := paths.FindPath(request, v.v3Model)
-	if len(errs) > 0 {
-		return false, errs
-	}
-	return v.ValidateHttpRequestWithPathItem(request, pathItem, foundPath)
+	return v.ValidateHttpRequestCtx(context.Background(), request)
 }
 
-func (v *validator) ValidateHttpRequestWithPathItem(request *http.Request, pathItem *v3.PathItem, pathValue string) (bool, []*errors.ValidationError) {
-	// create a new parameter validator
-	paramValidator := v.paramValidator
-
-	// create a new request body validator
-	reqBodyValidator := v.requestValidator
-
-	// create some channels to handle async validation
-	doneChan := make(chan struct{})
-	errChan := make(chan []*errors.ValidationError)
-	controlChan := make(chan struct{})
-
-	// async param validation function.
-	parameterValidationFunc := func(control chan struct{}, errorChan chan []*errors.ValidationError) {
-		paramErrs := make(chan []*errors.ValidationError)
-		paramControlChan := make(chan struct{})
-		paramFunctionControlChan := make(chan struct{})
-		var paramValidationErrors []*errors.ValidationError
-
-		validations := []validationFunction{
-			paramValidator.ValidatePathParamsWithPathItem,
-			paramValidator.ValidateCookieParamsWithPathItem,
-			paramValidator.ValidateHeaderParamsWithPathItem,
-			paramValidator.ValidateQueryParamsWithPathItem,
-			paramValidator.ValidateSecurityWithPathItem,
-		}
-
-		// listen for validation errors on parameters. everything will run async.
-		paramListener := func(control chan struct{}, errorChan chan []*errors.ValidationError) {
-			completedValidations := 0
-			for {
-				select {
-				case vErrs := <-errorChan:
-					paramValidationErrors = append(paramValidationErrors, vErrs...)
-				case <-control:
-					completedValidations++
-					if completedValidations == len(validations) {
-						paramFunctionControlChan <- struct{}{}
-						return
-					}
-				}
-			}
-		}
-
-		validateParamFunction := func(
-			control chan struct{},
-			errorChan chan []*errors.ValidationError,
-			validatorFunc validationFunction,
-		) {
-			valid, pErrs := validatorFunc(request, pathItem, pathValue)
-			if !valid {
-				errorChan <- pErrs
-			}
-			control <- struct{}{}
-		}
-		go paramListener(paramControlChan, paramErrs)
-		for i := range validations {
-			go validateParamFunction(paramControlChan, paramErrs, validations[i])
+func (v *validator) ValidateHttpRequestCtx(ctx context.Context, request *http.Request) (bool, []*errors.ValidationError) {
+	return errors.ValidateWithContext(ctx, request, func() (bool, []*errors.ValidationError) {
+		pathItem, errs, foundPath := paths.FindPath(request, v.v3Model, config.WithExistingOpts(v.options))
+		if len(errs) > 0 {
+			return false, errs
 		}
+		return v.validateHttpRequest(ctx, request, pathItem, foundPath)
+	})
+}
 
-		// wait for all the validations to complete
-		<-paramFunctionControlChan
-		if len(paramValidationErrors) > 0 {
-			errorChan <- paramValidationErrors
-		}
+func (v *validator) ValidateHttpRequestWithPathItem(request *http.Request, pathItem *v3.PathItem, pathValue string) (bool, []*errors.ValidationError) {
+	return v.ValidateHttpRequestWithPathItemCtx(context.Background(), request, pathItem, pathValue)
+}
 
-		// let runValidation know we are done with this part.
-		controlChan <- struct{}{}
-	}
+func (v *validator) ValidateHttpRequestWithPathItemCtx(
+	ctx context.Context,
+	request *http.Request,
+	pathItem *v3.PathItem,
+	pathValue string,
+) (bool, []*errors.ValidationError) {
+	return errors.ValidateWithContext(ctx, request, func() (bool, []*errors.ValidationError) {
+		return v.validateHttpRequest(ctx, request, pathItem, pathValue)
+	})
+}
 
-	requestBodyValidationFunc := func(control chan struct{}, errorChan chan []*errors.ValidationError) {
-		valid, pErrs := reqBodyValidator.ValidateRequestBodyWithPathItem(request, pathItem, pathValue)
-		if !valid {
-			errorChan <- pErrs
+// validateHttpRequest validates the parameters and body of a request in parallel, using the worker pool of the
+// validator. The errors are reported in the same order as validateHttpRequestSync, whatever order the validations
+// finish in. The validations stop early once the context is done, as they are supplied with the same context.
+func (v *validator) validateHttpRequest(
+	ctx context.Context,
+	request *http.Request,
+	pathItem *v3.PathItem,
+	pathValue string,
+) (bool, []*errors.ValidationError) {
+	results := make([][]*errors.ValidationError, len(v.requestValidations))
+	jobs := make([]func(), len(v.requestValidations))
+	for i, validate := range v.requestValidations {
+		jobs[i] = func() {
+			if valid, errs := validate(ctx, request, pathItem, pathValue); !valid {
+				results[i] = errs
+			}
 		}
-		control <- struct{}{}
-	}
-
-	// build async functions
-	asyncFunctions := []validationFunctionAsync{
-		parameterValidationFunc,
-		requestBodyValidationFunc,
 	}
+	v.pool.Run(jobs)
 
 	var validationErrors []*errors.ValidationError
-
-	// sit and wait for everything to report back.
-	go runValidation(controlChan, doneChan, errChan, &validationErrors, len(asyncFunctions))
-
-	// run async functions
-	for i := range asyncFunctions {
-		go asyncFunctions[i](controlChan, errChan)
+	for _, errs := range results {
+		validationErrors = append(validationErrors, errs...)
 	}
-
-	// wait for all the validations to complete
-	<-doneChan
 	return !(len(validationErrors) > 0), validationErrors
 }
 
 func (v *validator) ValidateHttpRequestSync(request *http.Request) (bool, []*errors.ValidationError) {
-	pathItem, errs, foundPath := paths.FindPath(request, v.v3Model)
-	if len(errs) > 0 {
-		return false, errs
-	}
-	return v.ValidateHttpRequestSyncWithPathItem(request, pathItem, foundPath)
+	return v.ValidateHttpRequestSyncCtx(context.Background(), request)
+}
+
+func (v *validator) ValidateHttpRequestSyncCtx(ctx context.Context, request *http.Request) (bool, []*errors.ValidationError) {
+	return errors.ValidateWithContext(ctx, request, func() (bool, []*errors.ValidationError) {
+		pathItem, errs, foundPath := paths.FindPath(request, v.v3Model, config.WithExistingOpts(v.options))
+		if len(errs) > 0 {
+			return false, errs
+		}
+		return v.validateHttpRequestSync(ctx, request, pathItem, foundPath)
+	})
 }
 
 func (v *validator) ValidateHttpRequestSyncWithPathItem(request *http.Request, pathItem *v3.PathItem, pathValue string) (bool, []*errors.ValidationError) {
-	// create a new parameter validator
-	paramValidator := v.paramValidator
+	return v.ValidateHttpRequestSyncWithPathItemCtx(context.Background(), request, pathItem, pathValue)
+}
 
-	// create a new request body validator
-	reqBodyValidator := v.requestValidator
+func (v *validator) ValidateHttpRequestSyncWithPathItemCtx(
+	ctx context.Context,
+	request *http.Request,
+	pathItem *v3.PathItem,
+	pathValue string,
+) (bool, []*errors.ValidationError) {
+	return errors.ValidateWithContext(ctx, request, func() (bool, []*errors.ValidationError) {
+		return v.validateHttpRequestSync(ctx, request, pathItem, pathValue)
+	})
+}
 
+// validateHttpRequestSync validates the parameters and body of a request one after the other, no more validations
+// are run once the context is done.
+func (v *validator) validateHttpRequestSync(
+	ctx context.Context,
+	request *http.Request,
+	pathItem *v3.PathItem,
+	pathValue string,
+) (bool, []*errors.ValidationError) {
 	validationErrors := make([]*errors.ValidationError, 0)
-
-	paramValidationErrors := make([]*errors.ValidationError, 0)
-	for _, validateFunc := range []validationFunction{
-		paramValidator.ValidatePathParamsWithPathItem,
-		paramValidator.ValidateCookieParamsWithPathItem,
-		paramValidator.ValidateHeaderParamsWithPathItem,
-		paramValidator.ValidateQueryParamsWithPathItem,
-		paramValidator.ValidateSecurityWithPathItem,
-	} {
-		valid, pErrs := validateFunc(request, pathItem, pathValue)
+	for _, validate := range v.requestValidations {
+		if err := ctx.Err(); err != nil {
+			return false, []*errors.ValidationError{errors.ContextError(request, err)}
+		}
+		valid, vErrs := validate(ctx, request, pathItem, pathValue)
 		if !valid {
-			paramValidationErrors = append(paramValidationErrors, pErrs...)
+			validationErrors = append(validationErrors, vErrs...)
 		}
 	}
-
-	valid, pErrs := reqBodyValidator.ValidateRequestBodyWithPathItem(request, pathItem, pathValue)
-	if !valid {
-		paramValidationErrors = append(paramValidationErrors, pErrs...)
-	}
-
-	validationErrors = append(validationErrors, paramValidationErrors...)
 	return !(len(validationErrors) > 0), validationErrors
 }
 
 type validator struct {
+	options           *config.ValidationOptions
 	v3Model           *v3.Document
 	document          libopenapi.Document
 	paramValidator    parameters.ParameterValidator
 	requestValidator  requests.RequestBodyValidator
 	responseValidator responses.ResponseBodyValidator
-}
 
-func runValidation(control, doneChan chan struct{},
-	errorChan chan []*errors.ValidationError,
-	validationErrors *[]*errors.ValidationError,
-	total int,
-) {
-	var validationLock sync.Mutex
-	completedValidations := 0
-	for {
-		select {
-		case vErrs := <-errorChan:
-			validationLock.Lock()
-			*validationErrors = append(*validationErrors, vErrs...)
-			validationLock.Unlock()
-		case <-control:
-			completedValidations++
-			if completedValidations == total {
-				doneChan <- struct{}{}
-				return
-			}
-		}
-	}
+	// pool runs the validations of requests in parallel, its workers are shared by every request.
+	pool *helpers.WorkerPool
+
+	// requestValidations are the validations of a request, in the order their errors are reported.
+	requestValidations []validationFunction
 }
 
-type (
-	validationFunction      func(request *http.Request, pathItem *v3.PathItem, pathValue string) (bool, []*errors.ValidationError)
-	validationFunctionAsync func(control chan struct{}, errorChan chan []*errors.ValidationError)
-)
+// validationFunction validates one part of a request, such as its query parameters or its body.
+type validationFunction func(ctx context.Context, request *http.Request, pathItem *v3.PathItem, pathValue string) (bool, []*errors.ValidationError)
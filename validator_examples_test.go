@@ -83,8 +83,9 @@ func ExampleNewValidator_validateHttpRequest() {
 			fmt.Printf("Type: %s, Failure: %s\n", e.ValidationType, e.Message)
 		}
 	}
-	// Output: Type: security, Failure: API Key api_key not found in header
-	// Type: parameter, Failure: Path parameter 'petId' is not a valid number
+	// Output: Type: parameter, Failure: Path parameter 'petId' is not a valid number
+	// Type: security, Failure: API Key api_key not found in header
+	// Type: security, Failure: Authorization header for 'oauth2' scheme
 }
 
 func ExampleNewValidator_validateHttpRequestSync() {
@@ -120,8 +121,9 @@ func ExampleNewValidator_validateHttpRequestSync() {
 			fmt.Printf("Type: %s, Failure: %s\n", e.ValidationType, e.Message)
 		}
 	}
-	// Type: parameter, Failure: Path parameter 'petId' is not a valid number
-	// Output: Type: security, Failure: API Key api_key not found in header
+	// Output: Type: parameter, Failure: Path parameter 'petId' is not a valid number
+	// Type: security, Failure: API Key api_key not found in header
+	// Type: security, Failure: Authorization header for 'oauth2' scheme
 }
 
 func ExampleNewValidator_validateHttpRequestResponse() {
@@ -147,6 +149,7 @@ func ExampleNewValidator_validateHttpRequestResponse() {
 
 	// 6. Create a new *http.Request (normally, this would be where the host application will pass in the request)
 	request, _ := http.NewRequest(http.MethodGet, "/pet/findByStatus?status=sold", nil)
+	request.Header.Set("Authorization", "Bearer petstore-token")
 
 	// 7. Simulate a request/response, in this case the contract returns a 200 with an array of pets.
 	// Normally, this would be where the host application would pass in the response.
@@ -5,12 +5,17 @@ package validator
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/pb33f/libopenapi"
 	"github.com/stretchr/testify/assert"
@@ -18,6 +23,8 @@ import (
 
 	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
 
+	"github.com/pb33f/libopenapi-validator/config"
+	"github.com/pb33f/libopenapi-validator/errors"
 	"github.com/pb33f/libopenapi-validator/helpers"
 )
 
@@ -668,6 +675,7 @@ func TestNewValidator_PetStore_MissingContentType(t *testing.T) {
 	request, _ := http.NewRequest(http.MethodPut, "https://hyperspace-superherbs.com/pet",
 		bytes.NewBuffer(bodyBytes))
 	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(helpers.AuthorizationHeader, "Bearer petstore-token")
 
 	// simulate a request/response, in this case the contract returns a 200 with the pet we just created.
 	res := httptest.NewRecorder()
@@ -719,6 +727,7 @@ func TestNewValidator_PetStore_PetPost200_Valid_PathPreset(t *testing.T) {
 	request, _ := http.NewRequest(http.MethodPut, "https://hyperspace-superherbs.com/pet",
 		bytes.NewBuffer(bodyBytes))
 	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(helpers.AuthorizationHeader, "Bearer petstore-token")
 
 	// simulate a request/response, in this case the contract returns a 200 with the pet we just created.
 	res := httptest.NewRecorder()
@@ -764,6 +773,7 @@ func TestNewValidator_PetStore_PetPost200_Valid(t *testing.T) {
 	request, _ := http.NewRequest(http.MethodPut, "https://hyperspace-superherbs.com/pet",
 		bytes.NewBuffer(bodyBytes))
 	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(helpers.AuthorizationHeader, "Bearer petstore-token")
 
 	// simulate a request/response, in this case the contract returns a 200 with the pet we just created.
 	res := httptest.NewRecorder()
@@ -808,6 +818,7 @@ func TestNewValidator_PetStore_PetPost200_Invalid(t *testing.T) {
 	request, _ := http.NewRequest(http.MethodPost, "https://hyperspace-superherbs.com/pet",
 		bytes.NewBuffer(bodyBytes))
 	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(helpers.AuthorizationHeader, "Bearer petstore-token")
 
 	// simulate a request/response, in this case the contract returns a 200 with the pet we just created.
 	res := httptest.NewRecorder()
@@ -862,6 +873,7 @@ func TestNewValidator_PetStore_PetFindByStatusGet200_Valid(t *testing.T) {
 	request, _ := http.NewRequest(http.MethodGet,
 		"https://hyperspace-superherbs.com/pet/findByStatus?status=sold", nil)
 	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(helpers.AuthorizationHeader, "Bearer petstore-token")
 
 	// simulate a request/response, in this case the contract returns a 200 with the pet we just created.
 	res := httptest.NewRecorder()
@@ -907,6 +919,7 @@ func TestNewValidator_PetStore_PetFindByStatusGet200_BadEnum(t *testing.T) {
 	request, _ := http.NewRequest(http.MethodGet,
 		"https://hyperspace-superherbs.com/pet/findByStatus?status=invalidEnum", nil) // enum is invalid
 	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(helpers.AuthorizationHeader, "Bearer petstore-token")
 
 	// simulate a request/response, in this case the contract returns a 200 with a pet
 	res := httptest.NewRecorder()
@@ -955,6 +968,7 @@ func TestNewValidator_PetStore_PetFindByTagsGet200_Valid(t *testing.T) {
 	request, _ := http.NewRequest(http.MethodGet,
 		"https://hyperspace-superherbs.com/pet/findByTags?tags=fuzzy&tags=wuzzy", nil)
 	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(helpers.AuthorizationHeader, "Bearer petstore-token")
 
 	// simulate a request/response, in this case the contract returns a 200 with the pet we just created.
 	res := httptest.NewRecorder()
@@ -1000,6 +1014,7 @@ func TestNewValidator_PetStore_PetFindByTagsGet200_InvalidExplode(t *testing.T)
 	request, _ := http.NewRequest(http.MethodGet,
 		"https://hyperspace-superherbs.com/pet/findByTags?tags=fuzzy,wuzzy", nil)
 	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(helpers.AuthorizationHeader, "Bearer petstore-token")
 
 	// simulate a request/response
 	res := httptest.NewRecorder()
@@ -1106,9 +1121,10 @@ func TestNewValidator_PetStore_PetGet200_PathNotFound(t *testing.T) {
 	valid, errors := v.ValidateHttpRequestResponse(request, res.Result())
 
 	assert.False(t, valid)
-	assert.Len(t, errors, 2)
-	assert.Equal(t, "API Key api_key not found in header", errors[0].Message)
-	assert.Equal(t, "Path parameter 'petId' is not a valid number", errors[1].Message)
+	assert.Len(t, errors, 3)
+	assert.Equal(t, "Path parameter 'petId' is not a valid number", errors[0].Message)
+	assert.Equal(t, "API Key api_key not found in header", errors[1].Message)
+	assert.Equal(t, "Authorization header for 'oauth2' scheme", errors[2].Message)
 }
 
 func TestNewValidator_PetStore_PetGet200(t *testing.T) {
@@ -1216,6 +1232,7 @@ func TestNewValidator_PetStore_PetWithIdPost200_Missing200(t *testing.T) {
 	request, _ := http.NewRequest(http.MethodPost,
 		"https://hyperspace-superherbs.com/pet/112233?name=peter&query=thing", nil)
 	request.Header.Set(helpers.ContentTypeHeader, helpers.JSONContentType)
+	request.Header.Set(helpers.AuthorizationHeader, "Bearer petstore-token")
 
 	// simulate a request/response, in this case the contract returns a 200 with the pet we just created.
 	res := httptest.NewRecorder()
@@ -1246,6 +1263,7 @@ func TestNewValidator_PetStore_UploadImage200_InvalidRequestBodyType(t *testing.
 	request, _ := http.NewRequest(http.MethodPost,
 		"https://hyperspace-superherbs.com/pet/112233/uploadImage?additionalMetadata=blem", nil)
 	request.Header.Set(helpers.ContentTypeHeader, helpers.JSONContentType)
+	request.Header.Set(helpers.AuthorizationHeader, "Bearer petstore-token")
 
 	// simulate a request/response, in this case the contract returns a 200 with the pet we just created.
 	res := httptest.NewRecorder()
@@ -1276,6 +1294,7 @@ func TestNewValidator_PetStore_UploadImage200_Valid(t *testing.T) {
 	request, _ := http.NewRequest(http.MethodPost,
 		"https://hyperspace-superherbs.com/pet/112233/uploadImage?additionalMetadata=blem", nil)
 	request.Header.Set(helpers.ContentTypeHeader, "application/octet-stream")
+	request.Header.Set(helpers.AuthorizationHeader, "Bearer petstore-token")
 
 	// simulate a request/response, in this case the contract returns a 200 with the pet we just created.
 	res := httptest.NewRecorder()
@@ -1317,6 +1336,7 @@ func TestNewValidator_PetStore_UploadImage200_InvalidAPIResponse(t *testing.T) {
 	request, _ := http.NewRequest(http.MethodPost,
 		"https://hyperspace-superherbs.com/pet/112233/uploadImage?additionalMetadata=blem", nil)
 	request.Header.Set(helpers.ContentTypeHeader, "application/octet-stream")
+	request.Header.Set(helpers.AuthorizationHeader, "Bearer petstore-token")
 
 	// simulate a request/response, in this case the contract returns a 200 with the pet we just created.
 	res := httptest.NewRecorder()
@@ -1510,3 +1530,748 @@ paths:
 	assert.True(t, valid)
 	assert.Len(t, errors, 0)
 }
+
+func TestNewValidator_WithFormatAssertions(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /burgers/{burgerId}:
+    post:
+      parameters:
+        - name: burgerId
+          in: path
+          required: true
+          schema:
+            type: string
+            format: uuid
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                email:
+                  type: string
+                  format: email`
+
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+
+	buildRequest := func() *http.Request {
+		request, _ := http.NewRequest(http.MethodPost, "https://things.com/burgers/not-a-uuid",
+			bytes.NewBufferString(`{"email": "not-an-email"}`))
+		request.Header.Set("Content-Type", "application/json")
+		return request
+	}
+
+	// formats are annotations by default.
+	v, _ := NewValidator(doc)
+	valid, errs := v.ValidateHttpRequestSync(buildRequest())
+	assert.True(t, valid)
+	assert.Len(t, errs, 0)
+
+	// with format assertions enabled, both the parameter and the body fail.
+	v, _ = NewValidator(doc, config.WithFormatAssertions())
+	valid, errs = v.ValidateHttpRequestSync(buildRequest())
+	assert.False(t, valid)
+	assert.Len(t, errs, 2)
+
+	// options are handed down to each sub-validator.
+	valid, errs = v.GetParameterValidator().ValidatePathParams(buildRequest())
+	assert.False(t, valid)
+	assert.Len(t, errs, 1)
+
+	valid, errs = v.GetRequestBodyValidator().ValidateRequestBody(buildRequest())
+	assert.False(t, valid)
+	assert.Len(t, errs, 1)
+}
+
+func TestNewValidator_AmbiguousPathsWarning(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /burgers/{burgerId}:
+    get:
+      operationId: getBurger
+  /burgers/{name}:
+    post:
+      operationId: createBurger
+  /burgers/special:
+    get:
+      operationId: specialBurger
+`
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	v, errs := NewValidator(doc, config.WithLogger(logger))
+	require.Empty(t, errs)
+
+	assert.Contains(t, logs.String(), "ambiguous path in document")
+	assert.Contains(t, logs.String(), "path=/burgers/{name} conflictsWith=/burgers/{burgerId}")
+	assert.NotContains(t, logs.String(), "/burgers/special")
+
+	// the ambiguities are available without a logger.
+	ambiguities := v.GetPathAmbiguities()
+	require.Len(t, ambiguities, 1)
+	assert.Equal(t, "/burgers/{name}", ambiguities[0].Path)
+	assert.Equal(t, "/burgers/{burgerId}", ambiguities[0].ConflictsWith)
+	assert.True(t, ambiguities[0].Identical)
+
+	// the literal path wins over the templated paths.
+	request, _ := http.NewRequest(http.MethodGet, "https://things.com/burgers/special", nil)
+	valid, validationErrs := v.ValidateHttpRequest(request)
+	assert.True(t, valid)
+	assert.Empty(t, validationErrs)
+}
+
+func TestNewValidator_ValidateDocument_AmbiguousPaths(t *testing.T) {
+	spec := `openapi: 3.1.0
+info:
+  title: Burgers
+  version: 1.0.0
+paths:
+  /burgers/{burgerId}:
+    get:
+      operationId: getBurger
+  /burgers/{name}:
+    post:
+      operationId: createBurger
+`
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+	v, errs := NewValidator(doc)
+	require.Empty(t, errs)
+
+	// the ambiguities are reported with the default logger, which only logs errors.
+	valid, validationErrs := v.ValidateDocument()
+	assert.False(t, valid)
+	require.Len(t, validationErrs, 1)
+	assert.Equal(t, helpers.Schema, validationErrs[0].ValidationType)
+	assert.Equal(t, helpers.PathAmbiguity, validationErrs[0].ValidationSubType)
+	assert.Equal(t, "Path '/burgers/{name}' is ambiguous with '/burgers/{burgerId}'", validationErrs[0].Message)
+	assert.Equal(t, "The path '/burgers/{name}' is identical to '/burgers/{burgerId}', only the template names differ",
+		validationErrs[0].Reason)
+	assert.Equal(t, errors.HowToFixPathAmbiguity, validationErrs[0].HowToFix)
+	assert.Equal(t, 9, validationErrs[0].SpecLine)
+	assert.Equal(t, 3, validationErrs[0].SpecCol)
+}
+
+func TestNewValidator_EagerSchemaCompilation(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /burgers/{burgerId}:
+    parameters:
+      - name: burgerId
+        in: path
+        required: true
+        schema:
+          type: integer
+    get:
+      parameters:
+        - name: sauce
+          in: query
+          schema:
+            type: string
+            pattern: "["
+      responses:
+        '200':
+          headers:
+            X-Calories:
+              schema:
+                type: string
+                pattern: "["
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Burger'
+    put:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                name:
+                  type: string
+                  pattern: "["
+      responses:
+        default:
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Burger'
+components:
+  schemas:
+    Burger:
+      type: object
+      properties:
+        name:
+          type: string`
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+
+	// schemas are compiled when they are first used, so a broken schema only fails validation.
+	v, errs := NewValidator(doc)
+	require.Empty(t, errs)
+	require.NotNil(t, v)
+
+	// eager compilation reports every broken schema, in the order of the document.
+	v, errs = NewValidator(doc, config.WithEagerSchemaCompilation())
+	assert.Nil(t, v)
+	require.Len(t, errs, 3)
+
+	var locations []string
+	for _, err := range errs {
+		var compileErr *helpers.SchemaCompileError
+		require.ErrorAs(t, err, &compileErr)
+		assert.Equal(t, "/burgers/{burgerId}", compileErr.Path)
+		locations = append(locations, compileErr.Method+" "+compileErr.Location)
+	}
+	assert.Equal(t, []string{
+		"GET query parameter 'sauce'",
+		"PUT request body 'application/json'",
+		"GET '200' response header 'X-Calories'",
+	}, locations)
+	assert.Contains(t, errs[0].Error(), "the schema of the query parameter 'sauce' of GET /burgers/{burgerId} "+
+		"cannot be compiled: failed to compile schema 'sauce'")
+}
+
+func TestNewValidator_EagerSchemaCompilation_Valid(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /burgers/{burgerId}:
+    post:
+      parameters:
+        - name: burgerId
+          in: path
+          required: true
+          schema:
+            type: integer
+            minimum: 1
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Burger'
+      responses:
+        '200':
+          headers:
+            X-Calories:
+              schema:
+                type: integer
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Burger'
+components:
+  schemas:
+    Burger:
+      type: object
+      required: [name]
+      properties:
+        name:
+          type: string`
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+	v, errs := NewValidator(doc, config.WithEagerSchemaCompilation())
+	require.Empty(t, errs)
+
+	request, _ := http.NewRequest(http.MethodPost, "https://things.com/burgers/1",
+		bytes.NewBufferString(`{"name": "Big Mac"}`))
+	request.Header.Set("Content-Type", "application/json")
+	valid, validationErrs := v.ValidateHttpRequest(request)
+	assert.True(t, valid)
+	assert.Empty(t, validationErrs)
+
+	request, _ = http.NewRequest(http.MethodPost, "https://things.com/burgers/0",
+		bytes.NewBufferString(`{}`))
+	request.Header.Set("Content-Type", "application/json")
+	valid, validationErrs = v.ValidateHttpRequest(request)
+	assert.False(t, valid)
+	assert.Len(t, validationErrs, 2)
+
+	response := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"application/json"}, "X-Calories": {"lots"}},
+		Body:       io.NopCloser(bytes.NewBufferString(`{"name": "Big Mac"}`)),
+	}
+	valid, validationErrs = v.ValidateHttpResponse(request, response)
+	assert.False(t, valid)
+	assert.Len(t, validationErrs, 1)
+}
+
+func TestNewValidatorFromV3Model_EagerSchemaCompilation(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /burgers:
+    get:
+      parameters:
+        - name: sauce
+          in: query
+          schema:
+            type: string
+            pattern: "["`
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+	m, _ := doc.BuildV3Model()
+
+	// without a way to return the errors, the broken schemas are logged.
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+	v := NewValidatorFromV3Model(&m.Model, config.WithEagerSchemaCompilation(), config.WithLogger(logger))
+	assert.NotNil(t, v)
+	assert.Contains(t, logs.String(), "unable to compile schema")
+	assert.Contains(t, logs.String(), "query parameter 'sauce' of GET /burgers")
+}
+
+func TestNewValidator_SharedSchemaCache(t *testing.T) {
+	burgers := `openapi: 3.1.0
+paths:
+  /burgers:
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [name]
+              properties:
+                name:
+                  type: string`
+	fries := `openapi: 3.1.0
+paths:
+  /fries:
+    get:
+      summary: a path that is not shared
+  /burgers:
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [name]
+              properties:
+                name:
+                  type: string`
+
+	cache := config.NewLRUSchemaCache(100)
+	validate := func(spec, body string) bool {
+		doc, _ := libopenapi.NewDocument([]byte(spec))
+		v, errs := NewValidator(doc, config.WithSchemaCache(cache))
+		require.Empty(t, errs)
+		request, _ := http.NewRequest(http.MethodPost, "https://things.com/burgers", bytes.NewBufferString(body))
+		request.Header.Set("Content-Type", "application/json")
+		valid, _ := v.ValidateHttpRequest(request)
+		return valid
+	}
+
+	assert.True(t, validate(burgers, `{"name": "Big Mac"}`))
+	entries := cache.Len()
+	assert.NotZero(t, entries)
+	hits := cache.Stats().Hits
+
+	// the second validator uses the schema compiled by the first.
+	assert.False(t, validate(fries, `{"patties": 2}`))
+	assert.Equal(t, entries, cache.Len())
+	assert.Greater(t, cache.Stats().Hits, hits)
+
+	// a cleared cache compiles the schema again.
+	cache.Clear()
+	assert.True(t, validate(fries, `{"name": "Whopper"}`))
+	assert.Equal(t, entries, cache.Len())
+}
+
+func TestNewValidator_SharedSchemaCache_SameReference(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /burgers:
+    post:
+      parameters:
+        - name: size
+          in: query
+          required: true
+          schema:
+            $ref: '#/components/schemas/Size'
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Burger'
+      responses:
+        '200':
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Burger'
+components:
+  schemas:
+    Size:
+      %s
+    Burger:
+      type: object
+      required: [%s]`
+
+	// both documents use the same references, to different schemas.
+	burgers := fmt.Sprintf(spec, "type: integer", "name")
+	fries := fmt.Sprintf(spec, "enum: [small, large]", "salt")
+
+	cache := config.NewLRUSchemaCache(100)
+	validate := func(spec, size, body string) (bool, bool) {
+		doc, _ := libopenapi.NewDocument([]byte(spec))
+		v, errs := NewValidator(doc, config.WithSchemaCache(cache))
+		require.Empty(t, errs)
+		request, _ := http.NewRequest(http.MethodPost, "https://things.com/burgers?size="+size,
+			bytes.NewBufferString(body))
+		request.Header.Set("Content-Type", "application/json")
+		requestValid, _ := v.ValidateHttpRequestSync(request)
+
+		response := &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(bytes.NewBufferString(body)),
+		}
+		responseValid, _ := v.ValidateHttpResponse(request, response)
+		return requestValid, responseValid
+	}
+
+	requestValid, responseValid := validate(burgers, "2", `{"name": "Big Mac"}`)
+	assert.True(t, requestValid)
+	assert.True(t, responseValid)
+
+	// the schemas compiled for the first document are not used for the second.
+	requestValid, responseValid = validate(fries, "large", `{"salt": true}`)
+	assert.True(t, requestValid)
+	assert.True(t, responseValid)
+
+	requestValid, responseValid = validate(fries, "2", `{"name": "Big Mac"}`)
+	assert.False(t, requestValid)
+	assert.False(t, responseValid)
+}
+
+func TestNewValidator_SharedSchemaCache_CompileOptions(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /burgers:
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                email:
+                  type: string
+                  format: email`
+
+	cache := config.NewLRUSchemaCache(100)
+	validate := func(opts ...config.Option) bool {
+		doc, _ := libopenapi.NewDocument([]byte(spec))
+		v, errs := NewValidator(doc, append(opts, config.WithSchemaCache(cache))...)
+		require.Empty(t, errs)
+		request, _ := http.NewRequest(http.MethodPost, "https://things.com/burgers",
+			bytes.NewBufferString(`{"email": "not an email"}`))
+		request.Header.Set("Content-Type", "application/json")
+		valid, _ := v.ValidateHttpRequestSync(request)
+		return valid
+	}
+
+	// the same schema is compiled again when the format assertions differ.
+	assert.True(t, validate())
+	assert.False(t, validate(config.WithFormatAssertions()))
+}
+
+func TestNewValidator_SchemaCacheEviction(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /burgers/{burgerId}:
+    post:
+      parameters:
+        - name: burgerId
+          in: path
+          required: true
+          schema:
+            type: integer
+            minimum: 1
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [name]`
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+
+	// a cache of a single entry is always evicting, but validation is unaffected.
+	cache := config.NewLRUSchemaCache(1)
+	v, errs := NewValidator(doc, config.WithSchemaCache(cache))
+	require.Empty(t, errs)
+
+	for range 3 {
+		request, _ := http.NewRequest(http.MethodPost, "https://things.com/burgers/0", bytes.NewBufferString(`{}`))
+		request.Header.Set("Content-Type", "application/json")
+		valid, validationErrs := v.ValidateHttpRequestSync(request)
+		assert.False(t, valid)
+		assert.Len(t, validationErrs, 2)
+	}
+	assert.Equal(t, 1, cache.Len())
+	assert.NotZero(t, cache.Stats().Evictions)
+}
+
+func TestNewValidator_ValidateHttpRequestCtx(t *testing.T) {
+	spec := `openapi: 3.1.0
+paths:
+  /burgers/{burgerId}:
+    post:
+      security:
+        - ApiKeyAuth: []
+      parameters:
+        - name: burgerId
+          in: path
+          required: true
+          schema:
+            type: integer
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [name]
+      responses:
+        '200':
+          content:
+            application/json:
+              schema:
+                type: object
+                required: [name]
+components:
+  securitySchemes:
+    ApiKeyAuth:
+      type: apiKey
+      in: header
+      name: X-API-Key`
+	doc, _ := libopenapi.NewDocument([]byte(spec))
+
+	// the authenticator cancels the validation when it is given the cancel key.
+	type key struct{}
+	var authCtx context.Context
+	v, errs := NewValidator(doc, config.WithAuthenticator("ApiKeyAuth",
+		config.AuthenticatorFunc(func(in *config.AuthenticationInput) (any, error) {
+			authCtx = in.Context
+			if cancel, ok := in.Context.Value(key{}).(context.CancelFunc); ok {
+				cancel()
+			}
+			return "pb33f", nil
+		})))
+	require.Empty(t, errs)
+
+	newRequest := func() *http.Request {
+		request, _ := http.NewRequest(http.MethodPost, "https://things.com/burgers/1",
+			bytes.NewBufferString(`{"name": "Big Mac"}`))
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("X-API-Key", "1234")
+		return request
+	}
+	newResponse := func() *http.Response {
+		res := httptest.NewRecorder()
+		res.Header().Set(helpers.ContentTypeHeader, "application/json")
+		res.WriteHeader(http.StatusOK)
+		_, _ = res.Write([]byte(`{"name": "Big Mac"}`))
+		return res.Result()
+	}
+
+	validations := map[string]func(ctx context.Context) (bool, []*errors.ValidationError){
+		"async": func(ctx context.Context) (bool, []*errors.ValidationError) {
+			return v.ValidateHttpRequestCtx(ctx, newRequest())
+		},
+		"sync": func(ctx context.Context) (bool, []*errors.ValidationError) {
+			return v.ValidateHttpRequestSyncCtx(ctx, newRequest())
+		},
+		"response": func(ctx context.Context) (bool, []*errors.ValidationError) {
+			return v.ValidateHttpResponseCtx(ctx, newRequest(), newResponse())
+		},
+		"requestResponse": func(ctx context.Context) (bool, []*errors.ValidationError) {
+			return v.ValidateHttpRequestResponseCtx(ctx, newRequest(), newResponse())
+		},
+	}
+
+	for name, validate := range validations {
+		t.Run(name, func(t *testing.T) {
+			valid, validationErrs := validate(context.Background())
+			assert.True(t, valid)
+			assert.Empty(t, validationErrs)
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+			defer cancel()
+			<-ctx.Done()
+			valid, validationErrs = validate(ctx)
+			assert.False(t, valid)
+			require.Len(t, validationErrs, 1)
+			assert.True(t, validationErrs[0].IsContextError())
+			assert.ErrorIs(t, validationErrs[0], context.DeadlineExceeded)
+		})
+	}
+
+	// the validation is cancelled part of the way through, by the authenticator.
+	for _, validate := range []func(context.Context, *http.Request) (bool, []*errors.ValidationError){
+		v.ValidateHttpRequestCtx,
+		v.ValidateHttpRequestSyncCtx,
+	} {
+		ctx, cancel := context.WithCancel(context.Background())
+		ctx = context.WithValue(ctx, key{}, cancel)
+		valid, validationErrs := validate(ctx, newRequest())
+		assert.False(t, valid)
+		require.Len(t, validationErrs, 1)
+		assert.ErrorIs(t, validationErrs[0], context.Canceled)
+		assert.Equal(t, http.MethodPost, validationErrs[0].RequestMethod)
+		assert.NotNil(t, authCtx.Value(key{}))
+	}
+}
+
+const requestOrderSpec = `openapi: 3.1.0
+paths:
+  /burgers/{burgerId}:
+    post:
+      security:
+        - ApiKeyAuth: []
+      parameters:
+        - name: burgerId
+          in: path
+          required: true
+          schema:
+            type: integer
+        - name: session
+          in: cookie
+          required: true
+          schema:
+            type: integer
+        - name: X-Kitchen
+          in: header
+          required: true
+          schema:
+            type: string
+        - name: fresh
+          in: query
+          required: true
+          schema:
+            type: boolean
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [name]
+              properties:
+                name:
+                  type: string
+components:
+  securitySchemes:
+    ApiKeyAuth:
+      type: apiKey
+      in: header
+      name: X-API-Key`
+
+func TestNewValidator_ValidateHttpRequest_ErrorOrder(t *testing.T) {
+	doc, _ := libopenapi.NewDocument([]byte(requestOrderSpec))
+
+	for _, concurrency := range []int{0, 1, 16} {
+		v, errs := NewValidator(doc, config.WithConcurrency(concurrency))
+		require.Empty(t, errs)
+
+		newRequest := func() *http.Request {
+			request, _ := http.NewRequest(http.MethodPost, "https://things.com/burgers/big", bytes.NewBufferString(`{}`))
+			request.Header.Set("Content-Type", "application/json")
+			request.AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+			return request
+		}
+
+		// every part of the request fails, the errors are always in the same order as the sync validation.
+		_, expected := v.ValidateHttpRequestSync(newRequest())
+		require.Len(t, expected, 6)
+		assert.Equal(t, helpers.ParameterValidationPath, expected[0].ValidationSubType)
+		assert.Equal(t, helpers.ParameterValidationCookie, expected[1].ValidationSubType)
+		assert.Equal(t, helpers.ParameterValidationHeader, expected[2].ValidationSubType)
+		assert.Equal(t, helpers.ParameterValidationQuery, expected[3].ValidationSubType)
+		assert.Equal(t, "security", expected[4].ValidationType)
+		assert.Equal(t, helpers.RequestBodyValidation, expected[5].ValidationType)
+
+		var wg sync.WaitGroup
+		for range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range 10 {
+					valid, validationErrs := v.ValidateHttpRequest(newRequest())
+					assert.False(t, valid)
+					require.Len(t, validationErrs, len(expected))
+					for i := range expected {
+						assert.Equal(t, expected[i].Message, validationErrs[i].Message)
+					}
+				}
+			}()
+		}
+		wg.Wait()
+	}
+}
+
+func benchmarkValidator(b *testing.B) (Validator, func() *http.Request) {
+	doc, _ := libopenapi.NewDocument([]byte(requestOrderSpec))
+	v, errs := NewValidator(doc)
+	require.Empty(b, errs)
+
+	body := []byte(`{"name": "Big Mac"}`)
+	request, _ := http.NewRequest(http.MethodPost, "https://things.com/burgers/1?fresh=true", nil)
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("X-Kitchen", "main")
+	request.Header.Set("X-API-Key", "1234")
+	request.AddCookie(&http.Cookie{Name: "session", Value: "42"})
+	return v, func() *http.Request {
+		r := request.Clone(context.Background())
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		return r
+	}
+}
+
+func BenchmarkValidator_ValidateHttpRequest(b *testing.B) {
+	v, newRequest := benchmarkValidator(b)
+	if valid, errs := v.ValidateHttpRequest(newRequest()); !valid {
+		b.Fatal(errs)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		v.ValidateHttpRequest(newRequest())
+	}
+}
+
+func BenchmarkValidator_ValidateHttpRequestSync(b *testing.B) {
+	v, newRequest := benchmarkValidator(b)
+	if valid, errs := v.ValidateHttpRequestSync(newRequest()); !valid {
+		b.Fatal(errs)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		v.ValidateHttpRequestSync(newRequest())
+	}
+}
+
+func BenchmarkValidator_ValidateHttpRequest_Parallel(b *testing.B) {
+	v, newRequest := benchmarkValidator(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			v.ValidateHttpRequest(newRequest())
+		}
+	})
+}
+
+func BenchmarkValidator_ValidateHttpRequestSync_Parallel(b *testing.B) {
+	v, newRequest := benchmarkValidator(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			v.ValidateHttpRequestSync(newRequest())
+		}
+	})
+}